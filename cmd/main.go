@@ -6,14 +6,17 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
 	"calendar-sync/pkg"
 	"calendar-sync/pkg/container"
+	"calendar-sync/pkg/logs"
 	"calendar-sync/pkg/tasks/activities"
+	"calendar-sync/pkg/tasks/queue"
+	"calendar-sync/pkg/tasks/scheduler"
+	"calendar-sync/pkg/tasks/temporal"
 	"calendar-sync/pkg/tasks/workflows"
 	"calendar-sync/pkg/www"
 )
@@ -22,32 +25,6 @@ var CommitSHA = "unknown"
 var CommitRef = "unknown"
 var BuildDate = "unknown"
 
-type job struct {
-	workflowID string
-	workflow   func(ctx context.Context, w *workflows.Workflows) error
-}
-
-var jobs = []job{
-	{
-		workflow: func(ctx context.Context, w *workflows.Workflows) error {
-			return w.CopyAllWorkflow(ctx)
-		},
-		workflowID: "hourly-sync-check",
-	},
-	{
-		workflowID: "hourly-invite-check",
-		workflow: func(ctx context.Context, w *workflows.Workflows) error {
-			return w.InviteAllWorkflow(ctx)
-		},
-	},
-	{
-		workflowID: "hourly-webhook-check",
-		workflow: func(ctx context.Context, w *workflows.Workflows) error {
-			return w.WatchAll(ctx)
-		},
-	},
-}
-
 var rootCmd = &cobra.Command{
 	Use:     "",
 	Version: fmt.Sprintf("SHA:%s, build:%s, ref:%s", CommitSHA, BuildDate, CommitRef),
@@ -80,36 +57,44 @@ var rootCmd = &cobra.Command{
 
 		go startWebserver(ctr, w, cfg.Listen, errs)
 
+		q := queue.New(ctr.Database, w.Jobs())
 		go func() {
-			triggerScheduledJobs(ctx, w, true, jobs)
-
-			// run cron job every 24 hours.
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case <-time.After(time.Hour):
-					triggerScheduledJobs(ctx, w, false, jobs)
-				}
+			if err := q.Run(logs.SetLogger(ctx, ctr.Logger), cfg.QueueWorkers); err != nil {
+				errs <- err
 			}
 		}()
 
+		if cfg.TemporalHostPort != "" {
+			log.Info().Str("host-port", cfg.TemporalHostPort).Msg("scheduling jobs on temporal")
+			go func() {
+				if err := temporal.Run(logs.SetLogger(ctx, ctr.Logger), cfg, w, a); err != nil {
+					errs <- err
+				}
+			}()
+		} else {
+			jobScheduler, err := scheduler.New(ctr.Database, scheduler.Specs(cfg.Schedules), cfg.ScheduleJitter, scheduledJobs(w)...)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			go func() {
+				if err := jobScheduler.Run(logs.SetLogger(ctx, ctr.Logger)); err != nil {
+					errs <- err
+				}
+			}()
+		}
+
 		log.Info().Msg("waiting for interrupts ...")
 		waitForInterrupt(ctx, errs)
 	},
 }
 
-func triggerScheduledJobs(ctx context.Context, w *workflows.Workflows, now bool, jobs []job) {
-	for _, job := range jobs {
-		jobID := job.workflowID
-		if now {
-			jobID += "-init"
-		}
-
-		log.Info().Msgf("trigger scheduled job: %s", jobID)
-		if err := job.workflow(ctx, w); err != nil {
-			log.Err(err).Msgf("failed to trigger %q job", jobID)
-		}
+func scheduledJobs(w *workflows.Workflows) []scheduler.Job {
+	return []scheduler.Job{
+		{Name: scheduler.JobCopyAll, Run: w.CopyAllWorkflow},
+		{Name: scheduler.JobInviteAll, Run: w.InviteAllWorkflow},
+		{Name: scheduler.JobWatchRenewal, Run: w.WatchAll},
+		{Name: scheduler.JobCleanup, Run: w.CleanupWorkflow},
 	}
 }
 
@@ -20,9 +20,24 @@ type Config struct {
 	WebhookUrl        string `env:"CS_WEBHOOK_URL"`
 	RedirectURL       string `env:"CS_REDIRECT_URL" envDefault:"http://localhost:31425/auth/end"`
 
+	CalDAVURL      string `env:"CS_CALDAV_URL"`
+	CalDAVUsername string `env:"CS_CALDAV_USERNAME"`
+	CalDAVPassword string `env:"CS_CALDAV_PASSWORD"`
+
 	DatabaseDriver string `env:"CS_DATABASE_DRIVER" envDefault:"sqlite3"`
 	DatabaseSource string `env:"CS_DATABASE_SOURCE" envDefault:"./database.db"`
 
+	QueueWorkers int `env:"CS_QUEUE_WORKERS" envDefault:"4"`
+
+	// Schedules overrides the schedules of jobs, like "copy-all=@every 30m;cleanup=0 3 * * *" or "cleanup=off".
+	Schedules      map[string]string `env:"CS_SCHEDULES" envSeparator:";" envKeyValSeparator:"="`
+	ScheduleJitter time.Duration     `env:"CS_SCHEDULE_JITTER" envDefault:"1m"`
+
+	// TemporalHostPort schedules the periodic workflows on a Temporal server, instead of running them in-process.
+	TemporalHostPort  string `env:"CS_TEMPORAL_HOST_PORT"`
+	TemporalNamespace string `env:"CS_TEMPORAL_NAMESPACE" envDefault:"default"`
+	TemporalTaskQueue string `env:"CS_TEMPORAL_TASK_QUEUE" envDefault:"calendar-sync"`
+
 	JwtAlgorithm string        `env:"JWT_ALGORITHM" envDefault:"HS256"`
 	JwtDuration  time.Duration `env:"JWT_DURATION" envDefault:"24h"`
 	JwtIssuer    string        `env:"JWT_ISSUER" envDefault:"calendar-sync-web"`
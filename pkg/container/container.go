@@ -15,6 +15,10 @@ import (
 	"calendar-sync/pkg"
 	"calendar-sync/pkg/logs"
 	"calendar-sync/pkg/persistence/sqlite"
+	"calendar-sync/pkg/providers"
+	"calendar-sync/pkg/providers/caldav"
+	"calendar-sync/pkg/providers/google"
+	"calendar-sync/pkg/providers/ics"
 )
 
 type Container struct {
@@ -22,6 +26,7 @@ type Container struct {
 	Database     *sqlite.Database
 	OAuth2Config *oauth2.Config
 	Logger       zerolog.Logger
+	Provider     providers.Provider
 }
 
 func (c Container) Close() {
@@ -46,6 +51,23 @@ func New(ctx context.Context, cfg pkg.Config) (Container, error) {
 		return ctr, errors.Wrap(err, "failed to read client secrets")
 	}
 
+	router := providers.NewRouter(google.New(ctr.GetCalendarClient))
+
+	if cfg.CalDAVURL != "" {
+		caldavProvider, err := caldav.New(cfg.CalDAVURL, cfg.CalDAVUsername, cfg.CalDAVPassword)
+		if err != nil {
+			return ctr, errors.Wrap(err, "failed to create caldav provider")
+		}
+		router.Register(caldav.CalendarIDPrefix, caldavProvider)
+	}
+
+	feeds := ics.New(&http.Client{Transport: addLogger(http.DefaultTransport)})
+	for _, prefix := range ics.CalendarIDPrefixes {
+		router.Register(prefix, feeds)
+	}
+
+	ctr.Provider = router
+
 	return ctr, nil
 }
 
@@ -0,0 +1,147 @@
+package copygraph
+
+import (
+	"slices"
+	"sort"
+
+	"calendar-sync/pkg/persistence"
+)
+
+// Edge is a copy config, events flow from From to To. Two-way edges also flow back.
+type Edge struct {
+	From, To      string
+	Bidirectional bool
+}
+
+func FromConfigs(configs []persistence.CopyConfig) []Edge {
+	edges := make([]Edge, 0, len(configs))
+	for _, config := range configs {
+		edges = append(edges, Edge{From: config.SourceID, To: config.DestinationID, Bidirectional: config.Bidirectional})
+	}
+	return edges
+}
+
+// FindCycle returns the calendars events would cycle through if edge was added, starting and ending at the same
+// calendar, or nil if adding it is safe.
+func FindCycle(edges []Edge, edge Edge) []string {
+	next := adjacency(edges)
+
+	if path := findPath(next, edge.To, edge.From); path != nil {
+		return append([]string{edge.From}, path...)
+	}
+
+	// events also flow back along a two-way edge
+	if edge.Bidirectional {
+		if path := findPath(next, edge.From, edge.To); path != nil {
+			return append([]string{edge.To}, path...)
+		}
+	}
+
+	return nil
+}
+
+func findPath(next map[string][]string, from, to string) []string {
+	visited := make(map[string]bool)
+
+	var walk func(calendarID string) []string
+	walk = func(calendarID string) []string {
+		if calendarID == to {
+			return []string{to}
+		}
+		if visited[calendarID] {
+			return nil
+		}
+		visited[calendarID] = true
+
+		for _, n := range next[calendarID] {
+			if path := walk(n); path != nil {
+				return append([]string{calendarID}, path...)
+			}
+		}
+		return nil
+	}
+
+	return walk(from)
+}
+
+func adjacency(edges []Edge) map[string][]string {
+	next := make(map[string][]string)
+	for _, e := range edges {
+		next[e.From] = append(next[e.From], e.To)
+		if e.Bidirectional {
+			next[e.To] = append(next[e.To], e.From)
+		}
+	}
+	return next
+}
+
+// Node is a calendar in the tree of copies. Calendars copied into more than one calendar appear more than once.
+type Node struct {
+	CalendarID    string
+	Bidirectional bool
+	// Cycle is set on a calendar which has already appeared higher up the tree, whose children aren't repeated.
+	Cycle    bool
+	Children []Node
+}
+
+// Build returns trees of the calendars which are copied from, rooted at calendars nothing is copied into.
+func Build(edges []Edge) []Node {
+	children := make(map[string][]Edge)
+	isDestination := make(map[string]bool)
+	var calendarIDs []string
+	for _, e := range edges {
+		children[e.From] = append(children[e.From], e)
+		isDestination[e.To] = true
+		calendarIDs = append(calendarIDs, e.From)
+	}
+
+	var roots []string
+	for _, calendarID := range calendarIDs {
+		if !isDestination[calendarID] && !slices.Contains(roots, calendarID) {
+			roots = append(roots, calendarID)
+		}
+	}
+	// calendars which are only part of cycles have no root, start from any of them
+	covered := make(map[string]bool)
+	var cover func(calendarID string)
+	cover = func(calendarID string) {
+		if covered[calendarID] {
+			return
+		}
+		covered[calendarID] = true
+		for _, e := range children[calendarID] {
+			cover(e.To)
+		}
+	}
+	for _, root := range roots {
+		cover(root)
+	}
+	sort.Strings(calendarIDs)
+	for _, calendarID := range calendarIDs {
+		if !covered[calendarID] {
+			roots = append(roots, calendarID)
+			cover(calendarID)
+		}
+	}
+
+	var build func(edge Edge, path []string) Node
+	build = func(edge Edge, path []string) Node {
+		node := Node{CalendarID: edge.To, Bidirectional: edge.Bidirectional}
+		if slices.Contains(path, edge.To) {
+			node.Cycle = true
+			return node
+		}
+
+		path = append(path, edge.To)
+		for _, child := range children[edge.To] {
+			node.Children = append(node.Children, build(child, path))
+		}
+		return node
+	}
+
+	nodes := make([]Node, 0, len(roots))
+	for _, root := range roots {
+		nodes = append(nodes, build(Edge{To: root}, nil))
+	}
+	return nodes
+}
@@ -0,0 +1,81 @@
+package copygraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindCycle(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		edges    []Edge
+		edge     Edge
+		expected []string
+	}{
+		"first copy": {
+			edge: Edge{From: "a", To: "b"},
+		},
+		"chain": {
+			edges: []Edge{{From: "a", To: "b"}},
+			edge:  Edge{From: "b", To: "c"},
+		},
+		"fan in": {
+			edges: []Edge{{From: "a", To: "c"}, {From: "a", To: "b"}},
+			edge:  Edge{From: "b", To: "c"},
+		},
+		"ping pong": {
+			edges:    []Edge{{From: "a", To: "b"}},
+			edge:     Edge{From: "b", To: "a"},
+			expected: []string{"b", "a", "b"},
+		},
+		"long cycle": {
+			edges:    []Edge{{From: "a", To: "b"}, {From: "b", To: "c"}},
+			edge:     Edge{From: "c", To: "a"},
+			expected: []string{"c", "a", "b", "c"},
+		},
+		"two-way": {
+			edge: Edge{From: "a", To: "b", Bidirectional: true},
+		},
+		"two-way closes a cycle backwards": {
+			edges:    []Edge{{From: "a", To: "c"}, {From: "c", To: "b"}},
+			edge:     Edge{From: "a", To: "b", Bidirectional: true},
+			expected: []string{"b", "a", "c", "b"},
+		},
+	}
+
+	for key, tc := range testcases {
+		t.Run(key, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, FindCycle(tc.edges, tc.edge))
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	nodes := Build([]Edge{
+		{From: "a", To: "b"},
+		{From: "b", To: "c", Bidirectional: true},
+		// cycles configured before they were refused
+		{From: "x", To: "y"},
+		{From: "y", To: "x"},
+	})
+
+	expected := []Node{
+		{CalendarID: "a", Children: []Node{
+			{CalendarID: "b", Children: []Node{
+				{CalendarID: "c", Bidirectional: true},
+			}},
+		}},
+		{CalendarID: "x", Children: []Node{
+			{CalendarID: "y", Children: []Node{
+				{CalendarID: "x", Cycle: true},
+			}},
+		}},
+	}
+	assert.Equal(t, expected, nodes)
+}
@@ -0,0 +1,224 @@
+package filters
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/persistence"
+)
+
+type matcher func(e *calendar.Event) bool
+
+// Filter decides which events are copied, see persistence.FilterRule.
+type Filter struct {
+	include, exclude []matcher
+}
+
+// New checks and compiles the rules. A filter without rules matches every event.
+func New(rules []persistence.FilterRule) (Filter, error) {
+	var f Filter
+
+	for _, rule := range rules {
+		m, err := newMatcher(rule.Field, rule.Value)
+		if err != nil {
+			return f, errors.Wrapf(err, "invalid %s rule", rule.Field)
+		}
+
+		switch rule.Action {
+		case persistence.FilterInclude:
+			f.include = append(f.include, m)
+		case persistence.FilterExclude:
+			f.exclude = append(f.exclude, m)
+		default:
+			return f, errors.Errorf("unknown filter action %q", rule.Action)
+		}
+	}
+
+	return f, nil
+}
+
+// Exclude adds a rule which isn't configured by the user.
+func (f Filter) Exclude(fn func(e *calendar.Event) bool) Filter {
+	f.exclude = append(slices.Clip(f.exclude), fn)
+	return f
+}
+
+func (f Filter) Matches(e *calendar.Event) bool {
+	for _, m := range f.include {
+		if !m(e) {
+			return false
+		}
+	}
+
+	for _, m := range f.exclude {
+		if m(e) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newMatcher(field persistence.FilterField, value string) (matcher, error) {
+	switch field {
+	case persistence.FilterSummary:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to compile regular expression")
+		}
+		return func(e *calendar.Event) bool { return re.MatchString(e.Summary) }, nil
+
+	case persistence.FilterEventType:
+		types := splitList(value)
+		return func(e *calendar.Event) bool { return slices.Contains(types, eventType(e)) }, nil
+
+	case persistence.FilterResponseStatus:
+		statuses := splitList(value)
+		return func(e *calendar.Event) bool { return slices.Contains(statuses, responseStatus(e)) }, nil
+
+	case persistence.FilterTransparency:
+		if value != "opaque" && value != "transparent" {
+			return nil, errors.Errorf("expected opaque or transparent, got %q", value)
+		}
+		return func(e *calendar.Event) bool { return transparency(e) == value }, nil
+
+	case persistence.FilterAllDay:
+		if value != "true" && value != "false" {
+			return nil, errors.Errorf("expected true or false, got %q", value)
+		}
+		allDay := value == "true"
+		return func(e *calendar.Event) bool { return isAllDay(e) == allDay }, nil
+
+	case persistence.FilterWeekday:
+		var days []time.Weekday
+		for _, name := range splitList(value) {
+			day, ok := weekdays[name]
+			if !ok {
+				return nil, errors.Errorf("unknown weekday %q", name)
+			}
+			days = append(days, day)
+		}
+		return func(e *calendar.Event) bool {
+			start, ok := startTime(e)
+			return ok && slices.Contains(days, start.Weekday())
+		}, nil
+
+	case persistence.FilterTimeOfDay:
+		from, to, err := parseTimeRange(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(e *calendar.Event) bool {
+			if isAllDay(e) {
+				return false
+			}
+			start, ok := startTime(e)
+			if !ok {
+				return false
+			}
+			minutes := start.Hour()*60 + start.Minute()
+			if from > to {
+				// the range crosses midnight
+				return minutes >= from || minutes < to
+			}
+			return minutes >= from && minutes < to
+		}, nil
+
+	default:
+		return nil, errors.Errorf("unknown filter field %q", field)
+	}
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, strings.ToLower(item))
+		}
+	}
+	return items
+}
+
+// parseTimeRange returns the minutes since midnight of a range like "09:00-17:00". Ranges like "22:00-06:00" cross
+// midnight.
+func parseTimeRange(value string) (int, int, error) {
+	fromStr, toStr, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, 0, errors.Errorf("expected a range like 09:00-17:00, got %q", value)
+	}
+
+	from, err := time.Parse("15:04", strings.TrimSpace(fromStr))
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to parse start of range")
+	}
+	to, err := time.Parse("15:04", strings.TrimSpace(toStr))
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to parse end of range")
+	}
+
+	fromMinutes, toMinutes := from.Hour()*60+from.Minute(), to.Hour()*60+to.Minute()
+	if fromMinutes == toMinutes {
+		return 0, 0, errors.Errorf("range %q is empty", value)
+	}
+
+	return fromMinutes, toMinutes, nil
+}
+
+func eventType(e *calendar.Event) string {
+	if e.EventType == "" {
+		return "default"
+	}
+	return strings.ToLower(e.EventType)
+}
+
+// responseStatus is the calendar owner's response. Events without attendees only exist on the organizer's calendar,
+// so they count as accepted.
+func responseStatus(e *calendar.Event) string {
+	for _, attendee := range e.Attendees {
+		if attendee.Self {
+			return strings.ToLower(attendee.ResponseStatus)
+		}
+	}
+	return "accepted"
+}
+
+func transparency(e *calendar.Event) string {
+	if e.Transparency == "" {
+		return "opaque"
+	}
+	return e.Transparency
+}
+
+func isAllDay(e *calendar.Event) bool {
+	return e.Start != nil && e.Start.DateTime == "" && e.Start.Date != ""
+}
+
+// startTime keeps the offset the event was written with, so weekdays and times are local to the event.
+func startTime(e *calendar.Event) (time.Time, bool) {
+	if e.Start == nil {
+		return time.Time{}, false
+	}
+
+	if e.Start.DateTime != "" {
+		t, err := time.Parse(time.RFC3339, e.Start.DateTime)
+		return t, err == nil
+	}
+
+	t, err := time.Parse(time.DateOnly, e.Start.Date)
+	return t, err == nil
+}
@@ -0,0 +1,119 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/persistence"
+)
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	// 2024-06-01 is a saturday
+	meeting := &calendar.Event{
+		Summary: "Standup",
+		Start:   &calendar.EventDateTime{DateTime: "2024-06-03T09:30:00+02:00"},
+	}
+	weekend := &calendar.Event{
+		Summary: "Hiking",
+		Start:   &calendar.EventDateTime{DateTime: "2024-06-01T09:30:00+02:00"},
+	}
+	allDay := &calendar.Event{
+		Summary: "Conference",
+		Start:   &calendar.EventDateTime{Date: "2024-06-03"},
+	}
+	declined := &calendar.Event{
+		Summary:   "Optional sync",
+		Start:     &calendar.EventDateTime{DateTime: "2024-06-03T18:00:00+02:00"},
+		Attendees: []*calendar.EventAttendee{{Email: "me@example.com", Self: true, ResponseStatus: "declined"}},
+	}
+	free := &calendar.Event{
+		Summary:      "Lunch",
+		Start:        &calendar.EventDateTime{DateTime: "2024-06-03T12:00:00+02:00"},
+		Transparency: "transparent",
+	}
+	focus := &calendar.Event{
+		Summary:   "Focus time",
+		EventType: "focusTime",
+		Start:     &calendar.EventDateTime{DateTime: "2024-06-03T14:00:00+02:00"},
+	}
+	events := []*calendar.Event{meeting, weekend, allDay, declined, free, focus}
+
+	testcases := map[string]struct {
+		rules    []persistence.FilterRule
+		expected []*calendar.Event
+	}{
+		"no rules": {
+			expected: events,
+		},
+		"exclude summary": {
+			rules:    []persistence.FilterRule{{Action: persistence.FilterExclude, Field: persistence.FilterSummary, Value: "^(Lunch|Hiking)$"}},
+			expected: []*calendar.Event{meeting, allDay, declined, focus},
+		},
+		"exclude event types": {
+			rules:    []persistence.FilterRule{{Action: persistence.FilterExclude, Field: persistence.FilterEventType, Value: "workingLocation, focusTime"}},
+			expected: []*calendar.Event{meeting, weekend, allDay, declined, free},
+		},
+		"exclude declined": {
+			rules:    []persistence.FilterRule{{Action: persistence.FilterExclude, Field: persistence.FilterResponseStatus, Value: "declined"}},
+			expected: []*calendar.Event{meeting, weekend, allDay, free, focus},
+		},
+		"include busy": {
+			rules:    []persistence.FilterRule{{Action: persistence.FilterInclude, Field: persistence.FilterTransparency, Value: "opaque"}},
+			expected: []*calendar.Event{meeting, weekend, allDay, declined, focus},
+		},
+		"exclude all day": {
+			rules:    []persistence.FilterRule{{Action: persistence.FilterExclude, Field: persistence.FilterAllDay, Value: "true"}},
+			expected: []*calendar.Event{meeting, weekend, declined, free, focus},
+		},
+		"working hours on weekdays": {
+			rules: []persistence.FilterRule{
+				{Action: persistence.FilterInclude, Field: persistence.FilterWeekday, Value: "mon,tue,wed,thu,fri"},
+				{Action: persistence.FilterInclude, Field: persistence.FilterTimeOfDay, Value: "09:00-17:00"},
+			},
+			expected: []*calendar.Event{meeting, free, focus},
+		},
+		"across midnight": {
+			rules:    []persistence.FilterRule{{Action: persistence.FilterInclude, Field: persistence.FilterTimeOfDay, Value: "17:00-10:00"}},
+			expected: []*calendar.Event{meeting, weekend, declined},
+		},
+	}
+
+	for key, tc := range testcases {
+		t.Run(key, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := New(tc.rules)
+			require.NoError(t, err)
+
+			var actual []*calendar.Event
+			for _, e := range events {
+				if f.Matches(e) {
+					actual = append(actual, e)
+				}
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestNewRejectsInvalidRules(t *testing.T) {
+	t.Parallel()
+
+	for _, rule := range []persistence.FilterRule{
+		{Action: persistence.FilterInclude, Field: persistence.FilterSummary, Value: "("},
+		{Action: persistence.FilterInclude, Field: persistence.FilterWeekday, Value: "someday"},
+		{Action: persistence.FilterInclude, Field: persistence.FilterTimeOfDay, Value: "9am"},
+		{Action: persistence.FilterInclude, Field: persistence.FilterTimeOfDay, Value: "09:00-09:00"},
+		{Action: persistence.FilterInclude, Field: persistence.FilterAllDay, Value: "yes"},
+		{Action: persistence.FilterInclude, Field: "color", Value: "red"},
+		{Action: "maybe", Field: persistence.FilterSummary, Value: ".*"},
+	} {
+		_, err := New([]persistence.FilterRule{rule})
+		assert.Error(t, err, rule)
+	}
+}
@@ -0,0 +1,334 @@
+package icalendar
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+)
+
+// ExtendedPropertyPrefix is prepended to private extended properties when they're stored as ical properties.
+const ExtendedPropertyPrefix = "X-CALENDAR-SYNC-"
+
+const ProductID = "-//calendar-sync//calendar-sync//EN"
+
+// participationStatuses maps the PARTSTAT of attendees to google's response status.
+var participationStatuses = map[string]string{
+	"NEEDS-ACTION": "needsAction",
+	"ACCEPTED":     "accepted",
+	"DECLINED":     "declined",
+	"TENTATIVE":    "tentative",
+}
+
+const (
+	roleRequired = "REQ-PARTICIPANT"
+	roleOptional = "OPT-PARTICIPANT"
+	mailtoPrefix = "mailto:"
+)
+
+var recurrenceProps = []string{
+	ical.PropRecurrenceRule,
+	ical.PropRecurrenceDates,
+	ical.PropExceptionDates,
+	"EXRULE",
+}
+
+// ToEvent converts an ical VEVENT into a google calendar event. The event ID is left to the caller.
+func ToEvent(e ical.Event) (*calendar.Event, error) {
+	var event calendar.Event
+
+	var err error
+	if event.ICalUID, err = e.Props.Text(ical.PropUID); err != nil {
+		return nil, errors.Wrap(err, "failed to read uid")
+	}
+	if event.ICalUID == "" {
+		return nil, errors.New("event has no uid")
+	}
+	if event.Summary, err = e.Props.Text(ical.PropSummary); err != nil {
+		return nil, errors.Wrap(err, "failed to read summary")
+	}
+	if event.Description, err = e.Props.Text(ical.PropDescription); err != nil {
+		return nil, errors.Wrap(err, "failed to read description")
+	}
+	if event.Location, err = e.Props.Text(ical.PropLocation); err != nil {
+		return nil, errors.Wrap(err, "failed to read location")
+	}
+
+	status, err := e.Status()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read status")
+	}
+	event.Status = strings.ToLower(string(status))
+
+	if transparency := e.Props.Get(ical.PropTransparency); transparency != nil {
+		event.Transparency = strings.ToLower(transparency.Value)
+	}
+
+	startProp := e.Props.Get(ical.PropDateTimeStart)
+	if startProp == nil {
+		return nil, errors.New("event has no start")
+	}
+	if event.Start, err = toEventDateTime(startProp); err != nil {
+		return nil, errors.Wrap(err, "failed to read start")
+	}
+
+	if endProp := e.Props.Get(ical.PropDateTimeEnd); endProp != nil {
+		if event.End, err = toEventDateTime(endProp); err != nil {
+			return nil, errors.Wrap(err, "failed to read end")
+		}
+	} else {
+		end, err := e.DateTimeEnd(time.UTC)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to calculate end")
+		}
+		event.End = formatEventDateTime(end, startProp.ValueType() == ical.ValueDate, event.Start.TimeZone)
+	}
+
+	if recurrenceID := e.Props.Get(ical.PropRecurrenceID); recurrenceID != nil {
+		if event.OriginalStartTime, err = toEventDateTime(recurrenceID); err != nil {
+			return nil, errors.Wrap(err, "failed to read recurrence id")
+		}
+	}
+
+	if created := e.Props.Get(ical.PropCreated); created != nil {
+		if t, err := created.DateTime(time.UTC); err == nil {
+			event.Created = t.Format(time.RFC3339)
+		}
+	}
+	if updated := e.Props.Get(ical.PropLastModified); updated != nil {
+		if t, err := updated.DateTime(time.UTC); err == nil {
+			event.Updated = t.Format(time.RFC3339)
+		}
+	}
+
+	for _, name := range recurrenceProps {
+		for _, prop := range e.Props.Values(name) {
+			event.Recurrence = append(event.Recurrence, encodeProp(prop))
+		}
+	}
+
+	for _, prop := range e.Props.Values(ical.PropAttendee) {
+		event.Attendees = append(event.Attendees, toAttendee(prop))
+	}
+
+	for name, props := range e.Props {
+		if !strings.HasPrefix(name, ExtendedPropertyPrefix) || len(props) == 0 {
+			continue
+		}
+
+		if event.ExtendedProperties == nil {
+			event.ExtendedProperties = &calendar.EventExtendedProperties{Private: make(map[string]string)}
+		}
+
+		key := strings.ToLower(strings.TrimPrefix(name, ExtendedPropertyPrefix))
+		event.ExtendedProperties.Private[key] = props[0].Value
+	}
+
+	return &event, nil
+}
+
+// FromEvent converts a google calendar event into an ical VEVENT. A UID is generated if the event doesn't have one.
+func FromEvent(event *calendar.Event) (*ical.Event, error) {
+	e := ical.NewEvent()
+
+	uid := event.ICalUID
+	if uid == "" {
+		uid = uuid.NewString()
+	}
+
+	e.Props.SetText(ical.PropUID, uid)
+	e.Props.SetDateTime(ical.PropDateTimeStamp, time.Now().UTC())
+
+	if event.Summary != "" {
+		e.Props.SetText(ical.PropSummary, event.Summary)
+	}
+	if event.Description != "" {
+		e.Props.SetText(ical.PropDescription, event.Description)
+	}
+	if event.Location != "" {
+		e.Props.SetText(ical.PropLocation, event.Location)
+	}
+	if event.Status != "" {
+		e.SetStatus(ical.EventStatus(strings.ToUpper(event.Status)))
+	}
+	if event.Transparency != "" {
+		e.Props.SetText(ical.PropTransparency, strings.ToUpper(event.Transparency))
+	}
+
+	start, err := fromEventDateTime(ical.PropDateTimeStart, event.Start)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert start")
+	}
+	e.Props.Set(start)
+
+	end, err := fromEventDateTime(ical.PropDateTimeEnd, event.End)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert end")
+	}
+	e.Props.Set(end)
+
+	if event.OriginalStartTime != nil {
+		recurrenceID, err := fromEventDateTime(ical.PropRecurrenceID, event.OriginalStartTime)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert recurrence id")
+		}
+		e.Props.Set(recurrenceID)
+	}
+
+	for _, line := range event.Recurrence {
+		prop, err := decodeProp(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse recurrence %q", line)
+		}
+		e.Props.Add(prop)
+	}
+
+	for _, attendee := range event.Attendees {
+		e.Props.Add(fromAttendee(attendee))
+	}
+
+	if event.ExtendedProperties != nil {
+		for key, value := range event.ExtendedProperties.Private {
+			prop := ical.NewProp(ExtendedPropertyPrefix + strings.ToUpper(key))
+			prop.Value = value
+			e.Props.Set(prop)
+		}
+	}
+
+	return e, nil
+}
+
+// NewCalendar creates an empty VCALENDAR with the required properties set.
+func NewCalendar() *ical.Calendar {
+	cal := ical.NewCalendar()
+	cal.Props.SetText(ical.PropVersion, "2.0")
+	cal.Props.SetText(ical.PropProductID, ProductID)
+
+	return cal
+}
+
+// toAttendee reads an ATTENDEE, whose value is usually a mailto: address.
+func toAttendee(prop ical.Prop) *calendar.EventAttendee {
+	email := prop.Value
+	if len(email) >= len(mailtoPrefix) && strings.EqualFold(email[:len(mailtoPrefix)], mailtoPrefix) {
+		email = email[len(mailtoPrefix):]
+	}
+
+	return &calendar.EventAttendee{
+		Email:          email,
+		DisplayName:    prop.Params.Get(ical.ParamCommonName),
+		Optional:       strings.EqualFold(prop.Params.Get(ical.ParamRole), roleOptional),
+		ResponseStatus: cmp.Or(participationStatuses[strings.ToUpper(prop.Params.Get(ical.ParamParticipationStatus))], "needsAction"),
+	}
+}
+
+func fromAttendee(attendee *calendar.EventAttendee) *ical.Prop {
+	prop := ical.NewProp(ical.PropAttendee)
+	prop.Value = mailtoPrefix + attendee.Email
+
+	if attendee.DisplayName != "" {
+		prop.Params.Set(ical.ParamCommonName, attendee.DisplayName)
+	}
+
+	role := roleRequired
+	if attendee.Optional {
+		role = roleOptional
+	}
+	prop.Params.Set(ical.ParamRole, role)
+
+	partstat := "NEEDS-ACTION"
+	for value, status := range participationStatuses {
+		if status == attendee.ResponseStatus {
+			partstat = value
+		}
+	}
+	prop.Params.Set(ical.ParamParticipationStatus, partstat)
+
+	return prop
+}
+
+func toEventDateTime(prop *ical.Prop) (*calendar.EventDateTime, error) {
+	t, err := prop.DateTime(time.UTC)
+	if err != nil {
+		return nil, err
+	}
+
+	return formatEventDateTime(t, prop.ValueType() == ical.ValueDate, prop.Params.Get(ical.PropTimezoneID)), nil
+}
+
+func formatEventDateTime(t time.Time, isDate bool, timeZone string) *calendar.EventDateTime {
+	if isDate {
+		return &calendar.EventDateTime{Date: t.Format(time.DateOnly)}
+	}
+
+	return &calendar.EventDateTime{DateTime: t.Format(time.RFC3339), TimeZone: timeZone}
+}
+
+func fromEventDateTime(name string, dt *calendar.EventDateTime) (*ical.Prop, error) {
+	prop := ical.NewProp(name)
+
+	if dt == nil {
+		return nil, errors.New("missing date")
+	}
+
+	if dt.Date != "" {
+		t, err := time.Parse(time.DateOnly, dt.Date)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse date")
+		}
+		prop.SetDate(t)
+		return prop, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, dt.DateTime)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse datetime")
+	}
+
+	t = t.UTC()
+	if dt.TimeZone != "" {
+		if loc, err := time.LoadLocation(dt.TimeZone); err == nil {
+			t = t.In(loc)
+		}
+	}
+
+	prop.SetDateTime(t)
+	return prop, nil
+}
+
+// encodeProp formats a property the way google stores recurrence rules, e.g. "EXDATE;TZID=UTC:20240101T100000".
+func encodeProp(prop ical.Prop) string {
+	var sb strings.Builder
+	sb.WriteString(prop.Name)
+	for key, values := range prop.Params {
+		sb.WriteString(";" + key + "=" + strings.Join(values, ","))
+	}
+	sb.WriteString(":" + prop.Value)
+
+	return sb.String()
+}
+
+func decodeProp(line string) (*ical.Prop, error) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return nil, errors.New("missing value")
+	}
+
+	parts := strings.Split(head, ";")
+	prop := ical.NewProp(parts[0])
+	prop.Value = value
+
+	for _, param := range parts[1:] {
+		key, val, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid parameter %q", param)
+		}
+		prop.Params.Set(strings.ToUpper(key), val)
+	}
+
+	return prop, nil
+}
@@ -0,0 +1,75 @@
+package icalendar_test
+
+import (
+	"testing"
+
+	"github.com/emersion/go-ical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/icalendar"
+)
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	event := &calendar.Event{
+		ICalUID:      "uid",
+		Summary:      "standup",
+		Description:  "daily",
+		Location:     "office",
+		Status:       "confirmed",
+		Transparency: "transparent",
+		Start:        &calendar.EventDateTime{DateTime: "2030-01-03T09:00:00+01:00", TimeZone: "Europe/Berlin"},
+		End:          &calendar.EventDateTime{DateTime: "2030-01-03T09:15:00+01:00", TimeZone: "Europe/Berlin"},
+		Recurrence:   []string{"RRULE:FREQ=DAILY;COUNT=3"},
+		Attendees: []*calendar.EventAttendee{
+			{Email: "alice@example.com", DisplayName: "Alice", ResponseStatus: "accepted"},
+			{Email: "bob@example.com", Optional: true},
+		},
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{"sourcecalendarid": "source"},
+		},
+	}
+
+	e, err := icalendar.FromEvent(event)
+	require.NoError(t, err)
+
+	converted, err := icalendar.ToEvent(*e)
+	require.NoError(t, err)
+	assert.Equal(t, "uid", converted.ICalUID)
+	assert.Equal(t, "standup", converted.Summary)
+	assert.Equal(t, "daily", converted.Description)
+	assert.Equal(t, "office", converted.Location)
+	assert.Equal(t, "confirmed", converted.Status)
+	assert.Equal(t, "transparent", converted.Transparency)
+	assert.Equal(t, "2030-01-03T09:00:00+01:00", converted.Start.DateTime)
+	assert.Equal(t, "Europe/Berlin", converted.Start.TimeZone)
+	assert.Equal(t, "2030-01-03T09:15:00+01:00", converted.End.DateTime)
+	assert.Equal(t, []string{"RRULE:FREQ=DAILY;COUNT=3"}, converted.Recurrence)
+	assert.Equal(t, map[string]string{"sourcecalendarid": "source"}, converted.ExtendedProperties.Private)
+	assert.Equal(t, []*calendar.EventAttendee{
+		{Email: "alice@example.com", DisplayName: "Alice", ResponseStatus: "accepted"},
+		{Email: "bob@example.com", Optional: true, ResponseStatus: "needsAction"},
+	}, converted.Attendees)
+
+	attendee := e.Props.Get(ical.PropAttendee)
+	require.NotNil(t, attendee)
+	assert.Equal(t, "mailto:alice@example.com", attendee.Value)
+	assert.Equal(t, "REQ-PARTICIPANT", attendee.Params.Get(ical.ParamRole))
+	assert.Equal(t, "ACCEPTED", attendee.Params.Get(ical.ParamParticipationStatus))
+
+	// all day events, and a generated uid
+	e, err = icalendar.FromEvent(&calendar.Event{
+		Start: &calendar.EventDateTime{Date: "2030-01-04"},
+		End:   &calendar.EventDateTime{Date: "2030-01-05"},
+	})
+	require.NoError(t, err)
+
+	converted, err = icalendar.ToEvent(*e)
+	require.NoError(t, err)
+	assert.NotEmpty(t, converted.ICalUID)
+	assert.Equal(t, "2030-01-04", converted.Start.Date)
+	assert.Equal(t, "2030-01-05", converted.End.Date)
+}
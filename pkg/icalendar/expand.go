@@ -0,0 +1,224 @@
+package icalendar
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/pkg/errors"
+	"github.com/teambition/rrule-go"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/logs"
+)
+
+const (
+	instanceDateFormat     = "20060102"
+	instanceDateTimeFormat = "20060102T150405Z"
+)
+
+// Expand converts every VEVENT in the calendar into events, using the UID as the event ID. Recurring events are
+// expanded into single instances between timeMin and timeMax, replaced by their overrides where there is one.
+// If timeMax is zero, recurring events are returned as-is. Events which can't be read are logged and left out, so
+// one broken event doesn't hide the rest of the calendar.
+func Expand(ctx context.Context, cal *ical.Calendar, timeMin, timeMax time.Time) []*calendar.Event {
+	var (
+		log       = logs.GetLogger(ctx)
+		events    []*calendar.Event
+		overrides = make(map[string]*calendar.Event)
+	)
+
+	for _, e := range cal.Events() {
+		// the uid is only for logging, events without a valid one fail to convert
+		uid, _ := e.Props.Text(ical.PropUID)
+
+		event, err := ToEvent(e)
+		if err != nil {
+			log.Warn().Err(err).Str("uid", uid).Msg("skipping event which can't be converted")
+			continue
+		}
+
+		event.Id = event.ICalUID
+
+		if recurrenceID := e.Props.Get(ical.PropRecurrenceID); recurrenceID != nil {
+			t, err := recurrenceID.DateTime(time.UTC)
+			if err != nil {
+				log.Warn().Err(err).Str("uid", uid).Msg("skipping event with an invalid recurrence id")
+				continue
+			}
+
+			event.Id = InstanceID(event.ICalUID, t, recurrenceID.ValueType() == ical.ValueDate)
+			event.RecurringEventId = event.ICalUID
+			overrides[event.Id] = event
+			continue
+		}
+
+		if len(event.Recurrence) == 0 || timeMax.IsZero() {
+			if Overlaps(event, timeMin, timeMax) {
+				events = append(events, event)
+			}
+			continue
+		}
+
+		instances, err := expandEvent(e, event, timeMin, timeMax)
+		if err != nil {
+			log.Warn().Err(err).Str("uid", uid).Msg("skipping event which can't be expanded")
+			continue
+		}
+
+		events = append(events, instances...)
+	}
+
+	// overrides replace their instance wherever they were moved to, which may be into or out of the window
+	events = slices.DeleteFunc(events, func(event *calendar.Event) bool {
+		_, ok := overrides[event.Id]
+		return ok
+	})
+	for _, override := range overrides {
+		if Overlaps(override, timeMin, timeMax) {
+			events = append(events, override)
+		}
+	}
+
+	return events
+}
+
+// InstanceID builds an ID for a single occurrence of a recurring event, in the same format google uses.
+func InstanceID(uid string, start time.Time, isDate bool) string {
+	if isDate {
+		return uid + "_" + start.Format(instanceDateFormat)
+	}
+
+	return uid + "_" + start.UTC().Format(instanceDateTimeFormat)
+}
+
+func expandEvent(e ical.Event, master *calendar.Event, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	startProp := e.Props.Get(ical.PropDateTimeStart)
+	isDate := startProp.ValueType() == ical.ValueDate
+
+	start, err := e.DateTimeStart(time.UTC)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse start")
+	}
+
+	end, err := e.DateTimeEnd(time.UTC)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse end")
+	}
+
+	set, err := recurrenceSet(e, start)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := end.Sub(start)
+
+	var instances []*calendar.Event
+	for _, occurrence := range set.Between(timeMin.Add(-duration), timeMax, true) {
+		instance := *master
+		instance.Id = InstanceID(master.ICalUID, occurrence, isDate)
+		instance.RecurringEventId = master.Id
+		instance.Recurrence = nil
+		instance.Start = formatEventDateTime(occurrence, isDate, master.Start.TimeZone)
+		instance.End = formatEventDateTime(occurrence.Add(duration), isDate, master.End.TimeZone)
+		instance.OriginalStartTime = instance.Start
+
+		instances = append(instances, &instance)
+	}
+
+	return instances, nil
+}
+
+func recurrenceSet(e ical.Event, start time.Time) (*rrule.Set, error) {
+	var set rrule.Set
+	set.DTStart(start)
+
+	for _, prop := range e.Props.Values(ical.PropRecurrenceRule) {
+		option, err := rrule.StrToROptionInLocation(prop.Value, start.Location())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse rrule")
+		}
+		option.Dtstart = start
+
+		rule, err := rrule.NewRRule(*option)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to build rrule")
+		}
+		set.RRule(rule)
+	}
+
+	rdates, err := dateList(e.Props.Values(ical.PropRecurrenceDates))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse rdate")
+	}
+	for _, rdate := range rdates {
+		set.RDate(rdate)
+	}
+
+	exdates, err := dateList(e.Props.Values(ical.PropExceptionDates))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse exdate")
+	}
+	for _, exdate := range exdates {
+		set.ExDate(exdate)
+	}
+
+	return &set, nil
+}
+
+// dateList parses properties which may contain several comma separated dates, like EXDATE.
+func dateList(props []ical.Prop) ([]time.Time, error) {
+	var dates []time.Time
+
+	for _, prop := range props {
+		for _, value := range strings.Split(prop.Value, ",") {
+			single := prop
+			single.Value = value
+
+			t, err := single.DateTime(time.UTC)
+			if err != nil {
+				return nil, err
+			}
+			dates = append(dates, t)
+		}
+	}
+
+	return dates, nil
+}
+
+// Overlaps reports whether an event overlaps the window. Zero bounds are open, and events with unparseable times
+// always overlap.
+func Overlaps(event *calendar.Event, timeMin, timeMax time.Time) bool {
+	start, err := ParseEventDateTime(event.Start)
+	if err != nil {
+		return true
+	}
+	end, err := ParseEventDateTime(event.End)
+	if err != nil {
+		return true
+	}
+
+	if !timeMin.IsZero() && !end.After(timeMin) {
+		return false
+	}
+	if !timeMax.IsZero() && !start.Before(timeMax) {
+		return false
+	}
+
+	return true
+}
+
+// ParseEventDateTime returns the instant an event starts or ends at. All day events start at midnight UTC.
+func ParseEventDateTime(dt *calendar.EventDateTime) (time.Time, error) {
+	if dt == nil {
+		return time.Time{}, errors.New("missing date")
+	}
+
+	if dt.DateTime != "" {
+		return time.Parse(time.RFC3339, dt.DateTime)
+	}
+
+	return time.Parse(time.DateOnly, dt.Date)
+}
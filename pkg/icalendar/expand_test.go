@@ -0,0 +1,171 @@
+package icalendar_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"calendar-sync/pkg/icalendar"
+)
+
+const feed = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//test//test//EN
+BEGIN:VEVENT
+UID:daily
+DTSTAMP:20300101T000000Z
+DTSTART:20300103T090000Z
+DTEND:20300103T091500Z
+RRULE:FREQ=DAILY;COUNT=4
+EXDATE:20300104T090000Z
+SUMMARY:standup
+END:VEVENT
+BEGIN:VEVENT
+UID:daily
+DTSTAMP:20300101T000000Z
+RECURRENCE-ID:20300105T090000Z
+DTSTART:20300105T100000Z
+DTEND:20300105T101500Z
+SUMMARY:late standup
+END:VEVENT
+BEGIN:VEVENT
+DTSTAMP:20300101T000000Z
+DTSTART:20300103T120000Z
+DTEND:20300103T130000Z
+SUMMARY:no uid
+END:VEVENT
+BEGIN:VEVENT
+UID:broken-rule
+DTSTAMP:20300101T000000Z
+DTSTART:20300103T120000Z
+DTEND:20300103T130000Z
+RRULE:FREQ=SOMETIMES
+SUMMARY:broken rule
+END:VEVENT
+BEGIN:VEVENT
+UID:all-day
+DTSTAMP:20300101T000000Z
+DTSTART;VALUE=DATE:20300104
+SUMMARY:holiday
+END:VEVENT
+END:VCALENDAR
+`
+
+func decode(t *testing.T, data string) *ical.Calendar {
+	t.Helper()
+
+	cal, err := ical.NewDecoder(strings.NewReader(strings.ReplaceAll(data, "\n", "\r\n"))).Decode()
+	require.NoError(t, err)
+
+	return cal
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	cal := decode(t, feed)
+	timeMin := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	timeMax := time.Date(2030, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	// events which can't be read are left out
+	events := icalendar.Expand(t.Context(), cal, timeMin, timeMax)
+
+	summaries := make(map[string]string)
+	for _, event := range events {
+		summaries[event.Id] = event.Summary
+	}
+	assert.Equal(t, map[string]string{
+		"daily_20300103T090000Z": "standup",
+		"daily_20300105T090000Z": "late standup",
+		"daily_20300106T090000Z": "standup",
+		"all-day":                "holiday",
+	}, summaries)
+
+	for _, event := range events {
+		if event.Id == "all-day" {
+			assert.Equal(t, "2030-01-04", event.Start.Date)
+			assert.Equal(t, "2030-01-05", event.End.Date)
+			continue
+		}
+		assert.Equal(t, "daily", event.RecurringEventId)
+	}
+
+	// series are returned as-is without a window end
+	events = icalendar.Expand(t.Context(), cal, time.Time{}, time.Time{})
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.Id)
+	}
+	assert.ElementsMatch(t, []string{"daily", "daily_20300105T090000Z", "broken-rule", "all-day"}, ids)
+}
+
+func TestExpandMovedOverrides(t *testing.T) {
+	t.Parallel()
+
+	const master = `BEGIN:VEVENT
+UID:daily
+DTSTAMP:20300101T000000Z
+DTSTART:20300103T090000Z
+DTEND:20300103T091500Z
+RRULE:FREQ=DAILY;COUNT=4
+SUMMARY:standup
+END:VEVENT
+`
+	const override = `BEGIN:VEVENT
+UID:daily
+DTSTAMP:20300101T000000Z
+RECURRENCE-ID:20300104T090000Z
+DTSTART:20300304T090000Z
+DTEND:20300304T091500Z
+SUMMARY:moved standup
+END:VEVENT
+`
+	january := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2030, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, events := range map[string]string{
+		"override after master":  master + override,
+		"override before master": override + master,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cal := decode(t, "BEGIN:VCALENDAR\nVERSION:2.0\nPRODID:-//test//test//EN\n"+events+"END:VCALENDAR\n")
+			starts := func(timeMin, timeMax time.Time) map[string]string {
+				starts := make(map[string]string)
+				for _, event := range icalendar.Expand(t.Context(), cal, timeMin, timeMax) {
+					starts[event.Id] = event.Start.DateTime
+				}
+				return starts
+			}
+
+			// moved out of the window
+			assert.Equal(t, map[string]string{
+				"daily_20300103T090000Z": "2030-01-03T09:00:00Z",
+				"daily_20300105T090000Z": "2030-01-05T09:00:00Z",
+				"daily_20300106T090000Z": "2030-01-06T09:00:00Z",
+			}, starts(january, january.AddDate(0, 1, 0)))
+
+			// moved into the window
+			assert.Equal(t, map[string]string{
+				"daily_20300104T090000Z": "2030-03-04T09:00:00Z",
+			}, starts(march, march.AddDate(0, 1, 0)))
+		})
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2030, time.January, 1, 10, 0, 0, 0, time.UTC)
+	event := icalendar.Expand(t.Context(), decode(t, feed), time.Time{}, time.Time{})[0]
+
+	assert.True(t, icalendar.Overlaps(event, time.Time{}, time.Time{}))
+	assert.True(t, icalendar.Overlaps(event, start, start.Add(48*time.Hour)))
+	assert.False(t, icalendar.Overlaps(event, start, start.Add(time.Hour)))
+	assert.False(t, icalendar.Overlaps(event, start.Add(72*time.Hour), time.Time{}))
+}
@@ -10,3 +10,27 @@ type Calendar struct {
 
 const SourceCalendarIDKey = "source-calendar-id"
 const SourceCalendarItemIDKey = "source-calendar-item-id"
+
+// OriginCalendarIDKey and OriginCalendarItemIDKey identify the event a copy of a copy was first copied from.
+const OriginCalendarIDKey = "origin-calendar-id"
+const OriginCalendarItemIDKey = "origin-calendar-item-id"
+
+// SyncedFingerprintKey records the fingerprint of a two-way copy when both sides were last in sync, which tells
+// which side has been edited since.
+const SyncedFingerprintKey = "synced-fingerprint"
+
+// BufferKey marks the padding events next to a copy, which are linked to the copy's source event like the copy is.
+// Its value is BufferBefore or BufferAfter.
+const BufferKey = "buffer"
+
+const (
+	BufferBefore = "before"
+	BufferAfter  = "after"
+)
+
+// MergedSourceItemIDsKey lists the source events a busy block is made of, separated by commas.
+const MergedSourceItemIDsKey = "merged-source-item-ids"
+
+// SourceICalUIDKey identifies the event a copy was made of across calendars, so copies of the same event from
+// different sources can be told apart from different events.
+const SourceICalUIDKey = "source-ical-uid"
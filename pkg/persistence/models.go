@@ -6,12 +6,260 @@ type InviteConfig struct {
 	ID           int
 	CalendarID   string
 	EmailAddress string
+	// GroupID invites every member of a group, besides or instead of EmailAddress.
+	GroupID int
+
+	// SummaryPattern is a regular expression events' titles must match. The other conditions are off unless set.
+	SummaryPattern    string
+	OnlyWithAttendees bool
+	OnlyOwnEvents     bool
+	WeekdaysOnly      bool
+	ExcludeAllDay     bool
+
+	// Optional invites the guest as optional, and SendUpdates decides who is notified of the invitation.
+	Optional    bool
+	SendUpdates SendUpdates
 }
 
+// Group is a named set of addresses which are invited together.
+type Group struct {
+	ID      int
+	Name    string
+	Members []string
+}
+
+// InvitedGuest is a guest an invite config added to an event, so exactly those guests can be removed again.
+type InvitedGuest struct {
+	InviteID     int
+	CalendarID   string
+	EventID      string
+	EmailAddress string
+}
+
+// SendUpdates are the notifications google sends about changed guest lists.
+type SendUpdates string
+
+const (
+	SendUpdatesAll      SendUpdates = "all"
+	SendUpdatesExternal SendUpdates = "externalOnly"
+	SendUpdatesNone     SendUpdates = "none"
+)
+
+var SendUpdatesModes = []SendUpdates{SendUpdatesNone, SendUpdatesExternal, SendUpdatesAll}
+
 type CopyConfig struct {
 	ID            int
 	SourceID      string
 	DestinationID string
+
+	// LookBack and LookAhead define the window of events which are copied, relative to now.
+	LookBack  time.Duration
+	LookAhead time.Duration
+
+	// Privacy controls how much of each event is copied, and BusyTitle replaces titles which aren't copied.
+	Privacy   PrivacyMode
+	BusyTitle string
+
+	// Filters decide which source events are copied.
+	Filters []FilterRule
+
+	// Bidirectional also copies edits of copies back to their source, resolving edits on both sides with
+	// ConflictPolicy.
+	Bidirectional  bool
+	ConflictPolicy ConflictPolicy
+
+	// ChainMode decides what happens to source events which are themselves copies.
+	ChainMode ChainMode
+
+	// SummaryTemplate and DescriptionTemplate are text/templates which generate the text of copies, see
+	// workflows.TemplateData. Empty templates copy the text as it is.
+	SummaryTemplate     string
+	DescriptionTemplate string
+
+	// Fields are the optional parts of events which are copied. ColorID gives every copy the same color instead, and
+	// NoReminders removes the reminders of copies, which otherwise get the destination calendar's default reminders.
+	Fields      []CopyField
+	ColorID     string
+	NoReminders bool
+
+	// Recurrence decides how recurring events are copied.
+	Recurrence RecurrenceMode
+
+	// AllDay converts all day events into timed ones or the other way around, in TimeZone. An empty TimeZone is UTC.
+	AllDay   AllDayMode
+	TimeZone string
+
+	// PadBefore and PadAfter add buffer time around copied events, only around events with a physical location if
+	// PadLocationOnly is set. Recurring series are only padded when they're expanded.
+	PadBefore       time.Duration
+	PadAfter        time.Duration
+	PadLocationOnly bool
+	PadMode         PadMode
+
+	// MergeBusy copies busy blocks instead of events, merging events which overlap or are at most MergeGap apart.
+	MergeBusy bool
+	MergeGap  time.Duration
+
+	// Label tells copies from different sources apart in a shared destination. Copies' titles are prefixed with it,
+	// unless there's a summary template.
+	Label string
+	// Deduplicate skips events which another source already copied into the destination, going by their iCalUID.
+	Deduplicate bool
+}
+
+// NewCopyConfig has the defaults of the copies table, for copies which aren't saved yet.
+func NewCopyConfig(sourceID, destinationID string) CopyConfig {
+	return CopyConfig{
+		SourceID:       sourceID,
+		DestinationID:  destinationID,
+		LookAhead:      14 * 24 * time.Hour,
+		Privacy:        PrivacyFull,
+		BusyTitle:      "Busy",
+		ConflictPolicy: ConflictSourceWins,
+		ChainMode:      ChainTransitive,
+		Recurrence:     RecurrenceSeries,
+		AllDay:         AllDayKeep,
+		PadMode:        PadExtend,
+	}
+}
+
+type PadMode string
+
+const (
+	// PadExtend makes copies longer.
+	PadExtend PadMode = "extend"
+	// PadSeparate creates separate buffer events next to copies, which follow their copy.
+	PadSeparate PadMode = "separate"
+)
+
+var PadModes = []PadMode{PadExtend, PadSeparate}
+
+type AllDayMode string
+
+const (
+	AllDayKeep AllDayMode = "keep"
+	// AllDayToTimed copies all day events as events from midnight to midnight.
+	AllDayToTimed AllDayMode = "to-timed"
+	// AllDayFromTimed copies timed events as all day events on every day they touch.
+	AllDayFromTimed AllDayMode = "to-all-day"
+)
+
+var AllDayModes = []AllDayMode{AllDayKeep, AllDayToTimed, AllDayFromTimed}
+
+type RecurrenceMode string
+
+const (
+	// RecurrenceSeries copies a series with its rules, and its modified and cancelled instances as instances of the
+	// copied series.
+	RecurrenceSeries RecurrenceMode = "series"
+	// RecurrenceExpand copies each instance in the window as a single event.
+	RecurrenceExpand RecurrenceMode = "expand"
+)
+
+var RecurrenceModes = []RecurrenceMode{RecurrenceSeries, RecurrenceExpand}
+
+type CopyField string
+
+const (
+	CopyAttendees      CopyField = "attendees"
+	CopyReminders      CopyField = "reminders"
+	CopyColor          CopyField = "color"
+	CopyTransparency   CopyField = "transparency"
+	CopyVisibility     CopyField = "visibility"
+	CopyConferenceData CopyField = "conferenceData"
+	CopyAttachments    CopyField = "attachments"
+)
+
+var CopyFields = []CopyField{
+	CopyAttendees, CopyReminders, CopyColor, CopyTransparency, CopyVisibility, CopyConferenceData, CopyAttachments,
+}
+
+type ChainMode string
+
+const (
+	// ChainTransitive copies copies too, remembering the calendar they originally came from. They are never copied
+	// back into that calendar.
+	ChainTransitive ChainMode = "transitive"
+	// ChainSkip only copies events which originate in the source calendar.
+	ChainSkip ChainMode = "skip"
+)
+
+var ChainModes = []ChainMode{ChainTransitive, ChainSkip}
+
+type ConflictPolicy string
+
+const (
+	ConflictSourceWins       ConflictPolicy = "source-wins"
+	ConflictLastModifiedWins ConflictPolicy = "last-modified-wins"
+)
+
+var ConflictPolicies = []ConflictPolicy{ConflictSourceWins, ConflictLastModifiedWins}
+
+// ConflictNoWinner is the Winner of writes which kept running into concurrent edits and were given up.
+const ConflictNoWinner = "neither"
+
+// Conflict records an event which was edited on both sides of a two-way copy, and which side was kept. Writes which
+// were given up are recorded too, invites have no CopyID.
+type Conflict struct {
+	ID                 int
+	CopyID             int
+	SourceEventID      string
+	DestinationEventID string
+	Summary            string
+	Winner             string
+	CreatedAt          time.Time
+}
+
+type PrivacyMode string
+
+const (
+	PrivacyFull      PrivacyMode = "full"
+	PrivacyTitleOnly PrivacyMode = "title-only"
+	PrivacyBusy      PrivacyMode = "busy"
+)
+
+var PrivacyModes = []PrivacyMode{PrivacyFull, PrivacyTitleOnly, PrivacyBusy}
+
+// FilterRule matches a field of source events against Value. An event is copied when it matches every include rule
+// and none of the exclude rules.
+type FilterRule struct {
+	ID     int
+	CopyID int
+	Action FilterAction
+	Field  FilterField
+	Value  string
+}
+
+type FilterAction string
+
+const (
+	FilterInclude FilterAction = "include"
+	FilterExclude FilterAction = "exclude"
+)
+
+var FilterActions = []FilterAction{FilterInclude, FilterExclude}
+
+type FilterField string
+
+const (
+	// FilterSummary is a regular expression.
+	FilterSummary FilterField = "summary"
+	// FilterEventType is a comma separated list, like "workingLocation,focusTime,outOfOffice".
+	FilterEventType FilterField = "eventType"
+	// FilterResponseStatus is a comma separated list of the calendar owner's responses, like "declined".
+	FilterResponseStatus FilterField = "responseStatus"
+	// FilterTransparency is "opaque" (busy) or "transparent" (free).
+	FilterTransparency FilterField = "transparency"
+	// FilterAllDay is "true" or "false".
+	FilterAllDay FilterField = "allDay"
+	// FilterWeekday is a comma separated list of the days events start on, like "sat,sun".
+	FilterWeekday FilterField = "weekday"
+	// FilterTimeOfDay is the range events start in, like "09:00-17:00".
+	FilterTimeOfDay FilterField = "timeOfDay"
+)
+
+var FilterFields = []FilterField{
+	FilterSummary, FilterEventType, FilterResponseStatus, FilterTransparency, FilterAllDay, FilterWeekday, FilterTimeOfDay,
 }
 
 type WatchConfig struct {
@@ -21,3 +269,51 @@ type WatchConfig struct {
 	Token      string
 	Expiration time.Time
 }
+
+type FeedConfig struct {
+	ID         int
+	CalendarID string
+	Token      string
+	Merged     bool
+}
+
+// JobState is where a queued job is in its life. Jobs which succeeded are deleted.
+type JobState string
+
+const (
+	JobPending JobState = "pending"
+	JobRunning JobState = "running"
+	// JobDead jobs failed too often, they stay around until they're retried or deleted by hand.
+	JobDead JobState = "dead"
+)
+
+// Job is work which was queued to run in the background, see the queue package.
+type Job struct {
+	ID   int
+	Kind string
+	// Args are the JSON encoded arguments of the job's handler.
+	Args string
+	// IdempotencyKey keeps a job from being queued again while another one with the same key is pending. A job with the
+	// key of a running job is queued, and runs once the running one is done.
+	IdempotencyKey string
+	State          JobState
+	Attempts       int
+	// RunAt is when a pending job runs next.
+	RunAt     time.Time
+	LastError string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ScheduledJob is the state of a job which runs on a schedule, see the scheduler package.
+type ScheduledJob struct {
+	Name string
+	// Spec is a cron expression or "@every <duration>".
+	Spec         string
+	Running      bool
+	LastRunAt    time.Time
+	LastDuration time.Duration
+	LastError    string
+	// NextRunAt is when the job runs next, jobs are triggered by hand by setting it to now.
+	NextRunAt time.Time
+}
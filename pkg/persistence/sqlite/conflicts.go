@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+)
+
+func (d *Database) CreateConflict(ctx context.Context, conflict persistence.Conflict) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+INSERT INTO conflicts (copyID, sourceEventID, destinationEventID, summary, winner, createdAt)
+VALUES (?, ?, ?, ?, ?, ?)
+`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx,
+		conflict.CopyID, conflict.SourceEventID, conflict.DestinationEventID,
+		conflict.Summary, conflict.Winner, conflict.CreatedAt,
+	); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Int("copy-id", conflict.CopyID).
+		Str("source-event-id", conflict.SourceEventID).
+		Str("destination-event-id", conflict.DestinationEventID).
+		Str("winner", conflict.Winner).
+		Msgf("recorded conflict")
+
+	return nil
+}
+
+// GetConflicts returns the most recent conflicts first.
+func (d *Database) GetConflicts(ctx context.Context, limit int) ([]persistence.Conflict, error) {
+	stmt, err := d.db.PrepareContext(ctx, `
+SELECT id, copyID, sourceEventID, destinationEventID, summary, winner, createdAt
+FROM conflicts
+ORDER BY createdAt DESC, id DESC
+LIMIT ?`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute statement")
+	}
+	defer rows.Close()
+
+	var conflicts []persistence.Conflict
+	for rows.Next() {
+		var conflict persistence.Conflict
+		if err := rows.Scan(
+			&conflict.ID, &conflict.CopyID, &conflict.SourceEventID, &conflict.DestinationEventID,
+			&conflict.Summary, &conflict.Winner, &conflict.CreatedAt,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "error getting rows")
+	}
+
+	return conflicts, nil
+}
+
+// DeleteConflictsBefore deletes the conflicts recorded before a time.
+func (d *Database) DeleteConflictsBefore(ctx context.Context, before time.Time) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM conflicts
+WHERE julianday(createdAt) < julianday(?)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, before)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	if deleted, err := result.RowsAffected(); err == nil {
+		logs.GetLogger(ctx).Info().
+			Int64("conflicts", deleted).
+			Time("before", before).
+			Msg("deleted old conflicts")
+	}
+
+	return nil
+}
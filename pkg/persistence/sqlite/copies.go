@@ -2,6 +2,8 @@ package sqlite
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -9,6 +11,54 @@ import (
 	"calendar-sync/pkg/persistence"
 )
 
+const copyConfigColumns = `id, sourceID, destinationID, lookBackSeconds, lookAheadSeconds, privacy, busyTitle, bidirectional, conflictPolicy, chainMode, summaryTemplate, descriptionTemplate, fields, colorID, noReminders, recurrence, allDay, timeZone,
+	padBeforeSeconds, padAfterSeconds, padLocationOnly, padMode, mergeBusy, mergeGapSeconds, label, deduplicate`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCopyConfig(row rowScanner) (persistence.CopyConfig, error) {
+	var (
+		config              persistence.CopyConfig
+		lookBack, lookAhead int64
+		padBefore, padAfter int64
+		mergeGap            int64
+		fields              string
+	)
+
+	if err := row.Scan(
+		&config.ID, &config.SourceID, &config.DestinationID, &lookBack, &lookAhead,
+		&config.Privacy, &config.BusyTitle, &config.Bidirectional, &config.ConflictPolicy, &config.ChainMode,
+		&config.SummaryTemplate, &config.DescriptionTemplate, &fields, &config.ColorID, &config.NoReminders,
+		&config.Recurrence, &config.AllDay, &config.TimeZone,
+		&padBefore, &padAfter, &config.PadLocationOnly, &config.PadMode, &config.MergeBusy, &mergeGap, &config.Label, &config.Deduplicate,
+	); err != nil {
+		return config, err
+	}
+
+	config.LookBack = time.Duration(lookBack) * time.Second
+	config.LookAhead = time.Duration(lookAhead) * time.Second
+	config.PadBefore = time.Duration(padBefore) * time.Second
+	config.PadAfter = time.Duration(padAfter) * time.Second
+	config.MergeGap = time.Duration(mergeGap) * time.Second
+	for _, field := range strings.Split(fields, ",") {
+		if field != "" {
+			config.Fields = append(config.Fields, persistence.CopyField(field))
+		}
+	}
+
+	return config, nil
+}
+
+func joinCopyFields(fields []persistence.CopyField) string {
+	values := make([]string, 0, len(fields))
+	for _, field := range fields {
+		values = append(values, string(field))
+	}
+	return strings.Join(values, ",")
+}
+
 func (d *Database) CreateCopyConfig(ctx context.Context, sourceCalendarID, destinationCalendarID string) error {
 	stmt, err := d.db.PrepareContext(ctx, `
 INSERT INTO copies (sourceID, destinationID)
@@ -31,6 +81,49 @@ VALUES (?, ?)
 	return nil
 }
 
+// UpdateCopyConfig stores the settings of an existing copy config. The calendars cannot be changed.
+func (d *Database) UpdateCopyConfig(ctx context.Context, config persistence.CopyConfig) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+UPDATE copies
+SET lookBackSeconds = ?, lookAheadSeconds = ?, privacy = ?, busyTitle = ?, bidirectional = ?, conflictPolicy = ?,
+    chainMode = ?, summaryTemplate = ?, descriptionTemplate = ?,
+    fields = ?, colorID = ?, noReminders = ?, recurrence = ?,
+    allDay = ?, timeZone = ?,
+    padBeforeSeconds = ?, padAfterSeconds = ?, padLocationOnly = ?, padMode = ?,
+    mergeBusy = ?, mergeGapSeconds = ?, label = ?, deduplicate = ?
+WHERE id = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx,
+		int64(config.LookBack.Seconds()), int64(config.LookAhead.Seconds()),
+		config.Privacy, config.BusyTitle,
+		config.Bidirectional, config.ConflictPolicy,
+		config.ChainMode,
+		config.SummaryTemplate, config.DescriptionTemplate,
+		joinCopyFields(config.Fields), config.ColorID, config.NoReminders,
+		config.Recurrence,
+		config.AllDay, config.TimeZone,
+		int64(config.PadBefore.Seconds()), int64(config.PadAfter.Seconds()), config.PadLocationOnly, config.PadMode,
+		config.MergeBusy, int64(config.MergeGap.Seconds()), config.Label, config.Deduplicate,
+		config.ID,
+	); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Int("copy-id", config.ID).
+		Dur("look-back", config.LookBack).
+		Dur("look-ahead", config.LookAhead).
+		Str("privacy", string(config.Privacy)).
+		Bool("bidirectional", config.Bidirectional).
+		Msgf("updated copy config")
+
+	return nil
+}
+
 func (d *Database) DeleteCopyConfig(ctx context.Context, copyID string) error {
 	stmt, err := d.db.PrepareContext(ctx, `
 DELETE FROM copies
@@ -44,6 +137,10 @@ WHERE id = ?`)
 		return errors.Wrap(err, "failed to execute statement")
 	}
 
+	if err := d.deleteCopyFilters(ctx, copyID); err != nil {
+		return errors.Wrap(err, "failed to delete copy filters")
+	}
+
 	logs.GetLogger(ctx).Info().
 		Str("copy-id", copyID).
 		Msgf("deleted copy config")
@@ -52,10 +149,8 @@ WHERE id = ?`)
 }
 
 func (d *Database) GetCopyConfig(ctx context.Context, id int64) (persistence.CopyConfig, error) {
-	var config persistence.CopyConfig
-
 	stmt, err := d.db.PrepareContext(ctx, `
-SELECT id, sourceID, destinationID
+SELECT `+copyConfigColumns+`
 FROM copies
 WHERE id = ?`)
 	if err != nil {
@@ -63,11 +158,17 @@ WHERE id = ?`)
 	}
 	defer stmt.Close()
 
-	if err := stmt.QueryRowContext(ctx, id).Scan(&config.ID, &config.SourceID, &config.DestinationID); err != nil {
+	config, err := scanCopyConfig(stmt.QueryRowContext(ctx, id))
+	if err != nil {
 		return persistence.CopyConfig{}, errors.Wrap(err, "failed to parse row")
 	}
 
-	return config, nil
+	configs := []persistence.CopyConfig{config}
+	if err = d.attachCopyFilters(ctx, configs); err != nil {
+		return persistence.CopyConfig{}, errors.Wrap(err, "failed to get copy filters")
+	}
+
+	return configs[0], nil
 }
 
 func (d *Database) queryForCopyConfigs(ctx context.Context, query string, args ...any) ([]persistence.CopyConfig, error) {
@@ -89,27 +190,39 @@ func (d *Database) queryForCopyConfigs(ctx context.Context, query string, args .
 
 	var configs []persistence.CopyConfig
 	for rows.Next() {
-		var config persistence.CopyConfig
-		if err = rows.Scan(&config.ID, &config.SourceID, &config.DestinationID); err != nil {
+		config, err := scanCopyConfig(rows)
+		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		configs = append(configs, config)
 	}
 
+	if err = d.attachCopyFilters(ctx, configs); err != nil {
+		return nil, errors.Wrap(err, "failed to get copy filters")
+	}
+
 	return configs, nil
 }
 
 func (d *Database) GetCopyConfigs(ctx context.Context) ([]persistence.CopyConfig, error) {
 	return d.queryForCopyConfigs(ctx, `
-SELECT id, sourceID, destinationID
+SELECT `+copyConfigColumns+`
 FROM copies
 `)
 }
 
 func (d *Database) GetCopyConfigsBySourceCalendar(ctx context.Context, sourceCalendarID string) ([]persistence.CopyConfig, error) {
 	return d.queryForCopyConfigs(ctx, `
-SELECT id, sourceID, destinationID
+SELECT `+copyConfigColumns+`
 FROM copies
 WHERE sourceID = ?
 `, sourceCalendarID)
 }
+
+func (d *Database) GetCopyConfigsByDestinationCalendar(ctx context.Context, destinationCalendarID string) ([]persistence.CopyConfig, error) {
+	return d.queryForCopyConfigs(ctx, `
+SELECT `+copyConfigColumns+`
+FROM copies
+WHERE destinationID = ?
+`, destinationCalendarID)
+}
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+)
+
+func (d *Database) CreateCopyFilter(ctx context.Context, rule persistence.FilterRule) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+INSERT INTO copyFilters (copyID, action, field, value)
+VALUES (?, ?, ?, ?)
+`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, rule.CopyID, rule.Action, rule.Field, rule.Value); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Int("copy-id", rule.CopyID).
+		Str("action", string(rule.Action)).
+		Str("field", string(rule.Field)).
+		Str("value", rule.Value).
+		Msgf("created copy filter")
+
+	return nil
+}
+
+func (d *Database) DeleteCopyFilter(ctx context.Context, filterID string) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM copyFilters
+WHERE id = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, filterID); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Str("filter-id", filterID).
+		Msgf("deleted copy filter")
+
+	return nil
+}
+
+func (d *Database) deleteCopyFilters(ctx context.Context, copyID string) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM copyFilters
+WHERE copyID = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, copyID); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	return nil
+}
+
+// attachCopyFilters loads the filters of every config. There are few enough filters to read them all at once.
+func (d *Database) attachCopyFilters(ctx context.Context, configs []persistence.CopyConfig) error {
+	if len(configs) == 0 {
+		return nil
+	}
+
+	stmt, err := d.db.PrepareContext(ctx, `
+SELECT id, copyID, action, field, value
+FROM copyFilters
+ORDER BY id`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+	defer rows.Close()
+
+	filtersByCopyID := make(map[int][]persistence.FilterRule)
+	for rows.Next() {
+		var rule persistence.FilterRule
+		if err := rows.Scan(&rule.ID, &rule.CopyID, &rule.Action, &rule.Field, &rule.Value); err != nil {
+			return errors.Wrap(err, "failed to scan row")
+		}
+		filtersByCopyID[rule.CopyID] = append(filtersByCopyID[rule.CopyID], rule)
+	}
+	if err = rows.Err(); err != nil {
+		return errors.Wrap(err, "error getting rows")
+	}
+
+	for idx := range configs {
+		configs[idx].Filters = filtersByCopyID[configs[idx].ID]
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package sqlite_test
 import (
 	"database/sql"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"calendar-sync/pkg"
+	"calendar-sync/pkg/persistence"
 	"calendar-sync/pkg/persistence/sqlite"
 )
 
@@ -77,4 +79,332 @@ func TestDatabase(t *testing.T) {
 
 	_, err = db.GetWatchConfig(ctx, "watch-id")
 	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// invites
+	err = db.CreateInviteConfig(ctx, "calendar-id", "guest@example.com", 0)
+	require.NoError(t, err)
+
+	is, err := db.GetInviteConfigsBySourceCalendar(ctx, "calendar-id")
+	require.NoError(t, err)
+	require.Len(t, is, 1)
+	assert.Equal(t, "guest@example.com", is[0].EmailAddress)
+	assert.Equal(t, persistence.SendUpdatesNone, is[0].SendUpdates)
+
+	invite := is[0]
+	invite.SummaryPattern = "^Standup"
+	invite.OnlyWithAttendees = true
+	invite.OnlyOwnEvents = true
+	invite.WeekdaysOnly = true
+	invite.ExcludeAllDay = true
+	invite.Optional = true
+	invite.SendUpdates = persistence.SendUpdatesExternal
+	err = db.UpdateInviteConfig(ctx, invite)
+	require.NoError(t, err)
+
+	i, err := db.GetInviteConfig(ctx, int64(invite.ID))
+	require.NoError(t, err)
+	assert.Equal(t, invite, i)
+
+	guest := persistence.InvitedGuest{InviteID: invite.ID, CalendarID: "calendar-id", EventID: "event-id", EmailAddress: "guest@example.com"}
+	require.NoError(t, db.CreateInvitedGuest(ctx, guest))
+	require.NoError(t, db.CreateInvitedGuest(ctx, persistence.InvitedGuest{InviteID: invite.ID, CalendarID: "calendar-id", EventID: "other-event-id", EmailAddress: "guest@example.com"}))
+	require.NoError(t, db.CreateInvitedGuest(ctx, persistence.InvitedGuest{InviteID: invite.ID, CalendarID: "calendar-id", EventID: "event-id", EmailAddress: "other@example.com"}))
+	require.NoError(t, db.DeleteInvitedGuest(ctx, persistence.InvitedGuest{InviteID: invite.ID, EventID: "other-event-id", EmailAddress: "guest@example.com"}))
+	require.NoError(t, db.DeleteInvitedGuest(ctx, persistence.InvitedGuest{InviteID: invite.ID, EventID: "event-id", EmailAddress: "other@example.com"}))
+
+	guests, err := db.GetInvitedGuests(ctx, invite.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []persistence.InvitedGuest{guest}, guests)
+
+	// deleting the config forgets its guests
+	require.NoError(t, db.DeleteInviteConfig(ctx, strconv.Itoa(invite.ID)))
+	guests, err = db.GetInvitedGuests(ctx, invite.ID)
+	require.NoError(t, err)
+	assert.Empty(t, guests)
+
+	// groups
+	require.NoError(t, db.CreateGroup(ctx, "team"))
+	groups, err := db.GetGroups(ctx)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Empty(t, groups[0].Members)
+
+	require.NoError(t, db.AddGroupMember(ctx, groups[0].ID, "b@example.com"))
+	require.NoError(t, db.AddGroupMember(ctx, groups[0].ID, "a@example.com"))
+	require.NoError(t, db.AddGroupMember(ctx, groups[0].ID, "c@example.com"))
+	require.NoError(t, db.RemoveGroupMember(ctx, groups[0].ID, "c@example.com"))
+
+	group, err := db.GetGroup(ctx, groups[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, persistence.Group{ID: groups[0].ID, Name: "team", Members: []string{"a@example.com", "b@example.com"}}, group)
+
+	// a calendar can invite several groups without any address
+	require.NoError(t, db.CreateInviteConfig(ctx, "calendar-id", "", group.ID))
+	require.NoError(t, db.CreateInviteConfig(ctx, "calendar-id", "", group.ID+1))
+	require.Error(t, db.CreateInviteConfig(ctx, "calendar-id", "", group.ID))
+
+	// groups which are still invited are kept
+	require.ErrorIs(t, db.DeleteGroup(ctx, strconv.Itoa(group.ID)), sqlite.ErrGroupInUse)
+	is, err = db.GetInviteConfigsBySourceCalendar(ctx, "calendar-id")
+	require.NoError(t, err)
+	for _, i := range is {
+		require.NoError(t, db.DeleteInviteConfig(ctx, strconv.Itoa(i.ID)))
+	}
+
+	require.NoError(t, db.DeleteGroup(ctx, strconv.Itoa(group.ID)))
+	_, err = db.GetGroup(ctx, group.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// copies
+	err = db.CreateCopyConfig(ctx, "source-id", "destination-id")
+	require.NoError(t, err)
+
+	cs, err := db.GetCopyConfigsBySourceCalendar(ctx, "source-id")
+	require.NoError(t, err)
+	require.Len(t, cs, 1)
+	assert.Equal(t, "destination-id", cs[0].DestinationID)
+	assert.Equal(t, time.Duration(0), cs[0].LookBack)
+	assert.Equal(t, 14*24*time.Hour, cs[0].LookAhead)
+
+	assert.Equal(t, persistence.PrivacyFull, cs[0].Privacy)
+	assert.Equal(t, "Busy", cs[0].BusyTitle)
+	assert.Equal(t, persistence.ChainTransitive, cs[0].ChainMode)
+	assert.Equal(t, persistence.RecurrenceSeries, cs[0].Recurrence)
+	assert.Equal(t, persistence.AllDayKeep, cs[0].AllDay)
+	assert.Equal(t, persistence.PadExtend, cs[0].PadMode)
+
+	update := cs[0]
+	update.LookBack = 24 * time.Hour
+	update.LookAhead = 30 * 24 * time.Hour
+	update.Privacy = persistence.PrivacyBusy
+	update.BusyTitle = "Unavailable"
+	update.Bidirectional = true
+	update.ConflictPolicy = persistence.ConflictLastModifiedWins
+	update.ChainMode = persistence.ChainSkip
+	update.SummaryTemplate = "[Work] {{.Summary}}"
+	update.DescriptionTemplate = "{{.HtmlLink}}"
+	update.Fields = []persistence.CopyField{persistence.CopyAttendees, persistence.CopyConferenceData}
+	update.ColorID = "11"
+	update.NoReminders = true
+	update.Recurrence = persistence.RecurrenceExpand
+	update.AllDay = persistence.AllDayToTimed
+	update.TimeZone = "Europe/Berlin"
+	update.PadBefore = 15 * time.Minute
+	update.PadAfter = 30 * time.Minute
+	update.PadLocationOnly = true
+	update.PadMode = persistence.PadSeparate
+	update.MergeBusy = true
+	update.MergeGap = 10 * time.Minute
+	update.Label = "Team"
+	update.Deduplicate = true
+	err = db.UpdateCopyConfig(ctx, update)
+	require.NoError(t, err)
+
+	c, err := db.GetCopyConfig(ctx, int64(cs[0].ID))
+	require.NoError(t, err)
+	assert.Equal(t, update, c)
+
+	// copy filters
+	err = db.CreateCopyFilter(ctx, persistence.FilterRule{
+		CopyID: c.ID,
+		Action: persistence.FilterExclude,
+		Field:  persistence.FilterSummary,
+		Value:  "^Lunch$",
+	})
+	require.NoError(t, err)
+
+	c, err = db.GetCopyConfig(ctx, int64(c.ID))
+	require.NoError(t, err)
+	require.Len(t, c.Filters, 1)
+	assert.Equal(t, persistence.FilterSummary, c.Filters[0].Field)
+
+	cs, err = db.GetCopyConfigs(ctx)
+	require.NoError(t, err)
+	require.Len(t, cs, 1)
+	assert.Equal(t, c.Filters, cs[0].Filters)
+
+	err = db.DeleteCopyFilter(ctx, strconv.Itoa(c.Filters[0].ID))
+	require.NoError(t, err)
+
+	c, err = db.GetCopyConfig(ctx, int64(c.ID))
+	require.NoError(t, err)
+	assert.Empty(t, c.Filters)
+
+	// feeds
+	err = db.CreateFeedConfig(ctx, "calendar-id", "feed-token", true)
+	require.NoError(t, err)
+
+	f, err := db.GetFeedConfigByToken(ctx, "feed-token")
+	require.NoError(t, err)
+	assert.Equal(t, "calendar-id", f.CalendarID)
+	assert.True(t, f.Merged)
+
+	fs, err := db.GetFeedConfigs(ctx)
+	require.NoError(t, err)
+	assert.Len(t, fs, 1)
+
+	err = db.DeleteFeedConfig(ctx, strconv.Itoa(f.ID))
+	require.NoError(t, err)
+
+	_, err = db.GetFeedConfigByToken(ctx, "feed-token")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// sync tokens
+	_, err = db.GetSyncToken(ctx, "calendar-id", false)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = db.SetSyncToken(ctx, "calendar-id", false, "token-1")
+	require.NoError(t, err)
+	err = db.SetSyncToken(ctx, "calendar-id", false, "token-2")
+	require.NoError(t, err)
+	err = db.SetSyncToken(ctx, "calendar-id", true, "single-token")
+	require.NoError(t, err)
+
+	token, err := db.GetSyncToken(ctx, "calendar-id", false)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+
+	err = db.DeleteSyncToken(ctx, "calendar-id", false)
+	require.NoError(t, err)
+
+	_, err = db.GetSyncToken(ctx, "calendar-id", false)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	token, err = db.GetSyncToken(ctx, "calendar-id", true)
+	require.NoError(t, err)
+	assert.Equal(t, "single-token", token)
+
+	// conflicts
+	for _, summary := range []string{"first", "second"} {
+		err = db.CreateConflict(ctx, persistence.Conflict{
+			CopyID:             c.ID,
+			SourceEventID:      "source-event-id",
+			DestinationEventID: "destination-event-id",
+			Summary:            summary,
+			Winner:             "source",
+			CreatedAt:          time.Now(),
+		})
+		require.NoError(t, err)
+	}
+
+	conflicts, err := db.GetConflicts(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "second", conflicts[0].Summary)
+	assert.Equal(t, c.ID, conflicts[0].CopyID)
+
+	// jobs
+	now := time.Now()
+	created, err := db.CreateJob(ctx, persistence.Job{Kind: "kind", Args: `{}`, IdempotencyKey: "key", RunAt: now})
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = db.CreateJob(ctx, persistence.Job{Kind: "kind", Args: `{}`, IdempotencyKey: "key", RunAt: now})
+	require.NoError(t, err)
+	assert.False(t, created, "a pending job with the same key is queued already")
+
+	_, err = db.ClaimJob(ctx, now.Add(-time.Minute))
+	require.ErrorIs(t, err, sql.ErrNoRows, "the job isn't due yet")
+
+	job, err := db.ClaimJob(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, persistence.JobRunning, job.State)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, "key", job.IdempotencyKey)
+
+	_, err = db.ClaimJob(ctx, now)
+	require.ErrorIs(t, err, sql.ErrNoRows, "running jobs aren't claimed twice")
+
+	// a job queued while one with the same key runs waits for it, and takes the place of its retry
+	created, err = db.CreateJob(ctx, persistence.Job{Kind: "kind", Args: `{}`, IdempotencyKey: "key", RunAt: now})
+	require.NoError(t, err)
+	assert.True(t, created)
+	_, err = db.ClaimJob(ctx, now)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, db.RetryJob(ctx, job.ID, now, "failed"))
+	_, err = db.GetJob(ctx, job.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	job, err = db.ClaimJob(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.Attempts)
+
+	require.NoError(t, db.RetryJob(ctx, job.ID, now.Add(time.Hour), "failed"))
+	_, err = db.ClaimJob(ctx, now)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	job, err = db.ClaimJob(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, job.Attempts)
+
+	require.NoError(t, db.ResetRunningJobs(ctx))
+	job, err = db.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, persistence.JobPending, job.State)
+
+	require.NoError(t, db.MarkJobDead(ctx, job.ID, "failed for good"))
+	jobs, err := db.GetFailedJobs(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, persistence.JobDead, jobs[0].State)
+	assert.Equal(t, "failed for good", jobs[0].LastError)
+
+	require.NoError(t, db.RequeueJob(ctx, job.ID))
+	job, err = db.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, persistence.JobPending, job.State)
+	assert.Zero(t, job.Attempts)
+
+	require.NoError(t, db.MarkJobDead(ctx, job.ID, "failed for good"))
+	created, err = db.CreateJob(ctx, persistence.Job{Kind: "kind", Args: `{}`, IdempotencyKey: "key", RunAt: now})
+	require.NoError(t, err)
+	assert.True(t, created, "dead jobs don't hold on to their key")
+
+	require.NoError(t, db.RequeueJob(ctx, job.ID))
+	_, err = db.GetJob(ctx, job.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows, "the new job takes the place of the dead one")
+
+	job, err = db.ClaimJob(ctx, now)
+	require.NoError(t, err)
+	require.NoError(t, db.DeleteJob(ctx, job.ID))
+	_, err = db.GetJob(ctx, job.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// old history
+	require.NoError(t, db.DeleteConflictsBefore(ctx, time.Now().Add(-time.Hour)))
+	conflicts, err = db.GetConflicts(ctx, 10)
+	require.NoError(t, err)
+	assert.Len(t, conflicts, 2)
+	require.NoError(t, db.DeleteConflictsBefore(ctx, time.Now().Add(time.Hour)))
+	conflicts, err = db.GetConflicts(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+
+	// scheduled jobs
+	scheduled := persistence.ScheduledJob{Name: "copy-all", Spec: "@every 1h", NextRunAt: now.Add(time.Hour)}
+	require.NoError(t, db.SaveScheduledJob(ctx, scheduled))
+
+	scheduledJobs, err := db.GetScheduledJobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, scheduledJobs, 1)
+	assert.Zero(t, scheduledJobs[0].LastRunAt)
+	assert.WithinDuration(t, scheduled.NextRunAt, scheduledJobs[0].NextRunAt, time.Millisecond)
+
+	scheduled.LastRunAt = now
+	scheduled.LastDuration = 1500 * time.Millisecond
+	scheduled.LastError = "failed"
+	require.NoError(t, db.SaveScheduledJob(ctx, scheduled))
+	require.NoError(t, db.TriggerScheduledJob(ctx, "copy-all"))
+	require.ErrorIs(t, db.TriggerScheduledJob(ctx, "unknown"), sql.ErrNoRows)
+
+	scheduledJobs, err = db.GetScheduledJobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, scheduledJobs, 1)
+	assert.WithinDuration(t, now, scheduledJobs[0].LastRunAt, time.Millisecond)
+	assert.Equal(t, 1500*time.Millisecond, scheduledJobs[0].LastDuration)
+	assert.Equal(t, "failed", scheduledJobs[0].LastError)
+	assert.WithinDuration(t, time.Now(), scheduledJobs[0].NextRunAt, time.Minute)
+
+	require.NoError(t, db.DeleteScheduledJob(ctx, "copy-all"))
+	scheduledJobs, err = db.GetScheduledJobs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, scheduledJobs)
 }
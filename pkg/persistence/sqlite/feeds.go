@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+)
+
+func (d *Database) CreateFeedConfig(ctx context.Context, calendarID, token string, merged bool) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+INSERT INTO feeds (calendarID, token, merged)
+VALUES (?, ?, ?)
+`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, calendarID, token, merged); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Str("calendar-id", calendarID).
+		Bool("merged", merged).
+		Msgf("created feed config")
+
+	return nil
+}
+
+func (d *Database) DeleteFeedConfig(ctx context.Context, feedID string) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM feeds
+WHERE id = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, feedID); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Str("feed-id", feedID).
+		Msgf("deleted feed config")
+
+	return nil
+}
+
+func (d *Database) GetFeedConfigByToken(ctx context.Context, token string) (persistence.FeedConfig, error) {
+	var config persistence.FeedConfig
+
+	stmt, err := d.db.PrepareContext(ctx, `
+SELECT id, calendarID, token, merged
+FROM feeds
+WHERE token = ?`)
+	if err != nil {
+		return config, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowContext(ctx, token).Scan(&config.ID, &config.CalendarID, &config.Token, &config.Merged); err != nil {
+		return config, errors.Wrap(err, "failed to parse row")
+	}
+
+	return config, nil
+}
+
+func (d *Database) GetFeedConfigs(ctx context.Context) ([]persistence.FeedConfig, error) {
+	stmt, err := d.db.PrepareContext(ctx, `
+SELECT id, calendarID, token, merged
+FROM feeds
+`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute statement")
+	}
+	defer rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to get rows")
+	}
+
+	var configs []persistence.FeedConfig
+	for rows.Next() {
+		var config persistence.FeedConfig
+		if err = rows.Scan(&config.ID, &config.CalendarID, &config.Token, &config.Merged); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
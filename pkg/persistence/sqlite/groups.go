@@ -0,0 +1,202 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+)
+
+// ErrGroupInUse is returned when deleting a group which invite configs still invite. Deleting those invite configs
+// first removes the members they added.
+var ErrGroupInUse = errors.New("group is used by invite configs")
+
+func (d *Database) CreateGroup(ctx context.Context, name string) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+INSERT INTO inviteGroups (name)
+VALUES (?)
+`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, name); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Str("name", name).
+		Msg("created group")
+
+	return nil
+}
+
+// DeleteGroup deletes a group and its members, unless invite configs still invite it.
+func (d *Database) DeleteGroup(ctx context.Context, groupID string) error {
+	inUse, err := d.groupInUse(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrGroupInUse
+	}
+
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM inviteGroups
+WHERE id = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, groupID); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	if err := d.deleteGroupMembers(ctx, groupID); err != nil {
+		return errors.Wrap(err, "failed to delete group members")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Str("group-id", groupID).
+		Msg("deleted group")
+
+	return nil
+}
+
+func (d *Database) groupInUse(ctx context.Context, groupID string) (bool, error) {
+	stmt, err := d.db.PrepareContext(ctx, `
+SELECT EXISTS (SELECT 1 FROM invites WHERE groupID = ?)`)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	var inUse bool
+	if err := stmt.QueryRowContext(ctx, groupID).Scan(&inUse); err != nil {
+		return false, errors.Wrap(err, "failed to scan row")
+	}
+
+	return inUse, nil
+}
+
+func (d *Database) deleteGroupMembers(ctx context.Context, groupID string) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM inviteGroupMembers
+WHERE groupID = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, groupID); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	return nil
+}
+
+func (d *Database) AddGroupMember(ctx context.Context, groupID int, emailAddress string) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+INSERT OR IGNORE INTO inviteGroupMembers (groupID, emailAddress)
+VALUES (?, ?)
+`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, groupID, emailAddress); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Int("group-id", groupID).
+		Str("email-address", emailAddress).
+		Msg("added group member")
+
+	return nil
+}
+
+func (d *Database) RemoveGroupMember(ctx context.Context, groupID int, emailAddress string) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM inviteGroupMembers
+WHERE groupID = ? AND emailAddress = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, groupID, emailAddress); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Int("group-id", groupID).
+		Str("email-address", emailAddress).
+		Msg("removed group member")
+
+	return nil
+}
+
+func (d *Database) GetGroup(ctx context.Context, groupID int) (persistence.Group, error) {
+	groups, err := d.GetGroups(ctx)
+	if err != nil {
+		return persistence.Group{}, err
+	}
+
+	for _, group := range groups {
+		if group.ID == groupID {
+			return group, nil
+		}
+	}
+
+	return persistence.Group{}, errors.Wrapf(sql.ErrNoRows, "group %d", groupID)
+}
+
+// GetGroups returns every group with its members. There are few enough of them to read them all at once.
+func (d *Database) GetGroups(ctx context.Context) ([]persistence.Group, error) {
+	stmt, err := d.db.PrepareContext(ctx, `
+SELECT g.id, g.name, m.emailAddress
+FROM inviteGroups g
+LEFT JOIN inviteGroupMembers m ON m.groupID = g.id
+ORDER BY g.id, m.emailAddress`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query context")
+	}
+	defer rows.Close()
+
+	var groups []persistence.Group
+	for rows.Next() {
+		var (
+			group  persistence.Group
+			member sql.NullString
+		)
+		if err := rows.Scan(&group.ID, &group.Name, &member); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+
+		if n := len(groups); n == 0 || groups[n-1].ID != group.ID {
+			groups = append(groups, group)
+		}
+		if member.Valid {
+			last := &groups[len(groups)-1]
+			last.Members = append(last.Members, member.String)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to get rows")
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+)
+
+// CreateInvitedGuest records a guest an invite config added to an event.
+func (d *Database) CreateInvitedGuest(ctx context.Context, guest persistence.InvitedGuest) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+INSERT OR REPLACE INTO invitedGuests (inviteID, calendarID, eventID, emailAddress)
+VALUES (?, ?, ?, ?)
+`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, guest.InviteID, guest.CalendarID, guest.EventID, guest.EmailAddress); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Int("invite-id", guest.InviteID).
+		Str("calendar-item-id", guest.EventID).
+		Str("email-address", guest.EmailAddress).
+		Msg("recorded invited guest")
+
+	return nil
+}
+
+func (d *Database) DeleteInvitedGuest(ctx context.Context, guest persistence.InvitedGuest) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM invitedGuests
+WHERE inviteID = ? AND eventID = ? AND emailAddress = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, guest.InviteID, guest.EventID, guest.EmailAddress); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Int("invite-id", guest.InviteID).
+		Str("calendar-item-id", guest.EventID).
+		Str("email-address", guest.EmailAddress).
+		Msg("deleted invited guest")
+
+	return nil
+}
+
+func (d *Database) deleteInvitedGuests(ctx context.Context, inviteID string) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM invitedGuests
+WHERE inviteID = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, inviteID); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	return nil
+}
+
+func (d *Database) GetInvitedGuests(ctx context.Context, inviteID int) ([]persistence.InvitedGuest, error) {
+	stmt, err := d.db.PrepareContext(ctx, `
+SELECT inviteID, calendarID, eventID, emailAddress
+FROM invitedGuests
+WHERE inviteID = ?`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, inviteID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query context")
+	}
+	defer rows.Close()
+
+	var guests []persistence.InvitedGuest
+	for rows.Next() {
+		var guest persistence.InvitedGuest
+		if err := rows.Scan(&guest.InviteID, &guest.CalendarID, &guest.EventID, &guest.EmailAddress); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+
+		guests = append(guests, guest)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to get rows")
+	}
+
+	return guests, nil
+}
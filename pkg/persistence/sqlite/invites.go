@@ -9,23 +9,39 @@ import (
 	"calendar-sync/pkg/persistence"
 )
 
-func (d *Database) CreateInviteConfig(ctx context.Context, calendarID, emailAddress string) error {
+const inviteConfigColumns = `id, calendarID, emailAddress, groupID, summaryPattern, onlyWithAttendees, onlyOwnEvents, weekdaysOnly, excludeAllDay, optional, sendUpdates`
+
+func scanInviteConfig(row rowScanner) (persistence.InviteConfig, error) {
+	var config persistence.InviteConfig
+
+	err := row.Scan(
+		&config.ID, &config.CalendarID, &config.EmailAddress, &config.GroupID,
+		&config.SummaryPattern, &config.OnlyWithAttendees, &config.OnlyOwnEvents, &config.WeekdaysOnly, &config.ExcludeAllDay,
+		&config.Optional, &config.SendUpdates,
+	)
+
+	return config, err
+}
+
+// CreateInviteConfig invites an address, the members of a group, or both.
+func (d *Database) CreateInviteConfig(ctx context.Context, calendarID, emailAddress string, groupID int) error {
 	stmt, err := d.db.PrepareContext(ctx, `
-INSERT INTO invites (calendarID, emailAddress)
-VALUES (?, ?)
+INSERT INTO invites (calendarID, emailAddress, groupID)
+VALUES (?, ?, ?)
 `)
 	if err != nil {
 		return errors.Wrap(err, "failed to prepare statement")
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.ExecContext(ctx, calendarID, emailAddress); err != nil {
+	if _, err := stmt.ExecContext(ctx, calendarID, emailAddress, groupID); err != nil {
 		return errors.Wrap(err, "failed to execute statement")
 	}
 
 	logs.GetLogger(ctx).Info().
 		Str("calendar-id", calendarID).
 		Str("email-address", emailAddress).
+		Int("group-id", groupID).
 		Msgf("created invite config")
 
 	return nil
@@ -44,6 +60,10 @@ WHERE id = ?`)
 		return errors.Wrap(err, "failed to execute statement")
 	}
 
+	if err := d.deleteInvitedGuests(ctx, inviteID); err != nil {
+		return errors.Wrap(err, "failed to delete invited guests")
+	}
+
 	logs.GetLogger(ctx).Info().
 		Str("invite-id", inviteID).
 		Msgf("deleted invite config")
@@ -51,11 +71,36 @@ WHERE id = ?`)
 	return nil
 }
 
-func (d *Database) GetInviteConfig(ctx context.Context, id int64) (persistence.InviteConfig, error) {
-	var config persistence.InviteConfig
+// UpdateInviteConfig stores the conditions and options of an existing invite config.
+func (d *Database) UpdateInviteConfig(ctx context.Context, config persistence.InviteConfig) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+UPDATE invites
+SET summaryPattern = ?, onlyWithAttendees = ?, onlyOwnEvents = ?, weekdaysOnly = ?, excludeAllDay = ?,
+    optional = ?, sendUpdates = ?
+WHERE id = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
 
+	if _, err := stmt.ExecContext(ctx,
+		config.SummaryPattern, config.OnlyWithAttendees, config.OnlyOwnEvents, config.WeekdaysOnly, config.ExcludeAllDay,
+		config.Optional, config.SendUpdates,
+		config.ID,
+	); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Int("invite-id", config.ID).
+		Msg("updated invite config")
+
+	return nil
+}
+
+func (d *Database) GetInviteConfig(ctx context.Context, id int64) (persistence.InviteConfig, error) {
 	stmt, err := d.db.PrepareContext(ctx, `
-SELECT id, calendarID, emailAddress
+SELECT `+inviteConfigColumns+`
 FROM invites
 WHERE id = ?`)
 	if err != nil {
@@ -63,7 +108,8 @@ WHERE id = ?`)
 	}
 	defer stmt.Close()
 
-	if err := stmt.QueryRowContext(ctx, id).Scan(&config.ID, &config.CalendarID, &config.EmailAddress); err != nil {
+	config, err := scanInviteConfig(stmt.QueryRowContext(ctx, id))
+	if err != nil {
 		return persistence.InviteConfig{}, errors.Wrap(err, "failed to parse row")
 	}
 
@@ -89,8 +135,8 @@ func (d *Database) queryInviteConfigs(ctx context.Context, query string, args ..
 
 	var configs []persistence.InviteConfig
 	for rows.Next() {
-		var config persistence.InviteConfig
-		if err = rows.Scan(&config.ID, &config.CalendarID, &config.EmailAddress); err != nil {
+		config, err := scanInviteConfig(rows)
+		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 
@@ -102,14 +148,14 @@ func (d *Database) queryInviteConfigs(ctx context.Context, query string, args ..
 
 func (d *Database) GetInviteConfigs(ctx context.Context) ([]persistence.InviteConfig, error) {
 	return d.queryInviteConfigs(ctx, `
-SELECT id, calendarID, emailAddress 
+SELECT `+inviteConfigColumns+`
 FROM invites
 `)
 }
 
 func (d *Database) GetInviteConfigsBySourceCalendar(ctx context.Context, calendarID string) ([]persistence.InviteConfig, error) {
 	return d.queryInviteConfigs(ctx, `
-SELECT id, calendarID, emailAddress 
+SELECT `+inviteConfigColumns+`
 FROM invites
 WHERE calendarID = ?
 `, calendarID)
@@ -0,0 +1,320 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+)
+
+// jobColumns are read by scanJob. The times of jobs are stored in UTC, so they compare correctly as text.
+const jobColumns = `id, kind, args, COALESCE(idempotencyKey, ''), state, attempts, runAt, lastError, createdAt, updatedAt`
+
+func scanJob(row rowScanner) (persistence.Job, error) {
+	var job persistence.Job
+	err := row.Scan(
+		&job.ID, &job.Kind, &job.Args, &job.IdempotencyKey, &job.State, &job.Attempts,
+		&job.RunAt, &job.LastError, &job.CreatedAt, &job.UpdatedAt,
+	)
+	return job, err
+}
+
+// CreateJob queues a pending job. It returns false without an error if a job with the same idempotency key is already
+// pending, jobs without a key are always queued.
+func (d *Database) CreateJob(ctx context.Context, job persistence.Job) (bool, error) {
+	stmt, err := d.db.PrepareContext(ctx, `
+INSERT OR IGNORE INTO jobs (kind, args, idempotencyKey, state, runAt, createdAt, updatedAt)
+VALUES (?, ?, NULLIF(?, ''), ?, ?, ?, ?)
+`)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	result, err := stmt.ExecContext(ctx, job.Kind, job.Args, job.IdempotencyKey, persistence.JobPending, job.RunAt.UTC(), now, now)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to execute statement")
+	}
+
+	created, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get affected rows")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Str("kind", job.Kind).
+		Str("idempotency-key", job.IdempotencyKey).
+		Bool("created", created > 0).
+		Msg("queued job")
+
+	return created > 0, nil
+}
+
+// ClaimJob marks the pending job which is due the longest as running and returns it, skipping jobs whose idempotency
+// key is running already. It returns sql.ErrNoRows if no job is due.
+func (d *Database) ClaimJob(ctx context.Context, now time.Time) (persistence.Job, error) {
+	stmt, err := d.db.PrepareContext(ctx, `
+UPDATE jobs
+SET state = ?, attempts = attempts + 1, updatedAt = ?
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE state = ? AND runAt <= ? AND (idempotencyKey IS NULL OR idempotencyKey NOT IN (
+        SELECT idempotencyKey FROM jobs WHERE state = ? AND idempotencyKey IS NOT NULL
+    ))
+    ORDER BY runAt, id
+    LIMIT 1
+)
+RETURNING `+jobColumns)
+	if err != nil {
+		return persistence.Job{}, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	now = now.UTC()
+	job, err := scanJob(stmt.QueryRowContext(ctx, persistence.JobRunning, now, persistence.JobPending, now, persistence.JobRunning))
+	if err != nil {
+		return persistence.Job{}, errors.Wrap(err, "failed to claim job")
+	}
+
+	return job, nil
+}
+
+// RetryJob makes a running job pending again, to run at runAt. The job is deleted instead if a job with the same
+// idempotency key was queued while it ran, which does the same work.
+func (d *Database) RetryJob(ctx context.Context, id int, runAt time.Time, lastError string) error {
+	updated, err := d.updateJobState(ctx, id, persistence.JobPending, runAt, lastError)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return d.DeleteJob(ctx, id)
+	}
+
+	return nil
+}
+
+// MarkJobDead stops retrying a job.
+func (d *Database) MarkJobDead(ctx context.Context, id int, lastError string) error {
+	_, err := d.updateJobState(ctx, id, persistence.JobDead, time.Now(), lastError)
+	return err
+}
+
+// updateJobState returns false if the job wasn't updated, because another pending job has its idempotency key.
+func (d *Database) updateJobState(ctx context.Context, id int, state persistence.JobState, runAt time.Time, lastError string) (bool, error) {
+	stmt, err := d.db.PrepareContext(ctx, `
+UPDATE OR IGNORE jobs
+SET state = ?, runAt = ?, lastError = ?, updatedAt = ?
+WHERE id = ?`)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, state, runAt.UTC(), lastError, time.Now().UTC(), id)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to execute statement")
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get affected rows")
+	}
+
+	logs.GetLogger(ctx).Info().
+		Int("job-id", id).
+		Str("state", string(state)).
+		Time("run-at", runAt).
+		Bool("updated", updated > 0).
+		Msg("updated job")
+
+	return updated > 0, nil
+}
+
+// RequeueJob gives a dead job a fresh set of attempts, starting now. A dead job is deleted instead if a job with the
+// same idempotency key was queued since.
+func (d *Database) RequeueJob(ctx context.Context, id int) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+UPDATE OR IGNORE jobs
+SET state = ?, attempts = 0, runAt = ?, updatedAt = ?
+WHERE id = ? AND state = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	result, err := stmt.ExecContext(ctx, persistence.JobPending, now, now, id, persistence.JobDead)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	requeued, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "failed to get affected rows")
+	}
+	if requeued == 0 {
+		return d.deleteDeadJob(ctx, id)
+	}
+
+	logs.GetLogger(ctx).Info().
+		Int("job-id", id).
+		Msg("requeued job")
+
+	return nil
+}
+
+func (d *Database) deleteDeadJob(ctx context.Context, id int) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM jobs
+WHERE id = ? AND state = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, id, persistence.JobDead); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	return nil
+}
+
+// ResetRunningJobs makes jobs pending again which were running when the process stopped. Jobs are deleted instead if
+// a job with the same idempotency key was queued while they ran.
+func (d *Database) ResetRunningJobs(ctx context.Context) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+UPDATE OR IGNORE jobs
+SET state = ?, updatedAt = ?
+WHERE state = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, persistence.JobPending, time.Now().UTC(), persistence.JobRunning)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	if reset, err := result.RowsAffected(); err == nil && reset > 0 {
+		logs.GetLogger(ctx).Info().
+			Int64("jobs", reset).
+			Msg("reset interrupted jobs")
+	}
+
+	return d.deleteRunningJobs(ctx)
+}
+
+func (d *Database) deleteRunningJobs(ctx context.Context) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM jobs
+WHERE state = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, persistence.JobRunning); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	return nil
+}
+
+func (d *Database) DeleteJob(ctx context.Context, id int) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM jobs
+WHERE id = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, id); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	return nil
+}
+
+// DeleteDeadJobsBefore deletes the jobs which died before a time.
+func (d *Database) DeleteDeadJobsBefore(ctx context.Context, before time.Time) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM jobs
+WHERE state = ? AND updatedAt < ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, persistence.JobDead, before.UTC())
+	if err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	if deleted, err := result.RowsAffected(); err == nil {
+		logs.GetLogger(ctx).Info().
+			Int64("jobs", deleted).
+			Time("before", before).
+			Msg("deleted dead jobs")
+	}
+
+	return nil
+}
+
+func (d *Database) GetJob(ctx context.Context, id int) (persistence.Job, error) {
+	stmt, err := d.db.PrepareContext(ctx, `
+SELECT `+jobColumns+`
+FROM jobs
+WHERE id = ?`)
+	if err != nil {
+		return persistence.Job{}, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	job, err := scanJob(stmt.QueryRowContext(ctx, id))
+	if err != nil {
+		return persistence.Job{}, errors.Wrap(err, "failed to parse row")
+	}
+
+	return job, nil
+}
+
+// GetFailedJobs returns the jobs which failed at least once, dead ones and ones waiting to be retried, the most
+// recently failed first.
+func (d *Database) GetFailedJobs(ctx context.Context, limit int) ([]persistence.Job, error) {
+	stmt, err := d.db.PrepareContext(ctx, `
+SELECT `+jobColumns+`
+FROM jobs
+WHERE lastError != ''
+ORDER BY updatedAt DESC, id DESC
+LIMIT ?`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute statement")
+	}
+	defer rows.Close()
+
+	var jobs []persistence.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		jobs = append(jobs, job)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "error getting rows")
+	}
+
+	return jobs, nil
+}
@@ -48,6 +48,172 @@ CREATE UNIQUE INDEX IF NOT EXISTS watches_calendarID ON watches (calendarID);
 CREATE UNIQUE INDEX IF NOT EXISTS watches_watchID ON watches (watchID);
 `,
 	2: ``,
+	3: `
+CREATE TABLE IF NOT EXISTS feeds (
+    id 			INTEGER PRIMARY KEY AUTOINCREMENT,
+    calendarID 	TEXT 	NOT NULL,
+    token 		TEXT 	NOT NULL,
+    merged 		BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS feeds_token ON feeds (token);
+`,
+	4: `
+ALTER TABLE copies ADD COLUMN lookBackSeconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE copies ADD COLUMN lookAheadSeconds INTEGER NOT NULL DEFAULT 1209600;
+`,
+	5: `
+CREATE TABLE IF NOT EXISTS syncTokens (
+    calendarID 	TEXT 	PRIMARY KEY,
+    token 		TEXT 	NOT NULL
+);
+`,
+	6: `
+ALTER TABLE copies ADD COLUMN privacy TEXT NOT NULL DEFAULT 'full';
+ALTER TABLE copies ADD COLUMN busyTitle TEXT NOT NULL DEFAULT 'Busy';
+`,
+	7: `
+CREATE TABLE IF NOT EXISTS copyFilters (
+    id 		INTEGER PRIMARY KEY AUTOINCREMENT,
+    copyID 	INTEGER NOT NULL,
+    action 	TEXT 	NOT NULL,
+    field 	TEXT 	NOT NULL,
+    value 	TEXT 	NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS copyFilters_copyID ON copyFilters (copyID);
+`,
+	8: `
+ALTER TABLE copies ADD COLUMN bidirectional BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE copies ADD COLUMN conflictPolicy TEXT NOT NULL DEFAULT 'source-wins';
+
+CREATE TABLE IF NOT EXISTS conflicts (
+    id 					INTEGER PRIMARY KEY AUTOINCREMENT,
+    copyID 				INTEGER NOT NULL,
+    sourceEventID 		TEXT 	NOT NULL,
+    destinationEventID 	TEXT 	NOT NULL,
+    summary 			TEXT 	NOT NULL,
+    winner 				TEXT 	NOT NULL,
+    createdAt 			DATE 	NOT NULL
+);
+`,
+	9: `
+ALTER TABLE copies ADD COLUMN chainMode TEXT NOT NULL DEFAULT 'transitive';
+`,
+	10: `
+ALTER TABLE copies ADD COLUMN summaryTemplate TEXT NOT NULL DEFAULT '';
+ALTER TABLE copies ADD COLUMN descriptionTemplate TEXT NOT NULL DEFAULT '';
+`,
+	11: `
+ALTER TABLE copies ADD COLUMN fields TEXT NOT NULL DEFAULT '';
+ALTER TABLE copies ADD COLUMN colorID TEXT NOT NULL DEFAULT '';
+ALTER TABLE copies ADD COLUMN noReminders BOOLEAN NOT NULL DEFAULT FALSE;
+`,
+	12: `
+ALTER TABLE copies ADD COLUMN recurrence TEXT NOT NULL DEFAULT 'series';
+`,
+	13: `
+ALTER TABLE copies ADD COLUMN allDay TEXT NOT NULL DEFAULT 'keep';
+ALTER TABLE copies ADD COLUMN timeZone TEXT NOT NULL DEFAULT '';
+`,
+	14: `
+ALTER TABLE copies ADD COLUMN padBeforeSeconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE copies ADD COLUMN padAfterSeconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE copies ADD COLUMN padLocationOnly BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE copies ADD COLUMN padMode TEXT NOT NULL DEFAULT 'extend';
+`,
+	15: `
+ALTER TABLE copies ADD COLUMN mergeBusy BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE copies ADD COLUMN mergeGapSeconds INTEGER NOT NULL DEFAULT 0;
+`,
+	16: `
+ALTER TABLE copies ADD COLUMN label TEXT NOT NULL DEFAULT '';
+ALTER TABLE copies ADD COLUMN deduplicate BOOLEAN NOT NULL DEFAULT FALSE;
+`,
+	17: `
+ALTER TABLE invites ADD COLUMN summaryPattern TEXT NOT NULL DEFAULT '';
+ALTER TABLE invites ADD COLUMN onlyWithAttendees BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE invites ADD COLUMN onlyOwnEvents BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE invites ADD COLUMN weekdaysOnly BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE invites ADD COLUMN excludeAllDay BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE invites ADD COLUMN optional BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE invites ADD COLUMN sendUpdates TEXT NOT NULL DEFAULT 'none';
+`,
+	18: `
+CREATE TABLE IF NOT EXISTS invitedGuests (
+    inviteID 		INTEGER NOT NULL,
+    calendarID 		TEXT 	NOT NULL,
+    eventID 		TEXT 	NOT NULL,
+    emailAddress 	TEXT 	NOT NULL,
+    PRIMARY KEY (inviteID, eventID)
+);
+`,
+	19: `
+ALTER TABLE invites ADD COLUMN groupID INTEGER NOT NULL DEFAULT 0;
+
+DROP INDEX IF EXISTS invites_calendarID_emailAddress;
+CREATE UNIQUE INDEX IF NOT EXISTS invites_calendarID_emailAddress_groupID ON invites (calendarID, emailAddress, groupID);
+
+CREATE TABLE IF NOT EXISTS inviteGroups (
+    id 		INTEGER PRIMARY KEY AUTOINCREMENT,
+    name 	TEXT 	NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS inviteGroupMembers (
+    groupID 		INTEGER NOT NULL,
+    emailAddress 	TEXT 	NOT NULL,
+    PRIMARY KEY (groupID, emailAddress)
+);
+
+CREATE TABLE invitedGuestsByEmail (
+    inviteID 		INTEGER NOT NULL,
+    calendarID 		TEXT 	NOT NULL,
+    eventID 		TEXT 	NOT NULL,
+    emailAddress 	TEXT 	NOT NULL,
+    PRIMARY KEY (inviteID, eventID, emailAddress)
+);
+INSERT INTO invitedGuestsByEmail SELECT inviteID, calendarID, eventID, emailAddress FROM invitedGuests;
+DROP TABLE invitedGuests;
+ALTER TABLE invitedGuestsByEmail RENAME TO invitedGuests;
+`,
+	20: `
+CREATE TABLE IF NOT EXISTS jobs (
+    id 				INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind 			TEXT 	NOT NULL,
+    args 			TEXT 	NOT NULL,
+    idempotencyKey 	TEXT,
+    state 			TEXT 	NOT NULL,
+    attempts 		INTEGER NOT NULL DEFAULT 0,
+    runAt 			DATE 	NOT NULL,
+    lastError 		TEXT 	NOT NULL DEFAULT '',
+    createdAt 		DATE 	NOT NULL,
+    updatedAt 		DATE 	NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS jobsByIdempotencyKey ON jobs (idempotencyKey) WHERE state = 'pending';
+CREATE INDEX IF NOT EXISTS jobsByRunAt ON jobs (state, runAt);
+`,
+	21: `
+CREATE TABLE IF NOT EXISTS scheduledJobs (
+    name 			TEXT 	PRIMARY KEY,
+    spec 			TEXT 	NOT NULL,
+    running 		INTEGER NOT NULL DEFAULT 0,
+    lastRunAt 		DATE,
+    lastDuration 	INTEGER NOT NULL DEFAULT 0,
+    lastError 		TEXT 	NOT NULL DEFAULT '',
+    nextRunAt 		DATE 	NOT NULL
+);
+`,
+	22: `
+DROP TABLE IF EXISTS syncTokens;
+
+CREATE TABLE IF NOT EXISTS syncTokens (
+    calendarID 		TEXT 	NOT NULL,
+    singleEvents 	BOOLEAN NOT NULL,
+    token 			TEXT 	NOT NULL,
+    PRIMARY KEY (calendarID, singleEvents)
+);
+`,
 }
 
 func migrate(ctx context.Context, db *Database, conn *sql.DB) error {
@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+)
+
+// SaveScheduledJob stores the state of a scheduled job. Durations are stored in milliseconds, and times in UTC like
+// those of jobs.
+func (d *Database) SaveScheduledJob(ctx context.Context, job persistence.ScheduledJob) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+INSERT INTO scheduledJobs (name, spec, running, lastRunAt, lastDuration, lastError, nextRunAt)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (name) DO UPDATE SET
+    spec = excluded.spec,
+    running = excluded.running,
+    lastRunAt = excluded.lastRunAt,
+    lastDuration = excluded.lastDuration,
+    lastError = excluded.lastError,
+    nextRunAt = excluded.nextRunAt
+`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	var lastRunAt sql.NullTime
+	if !job.LastRunAt.IsZero() {
+		lastRunAt = sql.NullTime{Time: job.LastRunAt.UTC(), Valid: true}
+	}
+
+	if _, err := stmt.ExecContext(ctx,
+		job.Name, job.Spec, job.Running, lastRunAt, job.LastDuration.Milliseconds(), job.LastError,
+		job.NextRunAt.UTC(),
+	); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Debug().
+		Str("name", job.Name).
+		Bool("running", job.Running).
+		Time("next-run-at", job.NextRunAt).
+		Msg("saved scheduled job")
+
+	return nil
+}
+
+// FinishScheduledJob stores the outcome of a run. The next run is left alone, it may have been triggered since the
+// run started.
+func (d *Database) FinishScheduledJob(ctx context.Context, job persistence.ScheduledJob) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+UPDATE scheduledJobs
+SET running = FALSE, lastRunAt = ?, lastDuration = ?, lastError = ?
+WHERE name = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, job.LastRunAt.UTC(), job.LastDuration.Milliseconds(), job.LastError, job.Name); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	logs.GetLogger(ctx).Debug().
+		Str("name", job.Name).
+		Msg("finished scheduled job")
+
+	return nil
+}
+
+// TriggerScheduledJob makes a scheduled job due now.
+func (d *Database) TriggerScheduledJob(ctx context.Context, name string) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+UPDATE scheduledJobs
+SET nextRunAt = ?
+WHERE name = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, time.Now().UTC(), name)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+	if triggered, err := result.RowsAffected(); err == nil && triggered == 0 {
+		return errors.Wrapf(sql.ErrNoRows, "unknown scheduled job %q", name)
+	}
+
+	logs.GetLogger(ctx).Info().
+		Str("name", name).
+		Msg("triggered scheduled job")
+
+	return nil
+}
+
+func (d *Database) DeleteScheduledJob(ctx context.Context, name string) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+DELETE FROM scheduledJobs
+WHERE name = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, name); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	return nil
+}
+
+func (d *Database) GetScheduledJobs(ctx context.Context) ([]persistence.ScheduledJob, error) {
+	stmt, err := d.db.PrepareContext(ctx, `
+SELECT name, spec, running, lastRunAt, lastDuration, lastError, nextRunAt
+FROM scheduledJobs
+ORDER BY name`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute statement")
+	}
+	defer rows.Close()
+
+	var jobs []persistence.ScheduledJob
+	for rows.Next() {
+		var (
+			job          persistence.ScheduledJob
+			lastRunAt    sql.NullTime
+			lastDuration int64
+		)
+		if err := rows.Scan(
+			&job.Name, &job.Spec, &job.Running, &lastRunAt, &lastDuration, &job.LastError, &job.NextRunAt,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		job.LastRunAt = lastRunAt.Time
+		job.LastDuration = time.Duration(lastDuration) * time.Millisecond
+		jobs = append(jobs, job)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "error getting rows")
+	}
+
+	return jobs, nil
+}
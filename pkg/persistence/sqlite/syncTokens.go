@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// GetSyncToken returns the token of a calendar's listings with or without singleEvents, tokens can only be used with
+// the setting of the listing which returned them.
+func (d *Database) GetSyncToken(ctx context.Context, calendarID string, singleEvents bool) (string, error) {
+	stmt, err := d.db.PrepareContext(ctx, `SELECT token FROM syncTokens WHERE calendarID = ? AND singleEvents = ?`)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	var token string
+	if err := stmt.QueryRowContext(ctx, calendarID, singleEvents).Scan(&token); err != nil {
+		return "", errors.Wrap(err, "failed to execute statement")
+	}
+
+	return token, nil
+}
+
+func (d *Database) SetSyncToken(ctx context.Context, calendarID string, singleEvents bool, token string) error {
+	stmt, err := d.db.PrepareContext(ctx, `
+INSERT INTO syncTokens(calendarID, singleEvents, token) VALUES(?, ?, ?)
+ON CONFLICT(calendarID, singleEvents) DO
+UPDATE SET token=excluded.token
+`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, calendarID, singleEvents, token); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	return nil
+}
+
+func (d *Database) DeleteSyncToken(ctx context.Context, calendarID string, singleEvents bool) error {
+	stmt, err := d.db.PrepareContext(ctx, `DELETE FROM syncTokens WHERE calendarID = ? AND singleEvents = ?`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, calendarID, singleEvents); err != nil {
+		return errors.Wrap(err, "failed to execute statement")
+	}
+
+	return nil
+}
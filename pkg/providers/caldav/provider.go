@@ -0,0 +1,437 @@
+package caldav
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav"
+	gocaldav "github.com/emersion/go-webdav/caldav"
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/icalendar"
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/providers"
+)
+
+// CalendarIDPrefix marks a calendar ID as a CalDAV collection path, e.g. "caldav:/calendars/user/work/".
+const CalendarIDPrefix = "caldav:"
+
+// managedProps are replaced when an event is patched, everything else on the server's copy is left untouched.
+var managedProps = []string{
+	ical.PropSummary,
+	ical.PropDescription,
+	ical.PropLocation,
+	ical.PropStatus,
+	ical.PropTransparency,
+	ical.PropDateTimeStart,
+	ical.PropDateTimeEnd,
+	ical.PropDuration,
+	ical.PropRecurrenceRule,
+	ical.PropRecurrenceDates,
+	ical.PropExceptionDates,
+}
+
+type Provider struct {
+	client *gocaldav.Client
+	// httpClient and endpoint send the conditional writes go-webdav's client can't send.
+	httpClient webdav.HTTPClient
+	endpoint   *url.URL
+}
+
+var _ providers.Provider = new(Provider)
+
+func New(endpoint, username, password string) (*Provider, error) {
+	var httpClient webdav.HTTPClient = http.DefaultClient
+	if username != "" {
+		httpClient = webdav.HTTPClientWithBasicAuth(httpClient, username, password)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse endpoint")
+	}
+
+	httpClient = statusClient{client: httpClient}
+	client, err := gocaldav.NewClient(httpClient, endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create caldav client")
+	}
+
+	return &Provider{client: client, httpClient: httpClient, endpoint: u}, nil
+}
+
+func toCalendarID(path string) string {
+	return CalendarIDPrefix + path
+}
+
+func toPath(calendarID string) string {
+	return strings.TrimPrefix(calendarID, CalendarIDPrefix)
+}
+
+func (p *Provider) ListCalendars(ctx context.Context) ([]providers.CalendarInfo, error) {
+	principal, err := p.client.FindCurrentUserPrincipal(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find user principal")
+	}
+
+	homeSet, err := p.client.FindCalendarHomeSet(ctx, principal)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find calendar home set")
+	}
+
+	found, err := p.client.FindCalendars(ctx, homeSet)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find calendars")
+	}
+
+	var calendars []providers.CalendarInfo
+	for _, c := range found {
+		summary := c.Name
+		if summary == "" {
+			summary = c.Path
+		}
+
+		calendars = append(calendars, providers.CalendarInfo{
+			ID:         toCalendarID(c.Path),
+			Summary:    summary,
+			AccessRole: "writer",
+		})
+	}
+
+	return calendars, nil
+}
+
+func (p *Provider) GetCalendar(ctx context.Context, calendarID string) (providers.CalendarInfo, error) {
+	calendars, err := p.ListCalendars(ctx)
+	if err != nil {
+		return providers.CalendarInfo{}, err
+	}
+
+	for _, c := range calendars {
+		if c.ID == calendarID {
+			return c, nil
+		}
+	}
+
+	return providers.CalendarInfo{}, errors.Errorf("calendar %q not found", calendarID)
+}
+
+func (p *Provider) ListEvents(ctx context.Context, calendarID string, opts providers.ListEventsOptions) (providers.EventList, error) {
+	var result providers.EventList
+
+	if opts.SyncToken != "" {
+		return result, providers.ErrNotSupported
+	}
+
+	query := &gocaldav.CalendarQuery{
+		CompRequest: gocaldav.CalendarCompRequest{
+			Name:     ical.CompCalendar,
+			AllProps: true,
+			AllComps: true,
+		},
+		CompFilter: gocaldav.CompFilter{
+			Name: ical.CompCalendar,
+			Comps: []gocaldav.CompFilter{{
+				Name:  ical.CompEvent,
+				Start: opts.TimeMin,
+				End:   opts.TimeMax,
+			}},
+		},
+	}
+
+	objects, err := p.client.QueryCalendar(ctx, toPath(calendarID), query)
+	if err != nil {
+		return result, errors.Wrap(err, "failed to query calendar")
+	}
+
+	for _, object := range objects {
+		var events []*calendar.Event
+		if opts.SingleEvents {
+			events = toInstances(ctx, object, opts.TimeMin, opts.TimeMax)
+		} else {
+			event, err := toEvent(object)
+			if err != nil {
+				logs.GetLogger(ctx).Warn().Err(err).Msg("skipping calendar object which can't be converted")
+				continue
+			}
+			events = []*calendar.Event{event}
+		}
+
+		for _, event := range events {
+			if !providers.HasPrivateProperties(event, opts.PrivateExtendedProperties) {
+				continue
+			}
+
+			result.Items = append(result.Items, event)
+		}
+	}
+
+	return result, nil
+}
+
+func (p *Provider) GetEvent(ctx context.Context, _, eventID string) (*calendar.Event, error) {
+	object, err := p.client.GetCalendarObject(ctx, eventID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, errors.Wrap(providers.ErrNotFound, eventID)
+		}
+		return nil, errors.Wrap(err, "failed to get calendar object")
+	}
+
+	return toEvent(*object)
+}
+
+// InsertEvent doesn't support replacing instances of recurring events, which are stored in the series' object.
+func (p *Provider) InsertEvent(ctx context.Context, calendarID string, event *calendar.Event) (*calendar.Event, error) {
+	if event.RecurringEventId != "" {
+		return nil, errors.Wrap(providers.ErrNotSupported, "modifying instances of recurring events")
+	}
+
+	e, err := icalendar.FromEvent(event)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert event")
+	}
+
+	uid, err := e.Props.Text(ical.PropUID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get uid")
+	}
+
+	cal := icalendar.NewCalendar()
+	cal.Children = append(cal.Children, e.Component)
+
+	path := strings.TrimSuffix(toPath(calendarID), "/") + "/" + uid + ".ics"
+	object, err := p.client.PutCalendarObject(ctx, path, cal)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to put calendar object")
+	}
+
+	object.Data = cal
+
+	return toEvent(*object)
+}
+
+// PatchAttendees leaves notifying the guests to the server's scheduling. Guests are stored as ATTENDEE properties.
+func (p *Provider) PatchAttendees(ctx context.Context, calendarID, eventID, etag string, attendees []*calendar.EventAttendee, _ string) (*calendar.Event, error) {
+	return p.PatchEvent(ctx, calendarID, eventID, &calendar.Event{Etag: etag, Attendees: attendees, ForceSendFields: []string{"Attendees"}})
+}
+
+// PatchEvent writes the patched object if it's still the version with the patch's etag, or the version it read when
+// the patch has none.
+func (p *Provider) PatchEvent(ctx context.Context, _, eventID string, patch *calendar.Event) (*calendar.Event, error) {
+	object, err := p.client.GetCalendarObject(ctx, eventID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, errors.Wrap(providers.ErrNotFound, eventID)
+		}
+		return nil, errors.Wrap(err, "failed to get calendar object")
+	}
+
+	e, err := mainEvent(object.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := icalendar.ToEvent(*e)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert event")
+	}
+
+	if err = providers.ApplyPatch(event, patch); err != nil {
+		return nil, err
+	}
+
+	patched, err := icalendar.FromEvent(event)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert patched event")
+	}
+
+	for name := range e.Props {
+		if strings.HasPrefix(name, icalendar.ExtendedPropertyPrefix) {
+			e.Props.Del(name)
+		}
+	}
+	for _, name := range managedProps {
+		e.Props.Del(name)
+	}
+	// guests are only replaced when they're patched, so parameters which aren't converted are kept otherwise
+	if patch.Attendees != nil || slices.Contains(patch.ForceSendFields, "Attendees") {
+		e.Props.Del(ical.PropAttendee)
+	}
+	for name, props := range patched.Props {
+		if _, ok := e.Props[name]; ok {
+			continue
+		}
+		e.Props[name] = props
+	}
+
+	var buf bytes.Buffer
+	if err := ical.NewEncoder(&buf).Encode(object.Data); err != nil {
+		return nil, errors.Wrap(err, "failed to encode calendar object")
+	}
+
+	resp, err := p.do(ctx, http.MethodPut, eventID, cmp.Or(patch.Etag, object.ETag), &buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to put calendar object")
+	}
+	resp.Body.Close()
+
+	object.ETag = resp.Header.Get("ETag")
+	if etag, err := strconv.Unquote(object.ETag); err == nil {
+		object.ETag = etag
+	}
+
+	return toEvent(*object)
+}
+
+func (p *Provider) DeleteEvent(ctx context.Context, _, eventID, etag string) error {
+	resp, err := p.do(ctx, http.MethodDelete, eventID, etag, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete calendar object")
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// do sends a request for an object which only succeeds if the object's etag still matches, go-webdav's client can't
+// send If-Match yet.
+func (p *Provider) do(ctx context.Context, method, objectPath, etag string, body io.Reader) (*http.Response, error) {
+	u := p.endpoint.ResolveReference(&url.URL{Path: objectPath})
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", ical.MIMEType)
+	}
+	if etag != "" {
+		req.Header.Set("If-Match", strconv.Quote(etag))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		if hasStatus(err, http.StatusPreconditionFailed) {
+			return nil, errors.Wrap(providers.ErrPreconditionFailed, objectPath)
+		}
+		if isNotFound(err) {
+			return nil, errors.Wrap(providers.ErrNotFound, objectPath)
+		}
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (p *Provider) Watch(context.Context, string, providers.Channel) (providers.Channel, error) {
+	return providers.Channel{}, providers.ErrNotSupported
+}
+
+func (p *Provider) Unwatch(context.Context, providers.Channel) error {
+	return providers.ErrNotSupported
+}
+
+// SupportsRecurrence is false, since instances can't be modified or cancelled on their own, see InsertEvent.
+func (p *Provider) SupportsRecurrence(string) bool {
+	return false
+}
+
+// statusError is a response which wasn't successful. go-webdav doesn't export its own error type, so its client is
+// given one which fails with this error before go-webdav sees the response.
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return e.status
+}
+
+type statusClient struct {
+	client webdav.HTTPClient
+}
+
+func (c statusClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		return nil, &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+
+	return resp, nil
+}
+
+func hasStatus(err error, code int) bool {
+	var statusErr *statusError
+	return errors.As(err, &statusErr) && statusErr.code == code
+}
+
+func isNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound) || hasStatus(err, http.StatusGone)
+}
+
+// mainEvent returns the VEVENT which isn't an override of a recurring event.
+func mainEvent(cal *ical.Calendar) (*ical.Event, error) {
+	events := cal.Events()
+	for _, e := range events {
+		if e.Props.Get(ical.PropRecurrenceID) == nil {
+			return &e, nil
+		}
+	}
+
+	if len(events) != 0 {
+		return &events[0], nil
+	}
+
+	return nil, errors.New("calendar object has no events")
+}
+
+func toEvent(object gocaldav.CalendarObject) (*calendar.Event, error) {
+	e, err := mainEvent(object.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", object.Path)
+	}
+
+	event, err := icalendar.ToEvent(*e)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert %s", object.Path)
+	}
+
+	event.Id = object.Path
+	event.Etag = object.ETag
+
+	return event, nil
+}
+
+// toInstances expands a calendar object, the IDs of instances are based on the object's path so they can be told
+// apart from other objects with the same UID.
+func toInstances(ctx context.Context, object gocaldav.CalendarObject, timeMin, timeMax time.Time) []*calendar.Event {
+	events := icalendar.Expand(ctx, object.Data, timeMin, timeMax)
+
+	for _, event := range events {
+		if event.RecurringEventId == "" {
+			event.Id = object.Path
+			event.Etag = object.ETag
+			continue
+		}
+
+		event.Id = object.Path + strings.TrimPrefix(event.Id, event.ICalUID)
+		event.RecurringEventId = object.Path
+	}
+
+	return events
+}
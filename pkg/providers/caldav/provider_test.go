@@ -0,0 +1,354 @@
+package caldav_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav"
+	gocaldav "github.com/emersion/go-webdav/caldav"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/providers"
+	"calendar-sync/pkg/providers/caldav"
+)
+
+// testBackend is a minimal in-memory caldav server.
+type testBackend struct {
+	mu       sync.Mutex
+	version  int
+	calendar gocaldav.Calendar
+	objects  map[string]gocaldav.CalendarObject
+}
+
+func (b *testBackend) CurrentUserPrincipal(context.Context) (string, error) {
+	return "/user/", nil
+}
+
+func (b *testBackend) CalendarHomeSetPath(context.Context) (string, error) {
+	return "/user/calendars/", nil
+}
+
+func (b *testBackend) CreateCalendar(context.Context, *gocaldav.Calendar) error {
+	return webdav.NewHTTPError(405, nil)
+}
+
+func (b *testBackend) ListCalendars(context.Context) ([]gocaldav.Calendar, error) {
+	return []gocaldav.Calendar{b.calendar}, nil
+}
+
+func (b *testBackend) GetCalendar(_ context.Context, p string) (*gocaldav.Calendar, error) {
+	if strings.TrimSuffix(p, "/") != strings.TrimSuffix(b.calendar.Path, "/") {
+		return nil, webdav.NewHTTPError(404, nil)
+	}
+
+	return &b.calendar, nil
+}
+
+func (b *testBackend) GetCalendarObject(_ context.Context, p string, _ *gocaldav.CalendarCompRequest) (*gocaldav.CalendarObject, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	object, ok := b.objects[p]
+	if !ok {
+		return nil, webdav.NewHTTPError(404, nil)
+	}
+
+	return &object, nil
+}
+
+func (b *testBackend) ListCalendarObjects(context.Context, string, *gocaldav.CalendarCompRequest) ([]gocaldav.CalendarObject, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var objects []gocaldav.CalendarObject
+	for _, object := range b.objects {
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+func (b *testBackend) QueryCalendarObjects(ctx context.Context, p string, query *gocaldav.CalendarQuery) ([]gocaldav.CalendarObject, error) {
+	objects, err := b.ListCalendarObjects(ctx, p, &query.CompRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return gocaldav.Filter(query, objects)
+}
+
+func (b *testBackend) PutCalendarObject(_ context.Context, p string, cal *ical.Calendar, opts *gocaldav.PutCalendarObjectOptions) (*gocaldav.CalendarObject, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if opts.IfMatch.IsSet() {
+		etag, err := opts.IfMatch.ETag()
+		if err != nil {
+			return nil, webdav.NewHTTPError(400, err)
+		}
+		if !b.matches(p, etag) {
+			return nil, webdav.NewHTTPError(412, nil)
+		}
+	}
+
+	b.version++
+	object := gocaldav.CalendarObject{
+		Path:    p,
+		ModTime: time.Now(),
+		ETag:    strconv.Itoa(b.version),
+		Data:    cal,
+	}
+	b.objects[p] = object
+
+	return &object, nil
+}
+
+func (b *testBackend) DeleteCalendarObject(_ context.Context, p string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.objects[p]; !ok {
+		return webdav.NewHTTPError(404, nil)
+	}
+
+	delete(b.objects, p)
+
+	return nil
+}
+
+func (b *testBackend) matches(p, etag string) bool {
+	object, ok := b.objects[p]
+	return ok && object.ETag == etag
+}
+
+// ServeHTTP checks If-Match of deletes, which go-webdav's handler doesn't pass on to the backend.
+func (b *testBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ifMatch := webdav.ConditionalMatch(r.Header.Get("If-Match")); r.Method == http.MethodDelete && ifMatch.IsSet() {
+		etag, err := ifMatch.ETag()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		b.mu.Lock()
+		matches := b.matches(r.URL.Path, etag)
+		b.mu.Unlock()
+
+		if !matches {
+			http.Error(w, "etag doesn't match", http.StatusPreconditionFailed)
+			return
+		}
+	}
+
+	(&gocaldav.Handler{Backend: b}).ServeHTTP(w, r)
+}
+
+func newTestServer(t *testing.T) *caldav.Provider {
+	t.Helper()
+
+	backend := &testBackend{
+		calendar: gocaldav.Calendar{Path: "/user/calendars/work/", Name: "Work"},
+		objects:  make(map[string]gocaldav.CalendarObject),
+	}
+
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+
+	provider, err := caldav.New(server.URL, "", "")
+	require.NoError(t, err)
+
+	return provider
+}
+
+func TestProvider(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	provider := newTestServer(t)
+
+	calendars, err := provider.ListCalendars(ctx)
+	require.NoError(t, err)
+	require.Len(t, calendars, 1)
+	assert.Equal(t, "caldav:/user/calendars/work/", calendars[0].ID)
+	assert.Equal(t, "Work", calendars[0].Summary)
+
+	calendarID := calendars[0].ID
+	start := time.Date(2030, time.January, 2, 10, 0, 0, 0, time.UTC)
+
+	created, err := provider.InsertEvent(ctx, calendarID, &calendar.Event{
+		Summary: "standup",
+		Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:     &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{
+				pkg.SourceCalendarIDKey:     "source",
+				pkg.SourceCalendarItemIDKey: "source-item",
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/user/calendars/work/", path.Dir(created.Id)+"/")
+
+	_, err = provider.InsertEvent(ctx, calendarID, &calendar.Event{
+		Summary: "vacation",
+		Start:   &calendar.EventDateTime{Date: "2030-01-03"},
+		End:     &calendar.EventDateTime{Date: "2030-01-04"},
+	})
+	require.NoError(t, err)
+
+	// list
+	events, err := provider.ListEvents(ctx, calendarID, providers.ListEventsOptions{
+		TimeMin: start.Add(-time.Hour),
+		TimeMax: start.Add(72 * time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Len(t, events.Items, 2)
+
+	// list by extended properties
+	events, err = provider.ListEvents(ctx, calendarID, providers.ListEventsOptions{
+		PrivateExtendedProperties: map[string]string{pkg.SourceCalendarItemIDKey: "source-item"},
+	})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	event := events.Items[0]
+	assert.Equal(t, created.Id, event.Id)
+	assert.Equal(t, "standup", event.Summary)
+	assert.Equal(t, start.Format(time.RFC3339), event.Start.DateTime)
+	assert.Equal(t, "source", event.ExtendedProperties.Private[pkg.SourceCalendarIDKey])
+
+	// patch
+	patched, err := provider.PatchEvent(ctx, calendarID, created.Id, &calendar.Event{
+		Summary: "retro",
+		End:     &calendar.EventDateTime{DateTime: start.Add(2 * time.Hour).Format(time.RFC3339)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "retro", patched.Summary)
+
+	event, err = provider.GetEvent(ctx, calendarID, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "retro", event.Summary)
+	assert.Equal(t, start.Format(time.RFC3339), event.Start.DateTime)
+	assert.Equal(t, start.Add(2*time.Hour).Format(time.RFC3339), event.End.DateTime)
+	assert.Equal(t, "source-item", event.ExtendedProperties.Private[pkg.SourceCalendarItemIDKey])
+
+	// guests are stored as attendees, and kept by other patches
+	patched, err = provider.PatchAttendees(ctx, calendarID, created.Id, "", []*calendar.EventAttendee{
+		{Email: "alice@example.com"},
+		{Email: "bob@example.com", Optional: true},
+	}, "none")
+	require.NoError(t, err)
+	assert.Len(t, patched.Attendees, 2)
+
+	event, err = provider.PatchEvent(ctx, calendarID, created.Id, &calendar.Event{Location: "office"})
+	require.NoError(t, err)
+	require.Len(t, event.Attendees, 2)
+	assert.Equal(t, "alice@example.com", event.Attendees[0].Email)
+	assert.True(t, event.Attendees[1].Optional)
+
+	event, err = provider.PatchAttendees(ctx, calendarID, created.Id, "", nil, "none")
+	require.NoError(t, err)
+	assert.Empty(t, event.Attendees)
+
+	// writes based on an older version fail
+	require.NotEmpty(t, event.Etag)
+	assert.NotEqual(t, created.Etag, event.Etag)
+	_, err = provider.PatchEvent(ctx, calendarID, created.Id, &calendar.Event{Etag: created.Etag, Summary: "stale"})
+	require.ErrorIs(t, err, providers.ErrPreconditionFailed)
+	require.ErrorIs(t, provider.DeleteEvent(ctx, calendarID, created.Id, created.Etag), providers.ErrPreconditionFailed)
+
+	// delete
+	require.NoError(t, provider.DeleteEvent(ctx, calendarID, created.Id, event.Etag))
+	_, err = provider.GetEvent(ctx, calendarID, created.Id)
+	require.ErrorIs(t, err, providers.ErrNotFound)
+
+	// events which are gone already
+	require.ErrorIs(t, provider.DeleteEvent(ctx, calendarID, created.Id, ""), providers.ErrNotFound)
+	_, err = provider.PatchEvent(ctx, calendarID, created.Id, &calendar.Event{Summary: "gone"})
+	require.ErrorIs(t, err, providers.ErrNotFound)
+
+	events, err = provider.ListEvents(ctx, calendarID, providers.ListEventsOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	assert.Equal(t, "vacation", events.Items[0].Summary)
+	assert.Equal(t, "2030-01-03", events.Items[0].Start.Date)
+
+	// switching between all day and timed clears the other field
+	holiday, err := provider.InsertEvent(ctx, calendarID, &calendar.Event{
+		Summary: "holiday",
+		Start:   &calendar.EventDateTime{Date: "2030-01-05"},
+		End:     &calendar.EventDateTime{Date: "2030-01-06"},
+	})
+	require.NoError(t, err)
+
+	_, err = provider.PatchEvent(ctx, calendarID, holiday.Id, &calendar.Event{
+		Start: &calendar.EventDateTime{DateTime: "2030-01-05T09:00:00Z", NullFields: []string{"Date"}},
+		End:   &calendar.EventDateTime{DateTime: "2030-01-05T17:00:00Z", NullFields: []string{"Date"}},
+	})
+	require.NoError(t, err)
+	event, err = provider.GetEvent(ctx, calendarID, holiday.Id)
+	require.NoError(t, err)
+	assert.Equal(t, &calendar.EventDateTime{DateTime: "2030-01-05T09:00:00Z"}, event.Start)
+	assert.Equal(t, &calendar.EventDateTime{DateTime: "2030-01-05T17:00:00Z"}, event.End)
+
+	_, err = provider.PatchEvent(ctx, calendarID, holiday.Id, &calendar.Event{
+		Start: &calendar.EventDateTime{Date: "2030-01-05", NullFields: []string{"DateTime"}},
+		End:   &calendar.EventDateTime{Date: "2030-01-06", NullFields: []string{"DateTime"}},
+	})
+	require.NoError(t, err)
+	event, err = provider.GetEvent(ctx, calendarID, holiday.Id)
+	require.NoError(t, err)
+	assert.Equal(t, &calendar.EventDateTime{Date: "2030-01-05"}, event.Start)
+	assert.Equal(t, &calendar.EventDateTime{Date: "2030-01-06"}, event.End)
+	require.NoError(t, provider.DeleteEvent(ctx, calendarID, holiday.Id, ""))
+
+	// watching is not supported
+	_, err = provider.Watch(ctx, calendarID, providers.Channel{})
+	require.ErrorIs(t, err, providers.ErrNotSupported)
+
+	// recurring events
+	series, err := provider.InsertEvent(ctx, calendarID, &calendar.Event{
+		Summary:    "daily",
+		Start:      &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:        &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+		Recurrence: []string{"RRULE:FREQ=DAILY;COUNT=3"},
+	})
+	require.NoError(t, err)
+
+	events, err = provider.ListEvents(ctx, calendarID, providers.ListEventsOptions{
+		TimeMin:      start.Add(-time.Hour),
+		TimeMax:      start.Add(72 * time.Hour),
+		SingleEvents: true,
+	})
+	require.NoError(t, err)
+
+	var instances []string
+	for _, e := range events.Items {
+		if e.RecurringEventId == series.Id {
+			instances = append(instances, e.Id)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		series.Id + "_20300102T100000Z",
+		series.Id + "_20300103T100000Z",
+		series.Id + "_20300104T100000Z",
+	}, instances)
+
+	// instances live in the series' object, which isn't supported
+	_, err = provider.InsertEvent(ctx, calendarID, &calendar.Event{
+		RecurringEventId:  series.Id,
+		OriginalStartTime: &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		Status:            "cancelled",
+	})
+	require.ErrorIs(t, err, providers.ErrNotSupported)
+}
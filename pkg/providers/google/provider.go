@@ -0,0 +1,323 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+
+	"calendar-sync/pkg/icalendar"
+	"calendar-sync/pkg/providers"
+)
+
+type ClientFactory func(ctx context.Context) (*calendar.Service, error)
+
+type Provider struct {
+	getClient ClientFactory
+}
+
+var _ providers.Provider = new(Provider)
+
+func New(getClient ClientFactory) *Provider {
+	return &Provider{getClient: getClient}
+}
+
+func (p *Provider) client(ctx context.Context) (*calendar.Service, error) {
+	client, err := p.getClient(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create client")
+	}
+
+	return client, nil
+}
+
+func (p *Provider) ListCalendars(ctx context.Context) ([]providers.CalendarInfo, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var calendars []providers.CalendarInfo
+	if err = client.CalendarList.List().Pages(ctx, func(list *calendar.CalendarList) error {
+		for _, c := range list.Items {
+			calendars = append(calendars, providers.CalendarInfo{
+				ID:         c.Id,
+				Summary:    c.Summary,
+				AccessRole: c.AccessRole,
+			})
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to list calendars")
+	}
+
+	return calendars, nil
+}
+
+func (p *Provider) GetCalendar(ctx context.Context, calendarID string) (providers.CalendarInfo, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return providers.CalendarInfo{}, err
+	}
+
+	c, err := client.Calendars.Get(calendarID).Context(ctx).Do()
+	if err != nil {
+		return providers.CalendarInfo{}, errors.Wrap(err, "failed to retrieve calendar")
+	}
+
+	return providers.CalendarInfo{ID: c.Id, Summary: c.Summary}, nil
+}
+
+func rfc3339(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+func (p *Provider) ListEvents(ctx context.Context, calendarID string, opts providers.ListEventsOptions) (providers.EventList, error) {
+	var result providers.EventList
+
+	client, err := p.client(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	listCall := client.Events.List(calendarID).MaxResults(100)
+	if opts.SyncToken != "" {
+		listCall = listCall.SyncToken(opts.SyncToken)
+	}
+	if opts.SingleEvents {
+		listCall = listCall.SingleEvents(true)
+	}
+	if !opts.TimeMin.IsZero() {
+		listCall = listCall.TimeMin(rfc3339(opts.TimeMin))
+	}
+	if !opts.TimeMax.IsZero() {
+		listCall = listCall.TimeMax(rfc3339(opts.TimeMax))
+	}
+	for key, value := range opts.PrivateExtendedProperties {
+		listCall = listCall.PrivateExtendedProperty(fmt.Sprintf("%s=%s", key, value))
+	}
+
+	if err = listCall.Pages(ctx, func(events *calendar.Events) error {
+		result.Items = append(result.Items, events.Items...)
+		result.NextSyncToken = events.NextSyncToken // only present on the last page
+		return nil
+	}); err != nil {
+		var apiErr *googleapi.Error
+		// tokens which expired are gone, and tokens used with other options than their listing's are bad requests
+		if opts.SyncToken != "" && errors.As(err, &apiErr) && (apiErr.Code == http.StatusGone || apiErr.Code == http.StatusBadRequest) {
+			return result, errors.Wrap(providers.ErrSyncTokenExpired, apiErr.Message)
+		}
+		return result, errors.Wrap(err, "failed to list events")
+	}
+
+	return result, nil
+}
+
+func (p *Provider) GetEvent(ctx context.Context, calendarID, eventID string) (*calendar.Event, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := client.Events.Get(calendarID, eventID).Context(ctx).Do()
+	if err != nil {
+		if isNotFound(err) {
+			return nil, errors.Wrap(providers.ErrNotFound, eventID)
+		}
+		return nil, errors.Wrap(err, "failed to get event")
+	}
+
+	return event, nil
+}
+
+func (p *Provider) InsertEvent(ctx context.Context, calendarID string, event *calendar.Event) (*calendar.Event, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.RecurringEventId != "" {
+		return p.patchInstance(ctx, calendarID, event)
+	}
+
+	// conference data and attachments are only written when asked for
+	created, err := client.Events.Insert(calendarID, event).
+		ConferenceDataVersion(1).
+		SupportsAttachments(true).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create event")
+	}
+
+	return created, nil
+}
+
+func (p *Provider) PatchEvent(ctx context.Context, calendarID, eventID string, patch *calendar.Event) (*calendar.Event, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the etag is sent as a precondition rather than as a field
+	etag := patch.Etag
+	if etag != "" {
+		body := *patch
+		body.Etag = ""
+		patch = &body
+	}
+
+	call := client.Events.Patch(calendarID, eventID, patch).
+		ConferenceDataVersion(1).
+		SupportsAttachments(true)
+	ifMatch(call.Header(), etag)
+
+	patched, err := call.Context(ctx).Do()
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return nil, errors.Wrap(providers.ErrPreconditionFailed, eventID)
+		}
+		if isNotFound(err) {
+			return nil, errors.Wrap(providers.ErrNotFound, eventID)
+		}
+		return nil, errors.Wrap(err, "failed to patch event")
+	}
+
+	return patched, nil
+}
+
+func (p *Provider) PatchAttendees(ctx context.Context, calendarID, eventID, etag string, attendees []*calendar.EventAttendee, sendUpdates string) (*calendar.Event, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := &calendar.Event{Attendees: attendees, ForceSendFields: []string{"Attendees"}}
+	call := client.Events.Patch(calendarID, eventID, patch)
+	if sendUpdates != "" {
+		call = call.SendUpdates(sendUpdates)
+	}
+	ifMatch(call.Header(), etag)
+
+	patched, err := call.Context(ctx).Do()
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return nil, errors.Wrap(providers.ErrPreconditionFailed, eventID)
+		}
+		if isNotFound(err) {
+			return nil, errors.Wrap(providers.ErrNotFound, eventID)
+		}
+		return nil, errors.Wrap(err, "failed to patch attendees")
+	}
+
+	return patched, nil
+}
+
+// patchInstance turns an instance of a recurring event into an exception, instances can't be inserted.
+func (p *Provider) patchInstance(ctx context.Context, calendarID string, event *calendar.Event) (*calendar.Event, error) {
+	originalStart, err := icalendar.ParseEventDateTime(event.OriginalStartTime)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse original start time")
+	}
+
+	instanceID := icalendar.InstanceID(event.RecurringEventId, originalStart, event.OriginalStartTime.DateTime == "")
+	return p.PatchEvent(ctx, calendarID, instanceID, event)
+}
+
+func (p *Provider) DeleteEvent(ctx context.Context, calendarID, eventID, etag string) error {
+	client, err := p.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	call := client.Events.Delete(calendarID, eventID)
+	ifMatch(call.Header(), etag)
+
+	if err = call.Context(ctx).Do(); err != nil {
+		if isPreconditionFailed(err) {
+			return errors.Wrap(providers.ErrPreconditionFailed, eventID)
+		}
+		if isNotFound(err) {
+			return errors.Wrap(providers.ErrNotFound, eventID)
+		}
+		return errors.Wrap(err, "failed to delete event")
+	}
+
+	return nil
+}
+
+func (p *Provider) Watch(ctx context.Context, calendarID string, channel providers.Channel) (providers.Channel, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return channel, err
+	}
+
+	request := &calendar.Channel{
+		Id:      channel.ID,
+		Type:    "web_hook",
+		Address: channel.Address,
+		Token:   channel.Token,
+	}
+
+	response, err := client.Events.Watch(calendarID, request).Context(ctx).Do()
+	if err != nil {
+		return channel, errors.Wrap(err, "failed to watch events")
+	}
+
+	return providers.Channel{
+		ID:         response.Id,
+		Token:      response.Token,
+		Address:    response.Address,
+		ResourceID: response.ResourceId,
+		Expiration: fromTimestamp(response.Expiration),
+	}, nil
+}
+
+func (p *Provider) Unwatch(ctx context.Context, channel providers.Channel) error {
+	client, err := p.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = client.Channels.Stop(&calendar.Channel{
+		Id:         channel.ID,
+		ResourceId: channel.ResourceID,
+	}).Context(ctx).Do(); err != nil {
+		return errors.Wrap(err, "failed to stop channel")
+	}
+
+	return nil
+}
+
+func (p *Provider) SupportsRecurrence(string) bool {
+	return true
+}
+
+func isNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusGone
+}
+
+func fromTimestamp(timestamp int64) time.Time {
+	return time.UnixMilli(timestamp)
+}
+
+func isPreconditionFailed(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed
+}
+
+func ifMatch(header http.Header, etag string) {
+	if etag != "" {
+		header.Set("If-Match", etag)
+	}
+}
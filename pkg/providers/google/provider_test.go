@@ -0,0 +1,42 @@
+package google_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+
+	"calendar-sync/pkg/providers"
+	"calendar-sync/pkg/providers/google"
+)
+
+func TestProviderNotFound(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	// deleted events are gone, and events which never existed aren't found
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusNotFound
+		if r.Method == http.MethodDelete {
+			status = http.StatusGone
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = fmt.Fprintf(w, `{"error": {"code": %d, "message": %q}}`, status, http.StatusText(status))
+	}))
+	t.Cleanup(server.Close)
+
+	provider := google.New(func(ctx context.Context) (*calendar.Service, error) {
+		return calendar.NewService(ctx, option.WithEndpoint(server.URL), option.WithHTTPClient(server.Client()))
+	})
+
+	require.ErrorIs(t, provider.DeleteEvent(ctx, "calendar", "event", ""), providers.ErrNotFound)
+	_, err := provider.PatchEvent(ctx, "calendar", "event", &calendar.Event{Summary: "gone"})
+	require.ErrorIs(t, err, providers.ErrNotFound)
+}
@@ -0,0 +1,209 @@
+package ics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/icalendar"
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/providers"
+)
+
+// CalendarIDPrefixes are the URL schemes which identify a calendar as an ICS feed.
+var CalendarIDPrefixes = []string{"webcal://", "https://", "http://"}
+
+const calendarNameProp = "X-WR-CALNAME"
+
+// Provider reads events from ICS feeds. Feeds are read-only and have no push channel, so
+// they can only be the source of a copy and are picked up by the scheduled sync.
+type Provider struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedFeed
+}
+
+type cachedFeed struct {
+	etag         string
+	lastModified string
+	calendar     *ical.Calendar
+}
+
+var _ providers.Provider = new(Provider)
+
+var ErrReadOnly = errors.Wrap(providers.ErrNotSupported, "ics feeds are read-only")
+
+func New(client *http.Client) *Provider {
+	return &Provider{
+		client: client,
+		cache:  make(map[string]cachedFeed),
+	}
+}
+
+// IsFeedURL reports whether a calendar ID should be handled by this provider.
+func IsFeedURL(calendarID string) bool {
+	for _, prefix := range CalendarIDPrefixes {
+		if strings.HasPrefix(calendarID, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toURL(calendarID string) string {
+	if rest, ok := strings.CutPrefix(calendarID, "webcal://"); ok {
+		return "https://" + rest
+	}
+
+	return calendarID
+}
+
+func (p *Provider) fetch(ctx context.Context, calendarID string) (*ical.Calendar, error) {
+	log := logs.GetLogger(ctx)
+
+	p.mu.Lock()
+	cached, isCached := p.cache[calendarID]
+	p.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, toURL(calendarID), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Accept", ical.MIMEType)
+
+	if isCached {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch feed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && isCached {
+		log.Debug().Str("calendar-id", calendarID).Msg("feed has not been modified")
+		return cached.calendar, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status fetching feed: %s", resp.Status)
+	}
+
+	cal, err := ical.NewDecoder(resp.Body).Decode()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode feed")
+	}
+
+	p.mu.Lock()
+	p.cache[calendarID] = cachedFeed{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		calendar:     cal,
+	}
+	p.mu.Unlock()
+
+	return cal, nil
+}
+
+// ListCalendars returns nothing, feeds can't be discovered and are added by URL.
+func (p *Provider) ListCalendars(context.Context) ([]providers.CalendarInfo, error) {
+	return nil, nil
+}
+
+func (p *Provider) GetCalendar(ctx context.Context, calendarID string) (providers.CalendarInfo, error) {
+	cal, err := p.fetch(ctx, calendarID)
+	if err != nil {
+		return providers.CalendarInfo{}, err
+	}
+
+	summary := calendarID
+	if name := cal.Props.Get(calendarNameProp); name != nil && name.Value != "" {
+		summary = name.Value
+	}
+
+	return providers.CalendarInfo{
+		ID:         calendarID,
+		Summary:    summary,
+		AccessRole: "reader",
+	}, nil
+}
+
+func (p *Provider) ListEvents(ctx context.Context, calendarID string, opts providers.ListEventsOptions) (providers.EventList, error) {
+	var result providers.EventList
+
+	if opts.SyncToken != "" {
+		return result, providers.ErrNotSupported
+	}
+
+	cal, err := p.fetch(ctx, calendarID)
+	if err != nil {
+		return result, err
+	}
+
+	for _, event := range icalendar.Expand(ctx, cal, opts.TimeMin, opts.TimeMax) {
+		if !providers.HasPrivateProperties(event, opts.PrivateExtendedProperties) {
+			continue
+		}
+
+		result.Items = append(result.Items, event)
+	}
+
+	return result, nil
+}
+
+func (p *Provider) GetEvent(ctx context.Context, calendarID, eventID string) (*calendar.Event, error) {
+	cal, err := p.fetch(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range icalendar.Expand(ctx, cal, time.Time{}, time.Time{}) {
+		if event.Id == eventID {
+			return event, nil
+		}
+	}
+
+	return nil, errors.Wrap(providers.ErrNotFound, eventID)
+}
+
+func (p *Provider) InsertEvent(context.Context, string, *calendar.Event) (*calendar.Event, error) {
+	return nil, ErrReadOnly
+}
+
+func (p *Provider) PatchEvent(context.Context, string, string, *calendar.Event) (*calendar.Event, error) {
+	return nil, ErrReadOnly
+}
+
+func (p *Provider) PatchAttendees(context.Context, string, string, string, []*calendar.EventAttendee, string) (*calendar.Event, error) {
+	return nil, ErrReadOnly
+}
+
+func (p *Provider) DeleteEvent(context.Context, string, string, string) error {
+	return ErrReadOnly
+}
+
+func (p *Provider) Watch(context.Context, string, providers.Channel) (providers.Channel, error) {
+	return providers.Channel{}, providers.ErrNotSupported
+}
+
+func (p *Provider) Unwatch(context.Context, providers.Channel) error {
+	return providers.ErrNotSupported
+}
+
+func (p *Provider) SupportsRecurrence(string) bool {
+	return false
+}
@@ -0,0 +1,105 @@
+package ics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/providers"
+	"calendar-sync/pkg/providers/ics"
+)
+
+const feed = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//test//test//EN
+X-WR-CALNAME:Team
+BEGIN:VEVENT
+UID:single
+DTSTAMP:20300101T000000Z
+DTSTART:20300102T100000Z
+DTEND:20300102T110000Z
+SUMMARY:kickoff
+END:VEVENT
+BEGIN:VEVENT
+UID:daily
+DTSTAMP:20300101T000000Z
+DTSTART:20300103T090000Z
+DTEND:20300103T091500Z
+RRULE:FREQ=DAILY;COUNT=5
+EXDATE:20300104T090000Z
+SUMMARY:standup
+END:VEVENT
+BEGIN:VEVENT
+UID:daily
+DTSTAMP:20300101T000000Z
+RECURRENCE-ID:20300105T090000Z
+DTSTART:20300105T100000Z
+DTEND:20300105T101500Z
+SUMMARY:late standup
+END:VEVENT
+BEGIN:VEVENT
+UID:far-away
+DTSTAMP:20300101T000000Z
+DTSTART;VALUE=DATE:20310101
+SUMMARY:new year
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestProvider(t *testing.T) {
+	t.Parallel()
+
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(strings.ReplaceAll(feed, "\n", "\r\n")))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := t.Context()
+	provider := ics.New(server.Client())
+	calendarID := server.URL + "/team.ics"
+
+	info, err := provider.GetCalendar(ctx, calendarID)
+	require.NoError(t, err)
+	assert.Equal(t, "Team", info.Summary)
+
+	start := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	events, err := provider.ListEvents(ctx, calendarID, providers.ListEventsOptions{
+		TimeMin: start,
+		TimeMax: start.Add(14 * 24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	byID := pkg.ToMap(events.Items, func(e *calendar.Event) string { return e.Id })
+	assert.Len(t, byID, 5)
+	assert.Equal(t, "kickoff", byID["single"].Summary)
+	assert.Equal(t, "standup", byID["daily_20300103T090000Z"].Summary)
+	assert.NotContains(t, byID, "daily_20300104T090000Z", "excluded by EXDATE")
+	assert.Equal(t, "late standup", byID["daily_20300105T090000Z"].Summary)
+	assert.Equal(t, "2030-01-05T10:00:00Z", byID["daily_20300105T090000Z"].Start.DateTime)
+	assert.Equal(t, "standup", byID["daily_20300106T090000Z"].Summary)
+	assert.Equal(t, "standup", byID["daily_20300107T090000Z"].Summary)
+	assert.Equal(t, "2030-01-07T09:15:00Z", byID["daily_20300107T090000Z"].End.DateTime)
+
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, int32(1), notModified.Load(), "second request should be served from the cache")
+
+	_, err = provider.InsertEvent(ctx, calendarID, &calendar.Event{})
+	require.ErrorIs(t, err, providers.ErrNotSupported)
+}
@@ -0,0 +1,536 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/icalendar"
+	"calendar-sync/pkg/providers"
+)
+
+// Provider is an in-memory calendar backend, used to exercise workflows without talking to a real server.
+type Provider struct {
+	mu        sync.Mutex
+	nextID    int
+	calendars map[string]*memoryCalendar
+	channels  map[string]providers.Channel
+
+	// sentUpdates records the last sendUpdates of each event's guest list
+	sentUpdates map[string]string
+
+	// onWrite runs before every patch and delete
+	onWrite func(calendarID, eventID string)
+
+	// version is bumped on every change, and is used as the sync token
+	version       int
+	oldestVersion int
+}
+
+type memoryCalendar struct {
+	info     providers.CalendarInfo
+	events   map[string]*calendar.Event
+	versions map[string]int
+	deleted  map[string]int
+
+	// noRecurrence makes the calendar refuse series and their instances, like CalDAV calendars
+	noRecurrence bool
+}
+
+var _ providers.Provider = new(Provider)
+
+var (
+	ErrCalendarNotFound = errors.Wrap(providers.ErrNotFound, "calendar")
+	ErrEventNotFound    = errors.Wrap(providers.ErrNotFound, "event")
+)
+
+func New() *Provider {
+	return &Provider{
+		calendars:   make(map[string]*memoryCalendar),
+		channels:    make(map[string]providers.Channel),
+		sentUpdates: make(map[string]string),
+	}
+}
+
+// AddCalendar registers an empty calendar.
+func (p *Provider) AddCalendar(info providers.CalendarInfo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calendars[info.ID] = &memoryCalendar{
+		info:     info,
+		events:   make(map[string]*calendar.Event),
+		versions: make(map[string]int),
+		deleted:  make(map[string]int),
+	}
+}
+
+// DisableRecurrence makes a calendar refuse series and their instances, see SupportsRecurrence.
+func (p *Provider) DisableRecurrence(calendarID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calendars[calendarID].noRecurrence = true
+}
+
+// ExpireSyncTokens invalidates every sync token handed out so far.
+func (p *Provider) ExpireSyncTokens() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.version++
+	p.oldestVersion = p.version
+}
+
+func (p *Provider) touch(c *memoryCalendar, eventID string) {
+	p.version++
+	c.versions[eventID] = p.version
+
+	if event, ok := c.events[eventID]; ok {
+		event.Updated = time.Now().UTC().Format(time.RFC3339Nano)
+		event.Etag = `"` + strconv.Itoa(p.version) + `"`
+	}
+}
+
+// OnWrite runs fn before every patch and delete, so tests can change an event in between a listing and a write.
+func (p *Provider) OnWrite(fn func(calendarID, eventID string)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.onWrite = fn
+}
+
+func (p *Provider) beforeWrite(calendarID, eventID string) {
+	p.mu.Lock()
+	fn := p.onWrite
+	p.mu.Unlock()
+
+	if fn != nil {
+		fn(calendarID, eventID)
+	}
+}
+
+// Events returns a copy of every event stored in a calendar, regardless of time.
+func (p *Provider) Events(calendarID string) []*calendar.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.calendars[calendarID]
+	if !ok {
+		return nil
+	}
+
+	var events []*calendar.Event
+	for _, event := range c.events {
+		events = append(events, clone(event))
+	}
+
+	return events
+}
+
+// Channels returns the currently active watch channels.
+func (p *Provider) Channels() []providers.Channel {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var channels []providers.Channel
+	for _, channel := range p.channels {
+		channels = append(channels, channel)
+	}
+
+	return channels
+}
+
+func (p *Provider) getCalendar(calendarID string) (*memoryCalendar, error) {
+	c, ok := p.calendars[calendarID]
+	if !ok {
+		return nil, errors.Wrap(ErrCalendarNotFound, calendarID)
+	}
+
+	return c, nil
+}
+
+func (p *Provider) ListCalendars(_ context.Context) ([]providers.CalendarInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var calendars []providers.CalendarInfo
+	for _, c := range p.calendars {
+		calendars = append(calendars, c.info)
+	}
+
+	return calendars, nil
+}
+
+func (p *Provider) GetCalendar(_ context.Context, calendarID string) (providers.CalendarInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, err := p.getCalendar(calendarID)
+	if err != nil {
+		return providers.CalendarInfo{}, err
+	}
+
+	return c.info, nil
+}
+
+func (p *Provider) ListEvents(ctx context.Context, calendarID string, opts providers.ListEventsOptions) (providers.EventList, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var result providers.EventList
+
+	c, err := p.getCalendar(calendarID)
+	if err != nil {
+		return result, err
+	}
+
+	result.NextSyncToken = syncToken(p.version, opts.SingleEvents)
+
+	if opts.SyncToken != "" {
+		// like google, tokens can't be used with another singleEvents setting than their listing's
+		version, singleEvents := strings.CutSuffix(opts.SyncToken, singleEventsSuffix)
+		since, err := strconv.Atoi(version)
+		if err != nil || since < p.oldestVersion || singleEvents != opts.SingleEvents {
+			return result, providers.ErrSyncTokenExpired
+		}
+
+		for id, version := range c.versions {
+			if version > since {
+				result.Items = append(result.Items, clone(c.events[id]))
+			}
+		}
+		for id, version := range c.deleted {
+			if version > since {
+				result.Items = append(result.Items, &calendar.Event{Id: id, Status: "cancelled"})
+			}
+		}
+
+		return result, nil
+	}
+
+	for _, event := range c.events {
+		events, err := listed(ctx, c, event, opts)
+		if err != nil {
+			return result, err
+		}
+
+		for _, event := range events {
+			if providers.HasPrivateProperties(event, opts.PrivateExtendedProperties) {
+				result.Items = append(result.Items, clone(event))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// listed returns what a listing shows of a stored event. Like google, a series is listed if any of its instances are
+// in the window, and cancelled instances are only listed alongside their series.
+func listed(ctx context.Context, c *memoryCalendar, event *calendar.Event, opts providers.ListEventsOptions) ([]*calendar.Event, error) {
+	if event.RecurringEventId != "" {
+		if opts.SingleEvents && event.Status == "cancelled" {
+			return nil, nil
+		}
+		if !inWindow(event, opts.TimeMin, opts.TimeMax) {
+			return nil, nil
+		}
+		return []*calendar.Event{event}, nil
+	}
+
+	if len(event.Recurrence) == 0 || opts.TimeMax.IsZero() {
+		if !inWindow(event, opts.TimeMin, opts.TimeMax) {
+			return nil, nil
+		}
+		return []*calendar.Event{event}, nil
+	}
+
+	instances, err := expand(ctx, event, opts.TimeMin, opts.TimeMax)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.SingleEvents {
+		if len(instances) == 0 {
+			return nil, nil
+		}
+		return []*calendar.Event{event}, nil
+	}
+
+	// stored instances replace the ones generated from the series
+	return slices.DeleteFunc(instances, func(instance *calendar.Event) bool {
+		_, ok := c.events[instance.Id]
+		return ok
+	}), nil
+}
+
+// expand generates the instances of a recurring event between timeMin and timeMax.
+func expand(ctx context.Context, master *calendar.Event, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	withUID := clone(master)
+	withUID.ICalUID = master.Id
+
+	e, err := icalendar.FromEvent(withUID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert %s", master.Id)
+	}
+	cal := icalendar.NewCalendar()
+	cal.Children = append(cal.Children, e.Component)
+
+	expanded := icalendar.Expand(ctx, cal, timeMin, timeMax)
+
+	instances := make([]*calendar.Event, 0, len(expanded))
+	for _, occurrence := range expanded {
+		instance := clone(master)
+		instance.Id = occurrence.Id
+		instance.RecurringEventId = master.Id
+		instance.Recurrence = nil
+		instance.Start = occurrence.Start
+		instance.End = occurrence.End
+		instance.OriginalStartTime = occurrence.OriginalStartTime
+
+		instances = append(instances, instance)
+	}
+
+	return instances, nil
+}
+
+func (p *Provider) GetEvent(_ context.Context, calendarID, eventID string) (*calendar.Event, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, err := p.getCalendar(calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, ok := c.events[eventID]
+	if !ok {
+		return nil, errors.Wrap(ErrEventNotFound, eventID)
+	}
+
+	return clone(event), nil
+}
+
+func (p *Provider) InsertEvent(_ context.Context, calendarID string, event *calendar.Event) (*calendar.Event, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, err := p.getCalendar(calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.noRecurrence && (len(event.Recurrence) > 0 || event.RecurringEventId != "") {
+		return nil, errors.Wrap(providers.ErrNotSupported, "recurring events")
+	}
+
+	created := clone(event)
+	if created.RecurringEventId != "" {
+		if created.Id, err = instanceID(c, created); err != nil {
+			return nil, err
+		}
+	}
+	if created.Id == "" {
+		p.nextID++
+		created.Id = "event-" + strconv.Itoa(p.nextID)
+	}
+	if created.Status == "" {
+		created.Status = "confirmed"
+	}
+
+	c.events[created.Id] = created
+	p.touch(c, created.Id)
+
+	return clone(created), nil
+}
+
+// instanceID returns the ID of the instance of a series an event replaces.
+func instanceID(c *memoryCalendar, event *calendar.Event) (string, error) {
+	if _, ok := c.events[event.RecurringEventId]; !ok {
+		return "", errors.Wrap(ErrEventNotFound, event.RecurringEventId)
+	}
+
+	originalStart, err := icalendar.ParseEventDateTime(event.OriginalStartTime)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse original start time")
+	}
+
+	return icalendar.InstanceID(event.RecurringEventId, originalStart, event.OriginalStartTime.DateTime == ""), nil
+}
+
+func (p *Provider) PatchEvent(_ context.Context, calendarID, eventID string, patch *calendar.Event) (*calendar.Event, error) {
+	p.beforeWrite(calendarID, eventID)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, err := p.getCalendar(calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, ok := c.events[eventID]
+	if !ok {
+		return nil, errors.Wrap(ErrEventNotFound, eventID)
+	}
+	if patch.Etag != "" && patch.Etag != event.Etag {
+		return nil, errors.Wrap(providers.ErrPreconditionFailed, eventID)
+	}
+
+	if err = providers.ApplyPatch(event, patch); err != nil {
+		return nil, err
+	}
+	p.touch(c, eventID)
+
+	return clone(event), nil
+}
+
+func (p *Provider) PatchAttendees(ctx context.Context, calendarID, eventID, etag string, attendees []*calendar.EventAttendee, sendUpdates string) (*calendar.Event, error) {
+	patched, err := p.PatchEvent(ctx, calendarID, eventID, &calendar.Event{Etag: etag, Attendees: attendees, ForceSendFields: []string{"Attendees"}})
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sentUpdates[calendarID+"/"+eventID] = sendUpdates
+
+	return patched, nil
+}
+
+// SentUpdates returns who was notified of the last change to an event's guest list.
+func (p *Provider) SentUpdates(calendarID, eventID string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.sentUpdates[calendarID+"/"+eventID]
+}
+
+func (p *Provider) DeleteEvent(_ context.Context, calendarID, eventID, etag string) error {
+	p.beforeWrite(calendarID, eventID)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, err := p.getCalendar(calendarID)
+	if err != nil {
+		return err
+	}
+
+	event, ok := c.events[eventID]
+	if !ok {
+		return errors.Wrap(ErrEventNotFound, eventID)
+	}
+	if etag != "" && etag != event.Etag {
+		return errors.Wrap(providers.ErrPreconditionFailed, eventID)
+	}
+
+	// instances go with their series
+	for id, event := range c.events {
+		if id == eventID || event.RecurringEventId == eventID {
+			delete(c.events, id)
+			delete(c.versions, id)
+
+			p.version++
+			c.deleted[id] = p.version
+		}
+	}
+
+	return nil
+}
+
+func (p *Provider) Watch(_ context.Context, calendarID string, channel providers.Channel) (providers.Channel, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.getCalendar(calendarID); err != nil {
+		return channel, err
+	}
+
+	channel.ResourceID = calendarID
+	channel.Expiration = time.Now().Add(7 * 24 * time.Hour)
+	p.channels[channel.ID] = channel
+
+	return channel, nil
+}
+
+func (p *Provider) Unwatch(_ context.Context, channel providers.Channel) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.channels, channel.ID)
+
+	return nil
+}
+
+func (p *Provider) SupportsRecurrence(calendarID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.calendars[calendarID]
+	return !ok || !c.noRecurrence
+}
+
+// singleEventsSuffix marks the sync tokens of expanded listings.
+const singleEventsSuffix = "-single"
+
+func syncToken(version int, singleEvents bool) string {
+	token := strconv.Itoa(version)
+	if singleEvents {
+		token += singleEventsSuffix
+	}
+	return token
+}
+
+func clone(event *calendar.Event) *calendar.Event {
+	data, err := json.Marshal(event)
+	if err != nil {
+		panic(err)
+	}
+
+	var result calendar.Event
+	if err = json.Unmarshal(data, &result); err != nil {
+		panic(err)
+	}
+
+	return &result
+}
+
+func inWindow(event *calendar.Event, timeMin, timeMax time.Time) bool {
+	start, startOK := parseEventTime(event.Start)
+	end, endOK := parseEventTime(event.End)
+
+	if !timeMin.IsZero() && endOK && !end.After(timeMin) {
+		return false
+	}
+	if !timeMax.IsZero() && startOK && !start.Before(timeMax) {
+		return false
+	}
+
+	return true
+}
+
+func parseEventTime(dt *calendar.EventDateTime) (time.Time, bool) {
+	if dt == nil {
+		return time.Time{}, false
+	}
+
+	if dt.DateTime != "" {
+		t, err := time.Parse(time.RFC3339, dt.DateTime)
+		return t, err == nil
+	}
+
+	if dt.Date != "" {
+		t, err := time.Parse(time.DateOnly, dt.Date)
+		return t, err == nil
+	}
+
+	return time.Time{}, false
+}
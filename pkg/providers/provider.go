@@ -0,0 +1,130 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+)
+
+// Provider is a calendar backend. Events are exchanged using the google
+// calendar model, other backends are expected to convert to and from it.
+type Provider interface {
+	ListCalendars(ctx context.Context) ([]CalendarInfo, error)
+	GetCalendar(ctx context.Context, calendarID string) (CalendarInfo, error)
+
+	ListEvents(ctx context.Context, calendarID string, opts ListEventsOptions) (EventList, error)
+	GetEvent(ctx context.Context, calendarID, eventID string) (*calendar.Event, error)
+	// InsertEvent creates an event. Events with a RecurringEventId and OriginalStartTime replace that instance of the
+	// series instead, which may also cancel it.
+	InsertEvent(ctx context.Context, calendarID string, event *calendar.Event) (*calendar.Event, error)
+	// PatchEvent changes the fields set on the patch. A patch with an Etag only applies to that version of the event,
+	// and fails with ErrPreconditionFailed if it was changed since. The same goes for the etag of the other writes,
+	// which is ignored when empty.
+	PatchEvent(ctx context.Context, calendarID, eventID string, patch *calendar.Event) (*calendar.Event, error)
+	// PatchAttendees replaces the guest list of an event. sendUpdates is who google notifies of the change, "all",
+	// "externalOnly" or "none"; other providers leave notifications to their server.
+	PatchAttendees(ctx context.Context, calendarID, eventID, etag string, attendees []*calendar.EventAttendee, sendUpdates string) (*calendar.Event, error)
+	DeleteEvent(ctx context.Context, calendarID, eventID, etag string) error
+
+	Watch(ctx context.Context, calendarID string, channel Channel) (Channel, error)
+	Unwatch(ctx context.Context, channel Channel) error
+
+	// SupportsRecurrence reports whether a calendar can store series along with their modified and cancelled
+	// instances. Copies into calendars which can't are made of the instances instead.
+	SupportsRecurrence(calendarID string) bool
+}
+
+var (
+	ErrNotSupported = errors.New("operation is not supported by this provider")
+	ErrNotFound     = errors.New("not found")
+
+	// ErrPreconditionFailed means the event was changed since the version a write was based on.
+	ErrPreconditionFailed = errors.New("event was changed concurrently")
+
+	// ErrSyncTokenExpired means a full listing is required to get a new sync token.
+	ErrSyncTokenExpired = errors.New("sync token is no longer valid")
+)
+
+type CalendarInfo struct {
+	ID         string
+	Summary    string
+	AccessRole string
+}
+
+type ListEventsOptions struct {
+	TimeMin, TimeMax time.Time
+
+	// SingleEvents expands recurring events into their instances within the window, instead of returning the series
+	// with its modified and cancelled instances.
+	SingleEvents bool
+
+	// PrivateExtendedProperties only returns events which have all the given private properties.
+	PrivateExtendedProperties map[string]string
+
+	// SyncToken only returns events which changed since the listing that returned it, including deleted
+	// events. It can't be combined with the other options, except SingleEvents which must be set like it was for
+	// that listing.
+	SyncToken string
+}
+
+type EventList struct {
+	Items []*calendar.Event
+
+	// NextSyncToken is empty if the provider doesn't support incremental listing.
+	NextSyncToken string
+}
+
+type Channel struct {
+	ID         string
+	Token      string
+	Address    string
+	ResourceID string
+	Expiration time.Time
+}
+
+// HasPrivateProperties reports whether an event has all the given private extended properties.
+func HasPrivateProperties(event *calendar.Event, properties map[string]string) bool {
+	for key, value := range properties {
+		if event.ExtendedProperties == nil || event.ExtendedProperties.Private[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ApplyPatch applies a patch to an event the way google does. Fields which are empty are left alone, unless they're
+// forced or null, which clears them. Providers which patch events themselves use it.
+func ApplyPatch(event, patch *calendar.Event) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal patch")
+	}
+	if err = json.Unmarshal(data, event); err != nil {
+		return errors.Wrap(err, "failed to apply patch")
+	}
+
+	// null is only unmarshalled into pointers, other fields keep their value
+	clearFields(event, patch.NullFields)
+	if patch.Start != nil && event.Start != nil {
+		clearFields(event.Start, patch.Start.NullFields)
+	}
+	if patch.End != nil && event.End != nil {
+		clearFields(event.End, patch.End.NullFields)
+	}
+
+	return nil
+}
+
+func clearFields(v any, names []string) {
+	value := reflect.ValueOf(v).Elem()
+	for _, name := range names {
+		if field := value.FieldByName(name); field.IsValid() {
+			field.SetZero()
+		}
+	}
+}
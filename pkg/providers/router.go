@@ -0,0 +1,103 @@
+package providers
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/logs"
+)
+
+// Router dispatches each call to a provider based on the prefix of the calendar ID, falling back to a default.
+type Router struct {
+	fallback Provider
+	routes   []route
+}
+
+type route struct {
+	prefix   string
+	provider Provider
+}
+
+var _ Provider = new(Router)
+
+func NewRouter(fallback Provider) *Router {
+	return &Router{fallback: fallback}
+}
+
+// Register sends every calendar ID starting with prefix to provider.
+func (r *Router) Register(prefix string, provider Provider) {
+	r.routes = append(r.routes, route{prefix: prefix, provider: provider})
+}
+
+func (r *Router) providerFor(calendarID string) Provider {
+	for _, route := range r.routes {
+		if strings.HasPrefix(calendarID, route.prefix) {
+			return route.provider
+		}
+	}
+
+	return r.fallback
+}
+
+// ListCalendars only fails if the fallback does, calendars of routed providers which fail are left out.
+func (r *Router) ListCalendars(ctx context.Context) ([]CalendarInfo, error) {
+	calendars, err := r.fallback.ListCalendars(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, route := range r.routes {
+		routed, err := route.provider.ListCalendars(ctx)
+		if err != nil {
+			logs.GetLogger(ctx).Warn().Err(err).Str("prefix", route.prefix).Msg("failed to list calendars")
+			continue
+		}
+
+		calendars = append(calendars, routed...)
+	}
+
+	return calendars, nil
+}
+
+func (r *Router) GetCalendar(ctx context.Context, calendarID string) (CalendarInfo, error) {
+	return r.providerFor(calendarID).GetCalendar(ctx, calendarID)
+}
+
+func (r *Router) ListEvents(ctx context.Context, calendarID string, opts ListEventsOptions) (EventList, error) {
+	return r.providerFor(calendarID).ListEvents(ctx, calendarID, opts)
+}
+
+func (r *Router) GetEvent(ctx context.Context, calendarID, eventID string) (*calendar.Event, error) {
+	return r.providerFor(calendarID).GetEvent(ctx, calendarID, eventID)
+}
+
+func (r *Router) InsertEvent(ctx context.Context, calendarID string, event *calendar.Event) (*calendar.Event, error) {
+	return r.providerFor(calendarID).InsertEvent(ctx, calendarID, event)
+}
+
+func (r *Router) PatchEvent(ctx context.Context, calendarID, eventID string, patch *calendar.Event) (*calendar.Event, error) {
+	return r.providerFor(calendarID).PatchEvent(ctx, calendarID, eventID, patch)
+}
+
+func (r *Router) PatchAttendees(ctx context.Context, calendarID, eventID, etag string, attendees []*calendar.EventAttendee, sendUpdates string) (*calendar.Event, error) {
+	return r.providerFor(calendarID).PatchAttendees(ctx, calendarID, eventID, etag, attendees, sendUpdates)
+}
+
+func (r *Router) DeleteEvent(ctx context.Context, calendarID, eventID, etag string) error {
+	return r.providerFor(calendarID).DeleteEvent(ctx, calendarID, eventID, etag)
+}
+
+func (r *Router) Watch(ctx context.Context, calendarID string, channel Channel) (Channel, error) {
+	return r.providerFor(calendarID).Watch(ctx, calendarID, channel)
+}
+
+func (r *Router) SupportsRecurrence(calendarID string) bool {
+	return r.providerFor(calendarID).SupportsRecurrence(calendarID)
+}
+
+// Unwatch is only supported by the fallback provider, since channels don't carry a calendar ID.
+func (r *Router) Unwatch(ctx context.Context, channel Channel) error {
+	return r.fallback.Unwatch(ctx, channel)
+}
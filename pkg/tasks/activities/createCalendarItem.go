@@ -25,14 +25,8 @@ func (a Activities) CreateCalendarItem(ctx context.Context, args CreateCalendarI
 
 	var result CreateCalendarItemResult
 
-	log.Info().Msg("get calendar client")
-	client, err := a.ctr.GetCalendarClient(ctx)
-	if err != nil {
-		return result, errors.Wrap(err, "failed to create client")
-	}
-
 	log.Info().Str("calendar-id", args.CalendarID).Msg("insert event into calendar")
-	created, err := client.Events.Insert(args.CalendarID, args.Event).Context(ctx).Do()
+	created, err := a.ctr.Provider.InsertEvent(ctx, args.CalendarID, args.Event)
 	if err != nil {
 		return result, errors.Wrap(err, "failed to create event")
 	}
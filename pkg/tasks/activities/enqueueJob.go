@@ -0,0 +1,47 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/persistence"
+)
+
+type EnqueueJobArgs struct {
+	Kind string
+	// IdempotencyKey keeps the job from being queued twice, see persistence.Job.
+	IdempotencyKey string
+	// Args are passed to the job's handler as JSON.
+	Args any
+}
+
+type EnqueueJobResult struct {
+	// Enqueued is false if a job with the same key was pending or running already.
+	Enqueued bool
+}
+
+func (a Activities) EnqueueJob(ctx context.Context, args EnqueueJobArgs) (EnqueueJobResult, error) {
+	ctx = setupLogger(ctx, "EnqueueJob")
+
+	var result EnqueueJobResult
+
+	encoded, err := json.Marshal(args.Args)
+	if err != nil {
+		return result, errors.Wrap(err, "failed to encode job arguments")
+	}
+
+	result.Enqueued, err = a.ctr.Database.CreateJob(ctx, persistence.Job{
+		Kind:           args.Kind,
+		Args:           string(encoded),
+		IdempotencyKey: args.IdempotencyKey,
+		RunAt:          time.Now(),
+	})
+	if err != nil {
+		return result, errors.Wrap(err, "failed to queue job")
+	}
+
+	return result, nil
+}
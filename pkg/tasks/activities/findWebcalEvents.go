@@ -2,18 +2,21 @@ package activities
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/pkg/errors"
 	"google.golang.org/api/calendar/v3"
 
 	"calendar-sync/pkg"
+	"calendar-sync/pkg/providers"
 )
 
+// FindWebcalEventsArgs finds the copies in a destination by their source. Fields which are empty match any value, so
+// copies of the same event from every source are found by SourceICalUID alone.
 type FindWebcalEventsArgs struct {
 	DestinationCalendarID string
 	SourceCalendarID      string
 	SourceCalendarItemID  string
+	SourceICalUID         string
 }
 
 type FindWebcalEventsResults struct {
@@ -25,15 +28,20 @@ func (a Activities) FindDestinationWebcalEvent(ctx context.Context, args FindWeb
 
 	var result FindWebcalEventsResults
 
-	client, err := a.ctr.GetCalendarClient(ctx)
-	if err != nil {
-		return result, errors.Wrap(err, "failed to create calendar client")
+	properties := make(map[string]string)
+	for key, value := range map[string]string{
+		pkg.SourceCalendarIDKey:     args.SourceCalendarID,
+		pkg.SourceCalendarItemIDKey: args.SourceCalendarItemID,
+		pkg.SourceICalUIDKey:        args.SourceICalUID,
+	} {
+		if value != "" {
+			properties[key] = value
+		}
 	}
 
-	response, err := client.Events.List(args.DestinationCalendarID).PrivateExtendedProperty(
-		fmt.Sprintf("%s=%s", pkg.SourceCalendarIDKey, args.SourceCalendarID),
-		fmt.Sprintf("%s=%s", pkg.SourceCalendarItemIDKey, args.SourceCalendarItemID),
-	).Context(ctx).Do()
+	response, err := a.ctr.Provider.ListEvents(ctx, args.DestinationCalendarID, providers.ListEventsOptions{
+		PrivateExtendedProperties: properties,
+	})
 	if err != nil {
 		return result, errors.Wrap(err, "failed to list events")
 	}
@@ -20,12 +20,7 @@ func (a Activities) GetCalendarItemByItemID(ctx context.Context, args GetCalenda
 
 	var result GetCalendarItemByItemIDResult
 
-	client, err := a.ctr.GetCalendarClient(ctx)
-	if err != nil {
-		return result, errors.Wrap(err, "failed to get calendar client")
-	}
-
-	response, err := client.Events.Get(args.CalendarID, args.EventID).Context(ctx).Do()
+	response, err := a.ctr.Provider.GetEvent(ctx, args.CalendarID, args.EventID)
 	if err != nil {
 		return result, errors.Wrap(err, "failed to get event")
 	}
@@ -0,0 +1,94 @@
+package activities
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/providers"
+)
+
+type GetChangedEventsArgs struct {
+	CalendarID string
+	// SingleEvents lists the changes of expanded listings, which have a sync token of their own.
+	SingleEvents bool
+}
+
+type GetChangedEventsResult struct {
+	// Items includes cancelled events, which have been removed since the last sync.
+	Items         []*calendar.Event
+	NextSyncToken string
+
+	// FullSyncRequired is set when there is no usable sync token, and Items is empty.
+	FullSyncRequired bool
+}
+
+// GetChangedEvents lists what changed since the stored sync token. Without a usable token, a new one is listed and
+// stored before the full sync that's required, so changes made during that sync aren't missed.
+func (a Activities) GetChangedEvents(ctx context.Context, args GetChangedEventsArgs) (GetChangedEventsResult, error) {
+	ctx = setupLogger(ctx, "GetChangedEvents")
+
+	log := logs.GetLogger(ctx).With().
+		Str("calendar-id", args.CalendarID).
+		Bool("single-events", args.SingleEvents).
+		Logger()
+
+	var result GetChangedEventsResult
+
+	token, err := a.ctr.Database.GetSyncToken(ctx, args.CalendarID, args.SingleEvents)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Info().Msg("no sync token")
+		result.FullSyncRequired = true
+		return result, a.listSyncToken(ctx, args)
+	}
+	if err != nil {
+		return result, errors.Wrap(err, "failed to get sync token")
+	}
+
+	opts := providers.ListEventsOptions{SyncToken: token, SingleEvents: args.SingleEvents}
+	events, err := a.ctr.Provider.ListEvents(ctx, args.CalendarID, opts)
+	expired := errors.Is(err, providers.ErrSyncTokenExpired)
+	if expired || errors.Is(err, providers.ErrNotSupported) {
+		log.Info().Err(err).Msg("sync token cannot be used")
+		if err = a.ctr.Database.DeleteSyncToken(ctx, args.CalendarID, args.SingleEvents); err != nil {
+			return result, errors.Wrap(err, "failed to delete sync token")
+		}
+		result.FullSyncRequired = true
+		if !expired {
+			return result, nil
+		}
+		return result, a.listSyncToken(ctx, args)
+	}
+	if err != nil {
+		return result, errors.Wrap(err, "failed to list changed events")
+	}
+
+	result.Items = events.Items
+	result.NextSyncToken = events.NextSyncToken
+
+	return result, nil
+}
+
+// listSyncToken stores the token of a listing with the same options as the listings of changes, windowed listings
+// can't be continued with a token.
+func (a Activities) listSyncToken(ctx context.Context, args GetChangedEventsArgs) error {
+	events, err := a.ctr.Provider.ListEvents(ctx, args.CalendarID, providers.ListEventsOptions{SingleEvents: args.SingleEvents})
+	if errors.Is(err, providers.ErrNotSupported) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to list events for a sync token")
+	}
+	if events.NextSyncToken == "" {
+		return nil
+	}
+
+	if err := a.ctr.Database.SetSyncToken(ctx, args.CalendarID, args.SingleEvents, events.NextSyncToken); err != nil {
+		return errors.Wrap(err, "failed to store sync token")
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package activities
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/persistence"
+)
+
+type GetCopyConfigArgs struct {
+	CopyID int
+}
+
+type GetCopyConfigResult struct {
+	CopyConfig persistence.CopyConfig
+}
+
+func (a Activities) GetCopyConfig(ctx context.Context, args GetCopyConfigArgs) (GetCopyConfigResult, error) {
+	ctx = setupLogger(ctx, "GetCopyConfig")
+
+	var result GetCopyConfigResult
+
+	config, err := a.ctr.Database.GetCopyConfig(ctx, int64(args.CopyID))
+	if err != nil {
+		return result, errors.Wrap(err, "failed to get copy config from the db")
+	}
+
+	result.CopyConfig = config
+	return result, nil
+}
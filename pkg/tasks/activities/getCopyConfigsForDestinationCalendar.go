@@ -0,0 +1,31 @@
+package activities
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/persistence"
+)
+
+type GetCopyConfigsForDestinationCalendarArgs struct {
+	CalendarID string
+}
+
+type GetCopyConfigsForDestinationCalendarResult struct {
+	CopyConfigs []persistence.CopyConfig
+}
+
+func (a Activities) GetCopyConfigsForDestinationCalendar(ctx context.Context, args GetCopyConfigsForDestinationCalendarArgs) (GetCopyConfigsForDestinationCalendarResult, error) {
+	ctx = setupLogger(ctx, "GetCopyConfigsForDestinationCalendar")
+
+	var result GetCopyConfigsForDestinationCalendarResult
+
+	configs, err := a.ctr.Database.GetCopyConfigsByDestinationCalendar(ctx, args.CalendarID)
+	if err != nil {
+		return result, errors.Wrap(err, "failed to get configs from the db")
+	}
+
+	result.CopyConfigs = configs
+	return result, nil
+}
@@ -0,0 +1,31 @@
+package activities
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/persistence"
+)
+
+type GetGroupArgs struct {
+	GroupID int
+}
+
+type GetGroupResult struct {
+	Group persistence.Group
+}
+
+func (a Activities) GetGroup(ctx context.Context, args GetGroupArgs) (GetGroupResult, error) {
+	ctx = setupLogger(ctx, "GetGroup")
+
+	var result GetGroupResult
+
+	group, err := a.ctr.Database.GetGroup(ctx, args.GroupID)
+	if err != nil {
+		return result, errors.Wrap(err, "failed to get group from the db")
+	}
+
+	result.Group = group
+	return result, nil
+}
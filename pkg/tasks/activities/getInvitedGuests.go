@@ -0,0 +1,31 @@
+package activities
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/persistence"
+)
+
+type GetInvitedGuestsArgs struct {
+	InviteID int
+}
+
+type GetInvitedGuestsResult struct {
+	Guests []persistence.InvitedGuest
+}
+
+func (a Activities) GetInvitedGuests(ctx context.Context, args GetInvitedGuestsArgs) (GetInvitedGuestsResult, error) {
+	ctx = setupLogger(ctx, "GetInvitedGuests")
+
+	var result GetInvitedGuestsResult
+
+	guests, err := a.ctr.Database.GetInvitedGuests(ctx, args.InviteID)
+	if err != nil {
+		return result, errors.Wrap(err, "failed to get invited guests from the db")
+	}
+
+	result.Guests = guests
+	return result, nil
+}
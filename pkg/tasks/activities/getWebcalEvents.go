@@ -5,61 +5,73 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"google.golang.org/api/calendar/v3"
 
 	"calendar-sync/pkg"
+	"calendar-sync/pkg/providers"
 )
 
 type GetCalendarEventsActivityArgs struct {
 	CalendarID string
+
+	// TimeMin and TimeMax default to now and SearchWindow from now.
+	TimeMin, TimeMax time.Time
+
+	// SingleEvents lists the instances of recurring events instead of the series, see
+	// providers.ListEventsOptions.
+	SingleEvents bool
+
+	// CancelledInstances keeps cancelled instances of recurring series, which are left out along with cancelled
+	// events otherwise.
+	CancelledInstances bool
 }
 
 type GetCalendarEventsActivityResult struct {
 	Calendar pkg.Calendar
+
+	// NextSyncToken can be used to list changes made after this listing.
+	NextSyncToken string
 }
 
 var SearchWindow = time.Hour * 24 * 14
 
-func rfc3339(t time.Time) string {
-	return t.Format(time.RFC3339)
-}
-
 func (a Activities) GetCalendarEventsActivity(ctx context.Context, args GetCalendarEventsActivityArgs) (GetCalendarEventsActivityResult, error) {
 	ctx = setupLogger(ctx, "GetCalendarEventsActivity")
 
 	var result GetCalendarEventsActivityResult
 
-	client, err := a.ctr.GetCalendarClient(ctx)
-	if err != nil {
-		return result, errors.Wrap(err, "failed to create client")
-	}
-
-	c, err := client.Calendars.Get(args.CalendarID).Context(ctx).Do()
+	c, err := a.ctr.Provider.GetCalendar(ctx, args.CalendarID)
 	if err != nil {
 		return result, errors.Wrap(err, "failed to retrieve calendar")
 	}
-	result.Calendar.CalendarID = c.Id
+	result.Calendar.CalendarID = c.ID
 	result.Calendar.Summary = c.Summary
 
 	now := time.Now()
-	listCall := client.Events.List(args.CalendarID).
-		TimeMin(rfc3339(now)).
-		TimeMax(rfc3339(now.Add(SearchWindow))).
-		MaxResults(100)
-
-	if err = listCall.Pages(ctx, func(events *calendar.Events) error {
-		for _, event := range events.Items {
-			if event.Status == "cancelled" {
-				continue
-			}
-
-			result.Calendar.Items = append(result.Calendar.Items, event)
-		}
+	timeMin, timeMax := args.TimeMin, args.TimeMax
+	if timeMin.IsZero() {
+		timeMin = now
+	}
+	if timeMax.IsZero() {
+		timeMax = now.Add(SearchWindow)
+	}
 
-		return nil
-	}); err != nil {
+	events, err := a.ctr.Provider.ListEvents(ctx, args.CalendarID, providers.ListEventsOptions{
+		TimeMin:      timeMin,
+		TimeMax:      timeMax,
+		SingleEvents: args.SingleEvents,
+	})
+	if err != nil {
 		return result, errors.Wrap(err, "failed to retrieve events")
 	}
+	result.NextSyncToken = events.NextSyncToken
+
+	for _, event := range events.Items {
+		if event.Status == "cancelled" && (!args.CancelledInstances || event.RecurringEventId == "") {
+			continue
+		}
+
+		result.Calendar.Items = append(result.Calendar.Items, event)
+	}
 
 	return result, nil
 }
@@ -0,0 +1,29 @@
+package activities
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type PruneHistoryArgs struct {
+	// Before is when the conflicts and dead jobs which are deleted were recorded before.
+	Before time.Time
+}
+
+type PruneHistoryResult struct{}
+
+func (a Activities) PruneHistory(ctx context.Context, args PruneHistoryArgs) (PruneHistoryResult, error) {
+	ctx = setupLogger(ctx, "PruneHistory")
+
+	if err := a.ctr.Database.DeleteConflictsBefore(ctx, args.Before); err != nil {
+		return PruneHistoryResult{}, errors.Wrap(err, "failed to delete conflicts")
+	}
+
+	if err := a.ctr.Database.DeleteDeadJobsBefore(ctx, args.Before); err != nil {
+		return PruneHistoryResult{}, errors.Wrap(err, "failed to delete dead jobs")
+	}
+
+	return PruneHistoryResult{}, nil
+}
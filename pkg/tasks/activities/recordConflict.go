@@ -0,0 +1,25 @@
+package activities
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/persistence"
+)
+
+type RecordConflictArgs struct {
+	Conflict persistence.Conflict
+}
+
+type RecordConflictResult struct{}
+
+func (a Activities) RecordConflict(ctx context.Context, args RecordConflictArgs) (RecordConflictResult, error) {
+	ctx = setupLogger(ctx, "RecordConflict")
+
+	if err := a.ctr.Database.CreateConflict(ctx, args.Conflict); err != nil {
+		return RecordConflictResult{}, errors.Wrap(err, "failed to record conflict")
+	}
+
+	return RecordConflictResult{}, nil
+}
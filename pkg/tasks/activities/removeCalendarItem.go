@@ -8,6 +8,8 @@ import (
 
 type RemoveCalendarItemArgs struct {
 	CalendarID, EventID string
+	// Etag is the version of the event which is removed, see providers.ErrPreconditionFailed.
+	Etag string
 }
 
 type RemoveCalendarItemResult struct{}
@@ -17,12 +19,7 @@ func (a Activities) RemoveCalendarItem(ctx context.Context, args RemoveCalendarI
 
 	var result RemoveCalendarItemResult
 
-	client, err := a.ctr.GetCalendarClient(ctx)
-	if err != nil {
-		return result, errors.Wrap(err, "failed to create client")
-	}
-
-	if err := client.Events.Delete(args.CalendarID, args.EventID).Context(ctx).Do(); err != nil {
+	if err := a.ctr.Provider.DeleteEvent(ctx, args.CalendarID, args.EventID, args.Etag); err != nil {
 		return result, errors.Wrap(err, "failed to delete event")
 	}
 
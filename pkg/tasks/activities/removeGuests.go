@@ -0,0 +1,57 @@
+package activities
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/providers"
+)
+
+type RemoveGuestsArgs struct {
+	InviteID       int
+	CalendarID     string
+	CalendarItemID string
+	EmailAddresses []string
+	SendUpdates    string
+}
+
+type RemoveGuestsResult struct{}
+
+// RemoveGuests takes guests an invite config added off an event again, and forgets about them. Events which are gone
+// are only forgotten.
+func (a Activities) RemoveGuests(ctx context.Context, args RemoveGuestsArgs) (RemoveGuestsResult, error) {
+	ctx = setupLogger(ctx, "RemoveGuests")
+
+	var result RemoveGuestsResult
+
+	event, err := a.ctr.Provider.GetEvent(ctx, args.CalendarID, args.CalendarItemID)
+	if err != nil && !errors.Is(err, providers.ErrNotFound) {
+		return result, errors.Wrap(err, "failed to get event")
+	}
+
+	if err == nil && event.Status != "cancelled" {
+		attendees := slices.DeleteFunc(slices.Clone(event.Attendees), func(attendee *calendar.EventAttendee) bool {
+			return slices.ContainsFunc(args.EmailAddresses, func(email string) bool { return strings.EqualFold(email, attendee.Email) })
+		})
+
+		if len(attendees) != len(event.Attendees) {
+			if _, err := a.ctr.Provider.PatchAttendees(ctx, args.CalendarID, args.CalendarItemID, event.Etag, attendees, args.SendUpdates); err != nil {
+				return result, errors.Wrap(err, "failed to patch event")
+			}
+		}
+	}
+
+	for _, email := range args.EmailAddresses {
+		guest := persistence.InvitedGuest{InviteID: args.InviteID, CalendarID: args.CalendarID, EventID: args.CalendarItemID, EmailAddress: email}
+		if err := a.ctr.Database.DeleteInvitedGuest(ctx, guest); err != nil {
+			return result, errors.Wrap(err, "failed to forget invited guest")
+		}
+	}
+
+	return result, nil
+}
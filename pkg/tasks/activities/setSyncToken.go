@@ -0,0 +1,25 @@
+package activities
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+type SetSyncTokenArgs struct {
+	CalendarID   string
+	SingleEvents bool
+	Token        string
+}
+
+type SetSyncTokenResult struct{}
+
+func (a Activities) SetSyncToken(ctx context.Context, args SetSyncTokenArgs) (SetSyncTokenResult, error) {
+	ctx = setupLogger(ctx, "SetSyncToken")
+
+	if err := a.ctr.Database.SetSyncToken(ctx, args.CalendarID, args.SingleEvents, args.Token); err != nil {
+		return SetSyncTokenResult{}, errors.Wrap(err, "failed to store sync token")
+	}
+
+	return SetSyncTokenResult{}, nil
+}
@@ -0,0 +1,18 @@
+package activities
+
+import (
+	"context"
+)
+
+type SupportsRecurrenceArgs struct {
+	CalendarID string
+}
+
+type SupportsRecurrenceResult struct {
+	Supported bool
+}
+
+// SupportsRecurrence reports whether a calendar can store series with their modified and cancelled instances.
+func (a Activities) SupportsRecurrence(_ context.Context, args SupportsRecurrenceArgs) (SupportsRecurrenceResult, error) {
+	return SupportsRecurrenceResult{Supported: a.ctr.Provider.SupportsRecurrence(args.CalendarID)}, nil
+}
@@ -11,6 +11,8 @@ type UpdateCalendarItemArgs struct {
 	CalendarID     string
 	CalendarItemID string
 	Patch          *calendar.Event
+	// Etag is the version of the event the patch is based on, see providers.ErrPreconditionFailed.
+	Etag string
 }
 
 type UpdateCalendarItemResult struct{}
@@ -20,12 +22,9 @@ func (a Activities) UpdateCalendarItem(ctx context.Context, args UpdateCalendarI
 
 	var result UpdateCalendarItemResult
 
-	client, err := a.ctr.GetCalendarClient(ctx)
-	if err != nil {
-		return result, errors.Wrap(err, "failed to create client")
-	}
-
-	if _, err := client.Events.Patch(args.CalendarID, args.CalendarItemID, args.Patch).Context(ctx).Do(); err != nil {
+	patch := *args.Patch
+	patch.Etag = args.Etag
+	if _, err := a.ctr.Provider.PatchEvent(ctx, args.CalendarID, args.CalendarItemID, &patch); err != nil {
 		return result, errors.Wrap(err, "failed to patch event")
 	}
 
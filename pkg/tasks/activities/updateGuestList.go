@@ -2,16 +2,31 @@ package activities
 
 import (
 	"context"
+	"slices"
+	"strings"
 
 	"github.com/pkg/errors"
 	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/persistence"
 )
 
+// InviteGuestArgs changes an event's guest list in a single patch, so guests who are added and removed at the same
+// time don't overwrite each other's changes.
 type InviteGuestArgs struct {
-	CalendarID           string
-	CalendarItemID       string
-	Attendees            []*calendar.EventAttendee
-	EmailAddressToInvite string
+	// InviteID is the invite config the guests are recorded for, so they can be removed again.
+	InviteID       int
+	CalendarID     string
+	CalendarItemID string
+	// Etag is the version of the event Attendees were read from, see providers.ErrPreconditionFailed.
+	Etag                   string
+	Attendees              []*calendar.EventAttendee
+	EmailAddressesToInvite []string
+	// EmailAddressesToRemove are guests the invite config added before.
+	EmailAddressesToRemove []string
+	Optional               bool
+	// SendUpdates is who is notified of the invitation, see persistence.SendUpdates.
+	SendUpdates string
 }
 
 type InviteGuestResult struct {
@@ -22,20 +37,43 @@ func (a Activities) UpdateGuestList(ctx context.Context, args InviteGuestArgs) (
 
 	var result InviteGuestResult
 
-	client, err := a.ctr.GetCalendarClient(ctx)
-	if err != nil {
-		return result, errors.Wrap(err, "failed to create client")
-	}
-
-	patch := client.Events.Patch(args.CalendarID, args.CalendarItemID, &calendar.Event{
-		Attendees: append(args.Attendees, &calendar.EventAttendee{
-			AdditionalGuests: 1,
-			Email:            args.EmailAddressToInvite,
-		}),
+	attendees := slices.DeleteFunc(slices.Clone(args.Attendees), func(attendee *calendar.EventAttendee) bool {
+		return slices.ContainsFunc(args.EmailAddressesToRemove, func(email string) bool { return strings.EqualFold(email, attendee.Email) })
 	})
-	if _, err = patch.Context(ctx).Do(); err != nil {
+	for _, email := range args.EmailAddressesToInvite {
+		attendees = append(attendees, &calendar.EventAttendee{
+			AdditionalGuests: 1,
+			Email:            email,
+			Optional:         args.Optional,
+		})
+	}
+	if _, err := a.ctr.Provider.PatchAttendees(ctx, args.CalendarID, args.CalendarItemID, args.Etag, attendees, args.SendUpdates); err != nil {
 		return result, errors.Wrap(err, "failed to patch event")
 	}
 
+	if args.InviteID == 0 {
+		return result, nil
+	}
+
+	for _, email := range args.EmailAddressesToInvite {
+		if err := a.ctr.Database.CreateInvitedGuest(ctx, args.guest(email)); err != nil {
+			return result, errors.Wrap(err, "failed to record invited guest")
+		}
+	}
+	for _, email := range args.EmailAddressesToRemove {
+		if err := a.ctr.Database.DeleteInvitedGuest(ctx, args.guest(email)); err != nil {
+			return result, errors.Wrap(err, "failed to forget invited guest")
+		}
+	}
+
 	return result, nil
 }
+
+func (args InviteGuestArgs) guest(email string) persistence.InvitedGuest {
+	return persistence.InvitedGuest{
+		InviteID:     args.InviteID,
+		CalendarID:   args.CalendarID,
+		EventID:      args.CalendarItemID,
+		EmailAddress: email,
+	}
+}
@@ -2,11 +2,11 @@ package activities
 
 import (
 	"context"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
-	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/providers"
 )
 
 type WatchCalendarArgs struct {
@@ -22,31 +22,20 @@ func (a Activities) WatchCalendar(ctx context.Context, args WatchCalendarArgs) (
 
 	var result WatchCalendarResult
 
-	client, err := a.ctr.GetCalendarClient(ctx)
-	if err != nil {
-		return result, errors.Wrap(err, "failed to create client")
-	}
-
-	channel := &calendar.Channel{
-		Id:      uuid.NewString(),
-		Type:    "web_hook",
+	channel, err := a.ctr.Provider.Watch(ctx, args.CalendarID, providers.Channel{
+		ID:      uuid.NewString(),
 		Address: a.ctr.Config.WebhookUrl,
 		Token:   uuid.NewString(),
-	}
-
-	if channel, err = client.Events.Watch(args.CalendarID, channel).Context(ctx).Do(); err != nil {
+	})
+	if err != nil {
 		return result, errors.Wrap(err, "failed to watch events")
 	}
 
-	if err := a.ctr.Database.CreateWatchConfig(ctx, args.CalendarID, channel.Id, channel.Token, fromTimestamp(channel.Expiration)); err != nil {
+	if err := a.ctr.Database.CreateWatchConfig(ctx, args.CalendarID, channel.ID, channel.Token, channel.Expiration); err != nil {
 		return result, errors.Wrap(err, "failed to write row")
 	}
 
-	result.WatchID = channel.Id
+	result.WatchID = channel.ID
 
 	return result, nil
 }
-
-func fromTimestamp(timestamp int64) time.Time {
-	return time.UnixMilli(timestamp)
-}
@@ -0,0 +1,156 @@
+// Package queue runs jobs which are stored in the database, so they survive restarts and are retried when they fail.
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/persistence/sqlite"
+)
+
+const (
+	// MaxAttempts is how often a job runs before it's marked dead.
+	MaxAttempts = 10
+
+	minBackoff   = 30 * time.Second
+	maxBackoff   = time.Hour
+	pollInterval = time.Second
+)
+
+// Handler runs a job with its JSON encoded arguments.
+type Handler func(ctx context.Context, args json.RawMessage) error
+
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+// Permanent marks an error which retrying won't fix, the job is marked dead right away.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+type Queue struct {
+	db       *sqlite.Database
+	handlers map[string]Handler
+	now      func() time.Time
+}
+
+// New creates a queue which runs jobs with the handler registered for their kind.
+func New(db *sqlite.Database, handlers map[string]Handler) *Queue {
+	return &Queue{db: db, handlers: handlers, now: time.Now}
+}
+
+// Run runs due jobs with a pool of workers until ctx is done. Jobs which were running when the process stopped are run
+// again.
+func (q *Queue) Run(ctx context.Context, workers int) error {
+	if err := q.db.ResetRunningJobs(ctx); err != nil {
+		return errors.Wrap(err, "failed to reset interrupted jobs")
+	}
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for {
+		ran, err := q.runNext(ctx)
+		if err != nil && ctx.Err() != nil {
+			// the database calls failed because of the shutdown
+			return
+		}
+		if err != nil {
+			logs.GetLogger(ctx).Error().Err(err).Msg("failed to run job")
+		}
+		if ran && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// runNext runs the job which is due the longest, it returns false if no job is due.
+func (q *Queue) runNext(ctx context.Context) (bool, error) {
+	job, err := q.db.ClaimJob(ctx, q.now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "failed to claim job")
+	}
+
+	log := logs.GetLogger(ctx).With().
+		Int("job-id", job.ID).
+		Str("job-kind", job.Kind).
+		Int("attempt", job.Attempts).
+		Logger()
+	ctx = logs.SetLogger(ctx, log)
+
+	err = q.run(ctx, job)
+	if err == nil {
+		return true, errors.Wrap(q.db.DeleteJob(ctx, job.ID), "failed to delete finished job")
+	}
+
+	// jobs interrupted by a shutdown stay running, and are run again on the next start
+	if ctx.Err() != nil {
+		return true, nil
+	}
+
+	var permanent permanentError
+	if errors.As(err, &permanent) || job.Attempts >= MaxAttempts {
+		log.Error().Err(err).Msg("job failed for good")
+		return true, errors.Wrap(q.db.MarkJobDead(ctx, job.ID, err.Error()), "failed to mark job as dead")
+	}
+
+	runAt := q.now().Add(Backoff(job.Attempts))
+	log.Warn().Err(err).Time("retry-at", runAt).Msg("job failed, retrying later")
+	return true, errors.Wrap(q.db.RetryJob(ctx, job.ID, runAt, err.Error()), "failed to retry job")
+}
+
+func (q *Queue) run(ctx context.Context, job persistence.Job) (err error) {
+	handler, ok := q.handlers[job.Kind]
+	if !ok {
+		return Permanent(errors.Errorf("unknown job kind %q", job.Kind))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, json.RawMessage(job.Args))
+}
+
+// Backoff is how long a job waits after its nth failed attempt, doubling from 30 seconds up to an hour.
+func Backoff(attempts int) time.Duration {
+	backoff := minBackoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
@@ -0,0 +1,122 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/persistence/sqlite"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 30*time.Second, Backoff(1))
+	assert.Equal(t, time.Minute, Backoff(2))
+	assert.Equal(t, 4*time.Minute, Backoff(4))
+	assert.Equal(t, time.Hour, Backoff(MaxAttempts))
+}
+
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	db, err := sqlite.NewDatabase(ctx, pkg.Config{
+		DatabaseDriver: "sqlite3",
+		DatabaseSource: filepath.Join(t.TempDir(), "test.db"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	var calls []string
+	q := New(db, map[string]Handler{
+		"flaky": func(ctx context.Context, args json.RawMessage) error {
+			calls = append(calls, string(args))
+			if len(calls) == 1 {
+				return errors.New("try again")
+			}
+			return nil
+		},
+		"broken": func(ctx context.Context, args json.RawMessage) error {
+			return Permanent(errors.New("never works"))
+		},
+		"failing": func(ctx context.Context, args json.RawMessage) error {
+			return errors.New("doesn't work")
+		},
+	})
+	now := time.Now()
+	q.now = func() time.Time { return now }
+
+	enqueue := func(kind string) {
+		_, err := db.CreateJob(ctx, persistence.Job{Kind: kind, Args: `{"a":1}`, IdempotencyKey: kind, RunAt: now})
+		require.NoError(t, err)
+	}
+	failedJob := func(kind string) (persistence.Job, bool) {
+		jobs, err := db.GetFailedJobs(ctx, 10)
+		require.NoError(t, err)
+		for _, job := range jobs {
+			if job.Kind == kind {
+				return job, true
+			}
+		}
+		return persistence.Job{}, false
+	}
+
+	// failed jobs are retried after a backoff, and deleted once they succeed
+	enqueue("flaky")
+	ran, err := q.runNext(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	job, ok := failedJob("flaky")
+	require.True(t, ok)
+	assert.Equal(t, persistence.JobPending, job.State)
+	assert.Equal(t, "try again", job.LastError)
+	assert.WithinDuration(t, now.Add(Backoff(1)), job.RunAt, time.Second)
+
+	ran, err = q.runNext(ctx)
+	require.NoError(t, err)
+	assert.False(t, ran, "the retry isn't due yet")
+
+	now = now.Add(Backoff(1))
+	ran, err = q.runNext(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+	assert.Equal(t, []string{`{"a":1}`, `{"a":1}`}, calls)
+
+	_, ok = failedJob("flaky")
+	assert.False(t, ok, "finished jobs are deleted")
+
+	// permanent errors and unknown kinds aren't retried
+	for _, kind := range []string{"broken", "unknown"} {
+		enqueue(kind)
+		_, err = q.runNext(ctx)
+		require.NoError(t, err)
+
+		job, ok = failedJob(kind)
+		require.True(t, ok, kind)
+		assert.Equal(t, persistence.JobDead, job.State, kind)
+	}
+
+	// jobs which keep failing die eventually
+	enqueue("failing")
+	for range MaxAttempts {
+		ran, err = q.runNext(ctx)
+		require.NoError(t, err)
+		require.True(t, ran)
+		now = now.Add(maxBackoff)
+	}
+
+	job, ok = failedJob("failing")
+	require.True(t, ok)
+	assert.Equal(t, persistence.JobDead, job.State)
+	assert.Equal(t, MaxAttempts, job.Attempts)
+}
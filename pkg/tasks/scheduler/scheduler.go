@@ -0,0 +1,254 @@
+// Package scheduler runs jobs on cron expressions or intervals. Their state is kept in the database, so schedules carry
+// over restarts and jobs can be triggered from the dashboard.
+package scheduler
+
+import (
+	"context"
+	"maps"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/persistence/sqlite"
+)
+
+// names of the scheduled jobs.
+const (
+	JobCopyAll      = "copy-all"
+	JobInviteAll    = "invite-all"
+	JobWatchRenewal = "watch-renewal"
+	JobCleanup      = "cleanup"
+)
+
+// Off is the spec of jobs which don't run.
+const Off = "off"
+
+const pollInterval = 5 * time.Second
+
+// DefaultSpecs are the schedules of the jobs which aren't configured.
+var DefaultSpecs = map[string]string{
+	JobCopyAll:      "@every 1h",
+	JobInviteAll:    "@every 1h",
+	JobWatchRenewal: "@every 1h",
+	JobCleanup:      "@daily",
+}
+
+// Specs applies configured specs on top of DefaultSpecs, and leaves out the jobs which are turned off.
+func Specs(configured map[string]string) map[string]string {
+	specs := maps.Clone(DefaultSpecs)
+	maps.Copy(specs, configured)
+	maps.DeleteFunc(specs, func(_, spec string) bool { return spec == Off })
+	return specs
+}
+
+// Parse parses a cron expression with five fields, or a descriptor like "@hourly" or "@every 30m".
+func Parse(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse schedule %q", spec)
+	}
+	return schedule, nil
+}
+
+type Job struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type scheduledJob struct {
+	Job
+	spec     string
+	schedule cron.Schedule
+}
+
+type Scheduler struct {
+	db     *sqlite.Database
+	jobs   map[string]scheduledJob
+	jitter time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	running map[string]struct{}
+	wg      sync.WaitGroup
+}
+
+// New schedules jobs with specs by their name, jobs without a spec don't run. Jitter delays every run by up to that
+// long, so jobs with the same schedule don't all start at once.
+func New(db *sqlite.Database, specs map[string]string, jitter time.Duration, jobs ...Job) (*Scheduler, error) {
+	byName := pkg.ToMap(jobs, func(job Job) string { return job.Name })
+
+	s := &Scheduler{
+		db:      db,
+		jobs:    map[string]scheduledJob{},
+		jitter:  jitter,
+		now:     time.Now,
+		running: map[string]struct{}{},
+	}
+
+	for name, spec := range specs {
+		job, ok := byName[name]
+		if !ok {
+			return nil, errors.Errorf("unknown job %q", name)
+		}
+
+		schedule, err := Parse(spec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid schedule of %q", name)
+		}
+
+		s.jobs[name] = scheduledJob{Job: job, spec: spec, schedule: schedule}
+	}
+
+	return s, nil
+}
+
+// Run runs the jobs when they're due until ctx is done, and waits for the running ones to finish.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if err := s.init(ctx); err != nil {
+		return err
+	}
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			return nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// init forgets the jobs which aren't scheduled anymore, and schedules the ones which are new or have a new spec. New
+// jobs run right away, as do jobs which were due or running while the process was down.
+func (s *Scheduler) init(ctx context.Context) error {
+	states, err := s.db.GetScheduledJobs(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get scheduled jobs")
+	}
+
+	statesByName := pkg.ToMap(states, func(state persistence.ScheduledJob) string { return state.Name })
+	for name := range statesByName {
+		if _, ok := s.jobs[name]; ok {
+			continue
+		}
+		if err := s.db.DeleteScheduledJob(ctx, name); err != nil {
+			return errors.Wrapf(err, "failed to delete scheduled job %q", name)
+		}
+	}
+
+	for name, job := range s.jobs {
+		state, ok := statesByName[name]
+		if !ok {
+			state = persistence.ScheduledJob{Name: name, Spec: job.spec, NextRunAt: s.now()}
+		}
+		if state.Spec != job.spec {
+			state.Spec = job.spec
+			state.NextRunAt = s.next(job, s.now())
+		}
+		// runs which were interrupted are over, and run again
+		if state.Running {
+			state.Running = false
+			state.NextRunAt = s.now()
+		}
+
+		if err := s.db.SaveScheduledJob(ctx, state); err != nil {
+			return errors.Wrapf(err, "failed to save scheduled job %q", name)
+		}
+	}
+
+	return nil
+}
+
+// tick starts the jobs which are due. Their state is read every time, since jobs are triggered through the database.
+func (s *Scheduler) tick(ctx context.Context) {
+	states, err := s.db.GetScheduledJobs(ctx)
+	if err != nil {
+		logs.GetLogger(ctx).Error().Err(err).Msg("failed to get scheduled jobs")
+		return
+	}
+
+	for _, state := range states {
+		job, ok := s.jobs[state.Name]
+		if !ok || state.NextRunAt.After(s.now()) {
+			continue
+		}
+
+		s.start(ctx, job, state)
+	}
+}
+
+// start runs a job in the background, unless it's still running.
+func (s *Scheduler) start(ctx context.Context, job scheduledJob, state persistence.ScheduledJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.running[job.Name]; ok {
+		return
+	}
+	s.running[job.Name] = struct{}{}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(ctx, job, state)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.running, job.Name)
+	}()
+}
+
+// run schedules the next run when the job starts, so a trigger while it runs isn't overwritten once it's done.
+func (s *Scheduler) run(ctx context.Context, job scheduledJob, state persistence.ScheduledJob) {
+	log := logs.GetLogger(ctx).With().Str("scheduled-job", job.Name).Logger()
+	ctx = logs.SetLogger(ctx, log)
+
+	started := s.now()
+	state.Running = true
+	state.NextRunAt = s.next(job, started)
+	if err := s.db.SaveScheduledJob(ctx, state); err != nil {
+		log.Warn().Err(err).Msg("failed to save scheduled job")
+	}
+
+	log.Info().Msg("running scheduled job")
+	err := runJob(ctx, job.Job)
+
+	state.Running = false
+	state.LastRunAt = started
+	state.LastDuration = s.now().Sub(started)
+	state.LastError = ""
+	if err != nil {
+		log.Error().Err(err).Msg("scheduled job failed")
+		state.LastError = err.Error()
+	}
+
+	if err := s.db.FinishScheduledJob(ctx, state); err != nil {
+		log.Error().Err(err).Msg("failed to save scheduled job")
+	}
+}
+
+func runJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return job.Run(ctx)
+}
+
+func (s *Scheduler) next(job scheduledJob, after time.Time) time.Time {
+	next := job.schedule.Next(after)
+	if s.jitter > 0 {
+		next = next.Add(rand.N(s.jitter))
+	}
+	return next
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/persistence/sqlite"
+)
+
+func TestSpecs(t *testing.T) {
+	t.Parallel()
+
+	specs := Specs(map[string]string{JobCopyAll: "*/15 * * * *", JobCleanup: Off})
+	assert.Equal(t, map[string]string{
+		JobCopyAll:      "*/15 * * * *",
+		JobInviteAll:    "@every 1h",
+		JobWatchRenewal: "@every 1h",
+	}, specs)
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	job := Job{Name: JobCopyAll, Run: func(ctx context.Context) error { return nil }}
+
+	_, err := New(nil, map[string]string{JobCopyAll: "@every 1h"}, 0, job)
+	require.NoError(t, err)
+	_, err = New(nil, map[string]string{"unknown": "@every 1h"}, 0, job)
+	require.Error(t, err)
+	_, err = New(nil, map[string]string{JobCopyAll: "every hour"}, 0, job)
+	require.Error(t, err)
+}
+
+func TestScheduler(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	db, err := sqlite.NewDatabase(ctx, pkg.Config{
+		DatabaseDriver: "sqlite3",
+		DatabaseSource: filepath.Join(t.TempDir(), "test.db"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	var copies, cleanups atomic.Int32
+	release := make(chan struct{})
+	s, err := New(db, map[string]string{JobCopyAll: "@every 1h", JobCleanup: "@daily"}, 0,
+		Job{Name: JobCopyAll, Run: func(ctx context.Context) error {
+			copies.Add(1)
+			return errors.New("failed")
+		}},
+		Job{Name: JobCleanup, Run: func(ctx context.Context) error {
+			cleanups.Add(1)
+			<-release
+			return nil
+		}},
+	)
+	require.NoError(t, err)
+
+	state := func(name string) persistence.ScheduledJob {
+		jobs, err := db.GetScheduledJobs(ctx)
+		require.NoError(t, err)
+		for _, job := range jobs {
+			if job.Name == name {
+				return job
+			}
+		}
+		require.Failf(t, "missing scheduled job", name)
+		return persistence.ScheduledJob{}
+	}
+
+	// new jobs run right away
+	require.NoError(t, s.init(ctx))
+	s.tick(ctx)
+
+	require.Eventually(t, func() bool { return !state(JobCopyAll).LastRunAt.IsZero() }, time.Second, 10*time.Millisecond)
+	copyAll := state(JobCopyAll)
+	assert.Equal(t, "failed", copyAll.LastError)
+	assert.False(t, copyAll.Running)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), copyAll.NextRunAt, time.Second)
+	assert.True(t, state(JobCleanup).Running)
+
+	// jobs which aren't due don't run, and jobs which are still running don't run twice
+	require.NoError(t, db.TriggerScheduledJob(ctx, JobCleanup))
+	s.tick(ctx)
+	assert.EqualValues(t, 1, copies.Load())
+	assert.EqualValues(t, 1, cleanups.Load())
+
+	// triggered jobs run on the next tick
+	require.NoError(t, db.TriggerScheduledJob(ctx, JobCopyAll))
+	s.tick(ctx)
+	require.Eventually(t, func() bool { return copies.Load() == 2 }, time.Second, 10*time.Millisecond)
+
+	close(release)
+	s.wg.Wait()
+	cleanup := state(JobCleanup)
+	assert.False(t, cleanup.Running)
+	assert.Empty(t, cleanup.LastError)
+	assert.False(t, cleanup.NextRunAt.After(time.Now()), "the trigger while it ran is kept")
+
+	// jobs which aren't scheduled anymore are forgotten, and new specs apply right away
+	s, err = New(db, map[string]string{JobCopyAll: "@every 2h"}, time.Minute,
+		Job{Name: JobCopyAll, Run: func(ctx context.Context) error { return nil }})
+	require.NoError(t, err)
+	require.NoError(t, s.init(ctx))
+
+	jobs, err := db.GetScheduledJobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "@every 2h", jobs[0].Spec)
+	assert.WithinRange(t, jobs[0].NextRunAt, time.Now().Add(2*time.Hour-time.Second), time.Now().Add(2*time.Hour+time.Minute))
+}
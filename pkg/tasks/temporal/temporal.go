@@ -0,0 +1,138 @@
+// Package temporal runs the periodic workflows on a Temporal server instead of in-process, see pkg.Config.
+package temporal
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	temporalsdk "go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/worker"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/tasks/activities"
+	"calendar-sync/pkg/tasks/scheduler"
+	"calendar-sync/pkg/tasks/workflows"
+)
+
+// scheduledWorkflows are the workflows of the scheduled jobs, their schedules are named after the jobs.
+var scheduledWorkflows = map[string]any{
+	scheduler.JobCopyAll:      CopyAllWorkflow,
+	scheduler.JobInviteAll:    InviteAllWorkflow,
+	scheduler.JobWatchRenewal: WatchAllWorkflow,
+	scheduler.JobCleanup:      CleanupWorkflow,
+}
+
+// legacySchedules are the schedules of earlier versions, which would run the jobs a second time.
+var legacySchedules = []string{"hourly-sync-check", "hourly-invite-check", "hourly-webhook-check"}
+
+type registry interface {
+	worker.WorkflowRegistry
+	worker.ActivityRegistry
+}
+
+// Register registers the scheduled workflows, and the activities they run.
+func Register(r registry, w *workflows.Workflows, a *activities.Activities) {
+	for _, workflow := range scheduledWorkflows {
+		r.RegisterWorkflow(workflow)
+	}
+
+	r.RegisterActivity(a)
+	r.RegisterActivityWithOptions(w.CopyAllWorkflow, activity.RegisterOptions{Name: CopyAllActivity})
+	r.RegisterActivityWithOptions(w.InviteAllWorkflow, activity.RegisterOptions{Name: InviteAllActivity})
+	r.RegisterActivityWithOptions(w.WatchAll, activity.RegisterOptions{Name: WatchAllActivity})
+	r.RegisterActivityWithOptions(w.CleanupWorkflow, activity.RegisterOptions{Name: CleanupActivity})
+}
+
+// Run runs a worker and creates, updates or deletes the schedules of the scheduled jobs as configured, until ctx is
+// done.
+func Run(ctx context.Context, cfg pkg.Config, w *workflows.Workflows, a *activities.Activities) error {
+	specs := scheduler.Specs(cfg.Schedules)
+	for name, spec := range specs {
+		if _, ok := scheduledWorkflows[name]; !ok {
+			return errors.Errorf("unknown job %q", name)
+		}
+		if _, err := scheduler.Parse(spec); err != nil {
+			return errors.Wrapf(err, "invalid schedule of %q", name)
+		}
+	}
+
+	c, err := client.Dial(client.Options{
+		HostPort:  cfg.TemporalHostPort,
+		Namespace: cfg.TemporalNamespace,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to temporal")
+	}
+	defer c.Close()
+
+	wk := worker.New(c, cfg.TemporalTaskQueue, worker.Options{BackgroundActivityContext: ctx})
+	Register(wk, w, a)
+	if err := wk.Start(); err != nil {
+		return errors.Wrap(err, "failed to start worker")
+	}
+	defer wk.Stop()
+
+	for _, id := range legacySchedules {
+		if err := deleteSchedule(ctx, c, id); err != nil {
+			return errors.Wrapf(err, "failed to delete legacy schedule %q", id)
+		}
+	}
+
+	for name, workflow := range scheduledWorkflows {
+		spec, ok := specs[name]
+		if !ok {
+			if err := deleteSchedule(ctx, c, name); err != nil {
+				return errors.Wrapf(err, "failed to unschedule %q", name)
+			}
+			continue
+		}
+
+		scheduleSpec := client.ScheduleSpec{CronExpressions: []string{spec}, Jitter: cfg.ScheduleJitter}
+		if err := createSchedule(ctx, c, cfg.TemporalTaskQueue, name, workflow, scheduleSpec); err != nil {
+			return errors.Wrapf(err, "failed to schedule %q", name)
+		}
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+// createSchedule creates a schedule, or updates its spec if it exists already. Schedules run once right after they
+// were created.
+func createSchedule(ctx context.Context, c client.Client, taskQueue, id string, workflow any, spec client.ScheduleSpec) error {
+	_, err := c.ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID:   id,
+		Spec: spec,
+		Action: &client.ScheduleWorkflowAction{
+			ID:        id,
+			Workflow:  workflow,
+			TaskQueue: taskQueue,
+		},
+		TriggerImmediately: true,
+	})
+	if !errors.Is(err, temporalsdk.ErrScheduleAlreadyRunning) {
+		return err
+	}
+
+	logs.GetLogger(ctx).Info().Str("schedule-id", id).Msg("updating existing schedule")
+	return c.ScheduleClient().GetHandle(ctx, id).Update(ctx, client.ScheduleUpdateOptions{
+		DoUpdate: func(input client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+			input.Description.Schedule.Spec = &spec
+			return &client.ScheduleUpdate{Schedule: &input.Description.Schedule}, nil
+		},
+	})
+}
+
+// deleteSchedule deletes the schedule of a job which was turned off or renamed, if there is one.
+func deleteSchedule(ctx context.Context, c client.Client, id string) error {
+	err := c.ScheduleClient().GetHandle(ctx, id).Delete(ctx)
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package temporal
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/container"
+	"calendar-sync/pkg/persistence/sqlite"
+	"calendar-sync/pkg/providers"
+	"calendar-sync/pkg/providers/memory"
+	"calendar-sync/pkg/tasks/activities"
+	"calendar-sync/pkg/tasks/workflows"
+)
+
+func newTestEnvironment(t *testing.T) (*testsuite.TestWorkflowEnvironment, *sqlite.Database) {
+	t.Helper()
+
+	provider := memory.New()
+	provider.AddCalendar(providers.CalendarInfo{ID: "source", Summary: "Source"})
+	provider.AddCalendar(providers.CalendarInfo{ID: "destination", Summary: "Destination"})
+
+	db, err := sqlite.NewDatabase(t.Context(), pkg.Config{
+		DatabaseDriver: "sqlite3",
+		DatabaseSource: filepath.Join(t.TempDir(), "test.db"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	a := activities.New(container.Container{Database: db, Provider: provider})
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	Register(env, workflows.New(a), a)
+
+	return env, db
+}
+
+func TestCopyAllWorkflow(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	env, db := newTestEnvironment(t)
+	require.NoError(t, db.CreateCopyConfig(ctx, "source", "destination"))
+
+	env.ExecuteWorkflow(CopyAllWorkflow)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	job, err := db.ClaimJob(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, workflows.JobCopyCalendar, job.Kind)
+}
+
+func TestWatchAllWorkflowRetries(t *testing.T) {
+	t.Parallel()
+
+	env, _ := newTestEnvironment(t)
+
+	var attempts int
+	env.OnActivity(WatchAllActivity, mock.Anything).Return(func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("temporarily broken")
+		}
+		return nil
+	})
+
+	env.ExecuteWorkflow(WatchAllWorkflow)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	assert.Equal(t, 3, attempts)
+}
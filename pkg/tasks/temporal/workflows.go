@@ -0,0 +1,46 @@
+package temporal
+
+import (
+	"time"
+
+	temporalsdk "go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// names of the in-process workflows, which Temporal runs as activities since they call providers and the database
+// directly instead of being deterministic.
+const (
+	CopyAllActivity   = "CopyAll"
+	InviteAllActivity = "InviteAll"
+	WatchAllActivity  = "WatchAll"
+	CleanupActivity   = "Cleanup"
+)
+
+var activityOptions = workflow.ActivityOptions{
+	StartToCloseTimeout: time.Hour,
+	RetryPolicy: &temporalsdk.RetryPolicy{
+		InitialInterval: 30 * time.Second,
+		MaximumAttempts: 5,
+	},
+}
+
+func CopyAllWorkflow(ctx workflow.Context) error {
+	return executeActivity(ctx, CopyAllActivity)
+}
+
+func InviteAllWorkflow(ctx workflow.Context) error {
+	return executeActivity(ctx, InviteAllActivity)
+}
+
+func WatchAllWorkflow(ctx workflow.Context) error {
+	return executeActivity(ctx, WatchAllActivity)
+}
+
+func CleanupWorkflow(ctx workflow.Context) error {
+	return executeActivity(ctx, CleanupActivity)
+}
+
+func executeActivity(ctx workflow.Context, name string, args ...any) error {
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+	return workflow.ExecuteActivity(ctx, name, args...).Get(ctx, nil)
+}
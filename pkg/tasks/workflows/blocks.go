@@ -0,0 +1,196 @@
+package workflows
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/logs"
+)
+
+// maxPropertyLength is the longest value google allows for an extended property.
+const maxPropertyLength = 1024
+
+// block is a stretch of busy time, made of source events which overlap or are at most the gap apart.
+type block struct {
+	start, end time.Time
+	sourceIDs  []string
+}
+
+// blocks merges the source's events into blocks of busy time. Free events don't make anyone busy, and all day events
+// are only merged once they're converted to timed ones.
+func (args CopyCalendarWorkflowArgs) blocks(sourceItems []*calendar.Event) []block {
+	var spans []block
+	for _, item := range sourceItems {
+		if item.Transparency == "transparent" {
+			continue
+		}
+
+		e := args.pad(item, args.convertTimes(*item))
+		if e.Start == nil || e.End == nil || e.Start.DateTime == "" || e.End.DateTime == "" {
+			continue
+		}
+		start, err := time.Parse(time.RFC3339, e.Start.DateTime)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse(time.RFC3339, e.End.DateTime)
+		if err != nil {
+			continue
+		}
+
+		spans = append(spans, block{start: start, end: end, sourceIDs: []string{item.Id}})
+	}
+
+	slices.SortFunc(spans, func(a, b block) int {
+		if c := a.start.Compare(b.start); c != 0 {
+			return c
+		}
+		return a.end.Compare(b.end)
+	})
+
+	var blocks []block
+	for _, span := range spans {
+		if n := len(blocks); n > 0 && !span.start.After(blocks[n-1].end.Add(args.MergeGap)) {
+			last := &blocks[n-1]
+			if span.end.After(last.end) {
+				last.end = span.end
+			}
+			last.sourceIDs = append(last.sourceIDs, span.sourceIDs...)
+			continue
+		}
+
+		blocks = append(blocks, span)
+	}
+
+	for i := range blocks {
+		slices.Sort(blocks[i].sourceIDs)
+	}
+
+	return blocks
+}
+
+// blockEvent is what the copy of a block looks like. Blocks of very many events only list as many of them as fit into
+// an extended property, which is still enough to match them up.
+func (args CopyCalendarWorkflowArgs) blockEvent(b block) *calendar.Event {
+	ids := strings.Join(b.sourceIDs, ",")
+	for len(ids) > maxPropertyLength {
+		idx := strings.LastIndex(ids[:maxPropertyLength+1], ",")
+		if idx < 0 {
+			idx = maxPropertyLength
+		}
+		ids = ids[:idx]
+	}
+
+	e := args.override(calendar.Event{
+		Summary: args.busyTitle(),
+		Start:   &calendar.EventDateTime{DateTime: b.start.Format(time.RFC3339)},
+		End:     &calendar.EventDateTime{DateTime: b.end.Format(time.RFC3339)},
+		Status:  "confirmed",
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{
+				pkg.SourceCalendarIDKey:    args.SourceCalendarID,
+				pkg.MergedSourceItemIDsKey: ids,
+			},
+		},
+	})
+	cleanEvent(&e)
+
+	return &e
+}
+
+func isBlock(e *calendar.Event) bool {
+	return getExtraByKey(e, pkg.MergedSourceItemIDsKey) != ""
+}
+
+func blockSourceIDs(e *calendar.Event) []string {
+	return strings.Split(getExtraByKey(e, pkg.MergedSourceItemIDsKey), ",")
+}
+
+// syncBlocks copies the source's events as blocks of busy time. Existing blocks are matched up with the new ones by
+// the source events they share, so blocks which merge or split are updated rather than replaced. Copies of single
+// events are removed, since the blocks take their place.
+func (w *Workflows) syncBlocks(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, sourceItems []*calendar.Event, destination destinationCopies) {
+	log := logs.GetLogger(ctx)
+
+	var wg sync.WaitGroup
+	run := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+
+	existing := slices.Clone(destination.blocks)
+	for _, b := range args.blocks(sourceItems) {
+		want := args.blockEvent(b)
+		sourceIDs := strings.Join(b.sourceIDs, ", ")
+
+		idx := matchBlock(existing, b.sourceIDs)
+		if idx < 0 {
+			run(func() { w.insertCopy(ctx, args, plan, sourceIDs, want) })
+			continue
+		}
+
+		destItem := existing[idx]
+		existing[idx] = nil
+
+		patch, diffs := diffEvents(*log, *want, *destItem, args.optionalFields()...)
+		if wantIDs, haveIDs := getExtraByKey(want, pkg.MergedSourceItemIDsKey), getExtraByKey(destItem, pkg.MergedSourceItemIDsKey); wantIDs != haveIDs {
+			if patch == nil {
+				patch = &calendar.Event{}
+			}
+			patch.ExtendedProperties = want.ExtendedProperties
+			diffs = append(diffs, FieldDiff{Field: "merged_events", Source: wantIDs, Destination: haveIDs})
+		}
+		if patch != nil {
+			run(func() { w.patchCopy(ctx, args, plan, sourceIDs, destItem, patch, diffs) })
+		}
+	}
+
+	var leftover []*calendar.Event
+	for _, destItem := range existing {
+		if destItem != nil {
+			leftover = append(leftover, destItem)
+		}
+	}
+	for _, destItem := range destination.bySourceItemID {
+		leftover = append(leftover, destItem)
+	}
+	for _, buffers := range destination.buffers {
+		leftover = append(leftover, buffers...)
+	}
+	for _, destItem := range leftover {
+		run(func() { w.removeCopy(ctx, args, plan, destItem) })
+	}
+
+	wg.Wait()
+}
+
+// matchBlock returns the index of the block which shares the most source events, or -1 if none shares any.
+func matchBlock(blocks []*calendar.Event, sourceIDs []string) int {
+	best, bestShared := -1, 0
+	for idx, destItem := range blocks {
+		if destItem == nil {
+			continue
+		}
+
+		var shared int
+		for _, id := range blockSourceIDs(destItem) {
+			if slices.Contains(sourceIDs, id) {
+				shared++
+			}
+		}
+		if shared > bestShared {
+			best, bestShared = idx, shared
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,63 @@
+package workflows
+
+import (
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/filters"
+	"calendar-sync/pkg/persistence"
+)
+
+// filter combines the copy's filter rules with its chain mode.
+func (args CopyCalendarWorkflowArgs) filter() (filters.Filter, error) {
+	f, err := filters.New(args.Filters)
+	if err != nil {
+		return f, errors.Wrap(err, "failed to build filter")
+	}
+
+	return f.Exclude(args.isExcludedCopy), nil
+}
+
+// isExcludedCopy stops copies of copies from being copied back to where they came from, which would copy them again
+// and again.
+func (args CopyCalendarWorkflowArgs) isExcludedCopy(e *calendar.Event) bool {
+	originCalendarID, _ := originOf(e)
+	if originCalendarID == "" {
+		return false
+	}
+
+	if args.ChainMode == persistence.ChainSkip {
+		return true
+	}
+
+	return originCalendarID == args.DestinationCalendarID
+}
+
+// originOf returns the event a copy was first copied from, or nothing for events which aren't copies.
+func originOf(e *calendar.Event) (string, string) {
+	if calendarID := getExtraByKey(e, pkg.OriginCalendarIDKey); calendarID != "" {
+		return calendarID, getExtraByKey(e, pkg.OriginCalendarItemIDKey)
+	}
+
+	return getExtraByKey(e, pkg.SourceCalendarIDKey), getExtraByKey(e, pkg.SourceCalendarItemIDKey)
+}
+
+// copyProperties links a copy to its source event, and to the original event if the source is a copy too.
+func copyProperties(args CopyCalendarWorkflowArgs, source *calendar.Event) map[string]string {
+	properties := map[string]string{
+		pkg.SourceCalendarIDKey:     args.SourceCalendarID,
+		pkg.SourceCalendarItemIDKey: source.Id,
+	}
+
+	if originCalendarID, originItemID := originOf(source); originCalendarID != "" {
+		properties[pkg.OriginCalendarIDKey] = originCalendarID
+		properties[pkg.OriginCalendarItemIDKey] = originItemID
+	}
+
+	if args.Bidirectional {
+		properties[pkg.SyncedFingerprintKey] = fingerprint(source)
+	}
+
+	return properties
+}
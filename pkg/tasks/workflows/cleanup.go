@@ -0,0 +1,19 @@
+package workflows
+
+import (
+	"context"
+	"time"
+
+	"calendar-sync/pkg/tasks/activities"
+)
+
+// historyRetention is how long conflicts and dead jobs are shown on the dashboard.
+const historyRetention = 30 * 24 * time.Hour
+
+// CleanupWorkflow deletes old conflicts and dead jobs.
+func (w *Workflows) CleanupWorkflow(ctx context.Context) error {
+	ctx, _ = setupLogger(ctx, "CleanupWorkflow")
+
+	_, err := w.a.PruneHistory(ctx, activities.PruneHistoryArgs{Before: time.Now().Add(-historyRetention)})
+	return err
+}
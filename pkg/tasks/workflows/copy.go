@@ -1,129 +1,639 @@
 package workflows
 
 import (
+	"cmp"
 	"context"
+	"slices"
 	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"google.golang.org/api/calendar/v3"
 
 	"calendar-sync/pkg"
+	"calendar-sync/pkg/filters"
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/providers"
 	"calendar-sync/pkg/tasks/activities"
 )
 
 type CopyCalendarWorkflowArgs struct {
+	CopyID                int
 	SourceCalendarID      string
 	DestinationCalendarID string
+
+	// LookBack and LookAhead define the window of events to copy, relative to now. LookAhead defaults to
+	// activities.SearchWindow.
+	LookBack  time.Duration
+	LookAhead time.Duration
+
+	// Privacy defaults to persistence.PrivacyFull, and BusyTitle to "Busy".
+	Privacy   persistence.PrivacyMode
+	BusyTitle string
+
+	Filters []persistence.FilterRule
+
+	Bidirectional  bool
+	ConflictPolicy persistence.ConflictPolicy
+
+	// ChainMode defaults to persistence.ChainTransitive.
+	ChainMode persistence.ChainMode
+
+	// SummaryTemplate and DescriptionTemplate generate the text of copies, see TemplateData.
+	SummaryTemplate     string
+	DescriptionTemplate string
+
+	// Fields are the optional parts of events which are copied, unless ColorID or NoReminders override them.
+	Fields      []persistence.CopyField
+	ColorID     string
+	NoReminders bool
+
+	// Recurrence defaults to persistence.RecurrenceSeries.
+	Recurrence persistence.RecurrenceMode
+
+	// AllDay defaults to persistence.AllDayKeep, and TimeZone to UTC.
+	AllDay   persistence.AllDayMode
+	TimeZone string
+
+	// PadBefore and PadAfter pad copies, either by extending them or with separate buffer events as PadMode says.
+	PadBefore       time.Duration
+	PadAfter        time.Duration
+	PadLocationOnly bool
+	PadMode         persistence.PadMode
+
+	// MergeBusy copies blocks of busy time instead of events, see syncBlocks.
+	MergeBusy bool
+	MergeGap  time.Duration
+
+	// Label prefixes the titles of copies without a summary template, and Deduplicate skips events which copies from
+	// other sources already cover.
+	Label       string
+	Deduplicate bool
+
+	// DryRun works out the changes without making them.
+	DryRun bool
+
+	// seriesUnsupported is set by withDestination when the destination can't store series.
+	seriesUnsupported bool
+}
+
+type CopyCalendarWorkflowResult struct {
+	Changes      []CopyChange `json:"changes"`
+	FailedWrites int          `json:"failedWrites,omitempty"`
+}
+
+type CopyAction string
+
+const (
+	CopyActionCreate CopyAction = "create"
+	CopyActionUpdate CopyAction = "update"
+	CopyActionDelete CopyAction = "delete"
+
+	// CopyActionUpdateSource copies an edit of a two-way copy back to its source. Its diffs go from the copy to the
+	// source.
+	CopyActionUpdateSource CopyAction = "update source"
+)
+
+// CopyChange is a change made to the destination calendar, or which would be made on a dry run.
+type CopyChange struct {
+	Action             CopyAction  `json:"action"`
+	SourceEventID      string      `json:"sourceEventId,omitempty"`
+	DestinationEventID string      `json:"destinationEventId,omitempty"`
+	Summary            string      `json:"summary"`
+	Start              string      `json:"start,omitempty"`
+	Diffs              []FieldDiff `json:"diffs,omitempty"`
 }
 
-func (w *Workflows) CopyCalendarWorkflow(ctx context.Context, args CopyCalendarWorkflowArgs) error {
-	ctx, log := setupLogger(ctx, "CopyCalendarWorkflow")
+// copyPlan collects the changes of a workflow run, which are made concurrently.
+type copyPlan struct {
+	mu      sync.Mutex
+	changes []CopyChange
+	// failedWrites counts the changes which couldn't be made, besides the ones given up because of concurrent edits.
+	failedWrites int
+}
+
+func (p *copyPlan) add(change CopyChange) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.changes = append(p.changes, change)
+}
+
+func (p *copyPlan) fail() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.failedWrites++
+}
+
+// sorted orders changes by action and start time, rather than the order the goroutines finished in.
+func (p *copyPlan) sorted() []CopyChange {
+	changes := slices.Clone(p.changes)
+	slices.SortFunc(changes, func(a, b CopyChange) int {
+		return cmp.Or(
+			cmp.Compare(a.Action, b.Action),
+			cmp.Compare(a.Start, b.Start),
+			cmp.Compare(a.Summary, b.Summary),
+			cmp.Compare(a.DestinationEventID, b.DestinationEventID),
+		)
+	})
+	return changes
+}
+
+func CopyCalendarWorkflowArgsFromConfig(config persistence.CopyConfig) CopyCalendarWorkflowArgs {
+	return CopyCalendarWorkflowArgs{
+		CopyID:                config.ID,
+		SourceCalendarID:      config.SourceID,
+		DestinationCalendarID: config.DestinationID,
+		LookBack:              config.LookBack,
+		LookAhead:             config.LookAhead,
+		Privacy:               config.Privacy,
+		BusyTitle:             config.BusyTitle,
+		Filters:               config.Filters,
+		Bidirectional:         config.Bidirectional,
+		ConflictPolicy:        config.ConflictPolicy,
+		ChainMode:             config.ChainMode,
+		SummaryTemplate:       config.SummaryTemplate,
+		DescriptionTemplate:   config.DescriptionTemplate,
+		Fields:                config.Fields,
+		ColorID:               config.ColorID,
+		NoReminders:           config.NoReminders,
+		Recurrence:            config.Recurrence,
+		AllDay:                config.AllDay,
+		TimeZone:              config.TimeZone,
+		PadBefore:             config.PadBefore,
+		PadAfter:              config.PadAfter,
+		PadLocationOnly:       config.PadLocationOnly,
+		PadMode:               config.PadMode,
+		MergeBusy:             config.MergeBusy,
+		MergeGap:              config.MergeGap,
+		Label:                 config.Label,
+		Deduplicate:           config.Deduplicate,
+	}
+}
+
+func (args CopyCalendarWorkflowArgs) window(now time.Time) (time.Time, time.Time) {
+	lookAhead := args.LookAhead
+	if lookAhead == 0 {
+		lookAhead = activities.SearchWindow
+	}
+
+	return now.Add(-args.LookBack), now.Add(lookAhead)
+}
+
+func (w *Workflows) CopyCalendarWorkflow(ctx context.Context, args CopyCalendarWorkflowArgs) (CopyCalendarWorkflowResult, error) {
+	ctx, _ = setupLogger(ctx, "CopyCalendarWorkflow")
+
+	var result CopyCalendarWorkflowResult
+
+	filter, err := args.filter()
+	if err != nil {
+		return result, err
+	}
+
+	args, err = w.withDestination(ctx, args)
+	if err != nil {
+		return result, err
+	}
+
+	// both calendars must be read with the same window, otherwise copies on the edge look orphaned
+	timeMin, timeMax := args.window(time.Now())
 
 	// get source events
-	sourceCalendarItems, err := w.getEvents(ctx, args.SourceCalendarID)
+	sourceResult, err := w.a.GetCalendarEventsActivity(ctx, args.listArgs(args.SourceCalendarID, timeMin, timeMax))
 	if err != nil {
-		return err
+		return result, err
 	}
-	sourceItemsByID := pkg.ToMap(sourceCalendarItems, func(item *calendar.Event) string { return item.Id })
+
+	// exceptions are copied onto the copies of their series once those exist. Instances whose series isn't listed,
+	// like those of feeds which are always expanded, are copied like single events.
+	listedByID := pkg.ToMap(sourceResult.Calendar.Items, func(item *calendar.Event) string { return item.Id })
+	isException := func(item *calendar.Event) bool {
+		_, ok := listedByID[item.RecurringEventId]
+		return ok && args.isException(item)
+	}
+	exceptions := pkg.Filter(sourceResult.Calendar.Items, isException)
+
+	sourceItems := pkg.Filter(sourceResult.Calendar.Items, func(item *calendar.Event) bool {
+		return !isException(item) && item.Status != "cancelled" && filter.Matches(item)
+	})
 
 	// get destination events
-	destinationCalendarItems, err := w.getEvents(ctx, args.DestinationCalendarID)
+	destination, err := w.getCopies(ctx, args, timeMin, timeMax)
 	if err != nil {
-		return err
+		return result, err
 	}
-	destinationCalendarItems = pkg.Filter(destinationCalendarItems, func(item *calendar.Event) bool {
-		return getExtraByKey(item, pkg.SourceCalendarIDKey) == args.SourceCalendarID
-	})
-	destinationItemsBySourceItemID := pkg.ToMap(destinationCalendarItems, func(item *calendar.Event) string { return getExtraByKey(item, pkg.SourceCalendarItemIDKey) })
 
-	// find missing destination events
+	var plan copyPlan
+	if args.MergeBusy {
+		w.syncBlocks(ctx, args, &plan, sourceItems, destination)
+	} else if err := w.syncEvents(ctx, args, &plan, filter, sourceItems, exceptions, destination, timeMin, timeMax); err != nil {
+		return result, err
+	}
+
+	result.Changes = plan.sorted()
+	result.FailedWrites = plan.failedWrites
+
+	if result.FailedWrites > 0 {
+		return result, errors.Errorf("failed to make %d of the copy's changes", result.FailedWrites)
+	}
+
+	return result, nil
+}
+
+// syncEvents brings the copies of the source's events up to date, one copy per event.
+func (w *Workflows) syncEvents(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, filter filters.Filter, sourceItems, exceptions []*calendar.Event, destination destinationCopies, timeMin, timeMax time.Time) error {
+	sourceItemsByID := pkg.ToMap(sourceItems, func(item *calendar.Event) string { return item.Id })
+	exceptionsByID := pkg.ToMap(exceptions, func(item *calendar.Event) string { return item.Id })
+
 	var wg sync.WaitGroup
 
+	// find missing destination events
 	for key, sourceItem := range sourceItemsByID {
-		if destItem, ok := destinationItemsBySourceItemID[key]; ok {
-			if patch := buildPatch(log, *sourceItem, *destItem); patch != nil {
-				updateArgs := activities.UpdateCalendarItemArgs{
-					CalendarID:     args.DestinationCalendarID,
-					CalendarItemID: destItem.Id,
-					Patch:          patch,
+		destItem, hasCopy := destination.bySourceItemID[key]
+
+		if args.copiedElsewhere(args.otherSources(destination.byDuplicateKey[duplicateKey(sourceItem)]), hasCopy) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if hasCopy {
+					w.removeCopy(ctx, args, plan, destItem)
 				}
-
-				wg.Add(1)
-				go func(args activities.UpdateCalendarItemArgs) {
-					defer wg.Done()
-					if _, err := w.a.UpdateCalendarItem(ctx, updateArgs); err != nil {
-						log.Error().Err(err).
-							Str("calendar-id", args.CalendarID).
-							Str("calendar-item-id", args.CalendarItemID).
-							Msg("failed to update calendar")
-					}
-				}(updateArgs)
-			}
+				w.syncBuffers(ctx, args, plan, nil, destination.buffers[key], timeMin, timeMax)
+			}()
 
 			continue
 		}
 
-		createArgs := activities.CreateCalendarItemArgs{
-			Event:      toInsert(args.SourceCalendarID, sourceItem),
-			CalendarID: args.DestinationCalendarID,
+		if hasCopy {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.syncCopy(ctx, args, plan, sourceItem, destItem)
+				w.syncBuffers(ctx, args, plan, sourceItem, destination.buffers[key], timeMin, timeMax)
+			}()
+
+			continue
 		}
+
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if _, err := w.a.CreateCalendarItem(ctx, createArgs); err != nil {
-				log.Error().
-					Err(err).
-					Str("source-calendar-id", args.SourceCalendarID).
-					Str("destination-calendar-id", args.DestinationCalendarID).
-					Msg("failed to create calendar item")
-			}
+			w.createCopy(ctx, args, plan, sourceItem)
+			w.syncBuffers(ctx, args, plan, sourceItem, destination.buffers[key], timeMin, timeMax)
 		}()
 	}
 
 	// find extra destination events
-	for key, destItem := range destinationItemsBySourceItemID {
+	for key, destItem := range destination.bySourceItemID {
 		if _, ok := sourceItemsByID[key]; ok {
 			continue
 		}
+		if _, ok := exceptionsByID[key]; ok {
+			continue
+		}
 
-		removeArgs := activities.RemoveCalendarItemArgs{
-			CalendarID: args.DestinationCalendarID,
-			EventID:    destItem.Id,
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.reconcileOrphanedCopy(ctx, args, plan, filter, key, destItem, destination.buffers[key], timeMin, timeMax)
+		}()
+	}
+
+	// buffers whose copy is gone
+	for key, buffers := range destination.buffers {
+		if _, ok := sourceItemsByID[key]; ok {
+			continue
+		}
+		if _, ok := destination.bySourceItemID[key]; ok {
+			continue
 		}
 
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			_, err := w.a.RemoveCalendarItem(ctx, removeArgs)
-			if err != nil {
-				log.Error().Err(err).
-					Str("event-id", destItem.Id).
-					Msg("failed to remove calendar item")
-			}
+			w.syncBuffers(ctx, args, plan, nil, buffers, timeMin, timeMax)
+		}()
+	}
+
+	// blocks left over from merging
+	for _, block := range destination.blocks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.removeCopy(ctx, args, plan, block)
 		}()
 	}
 
 	wg.Wait()
 
+	if len(exceptions) > 0 {
+		// the copies of new series have IDs now
+		destination, err := w.getCopies(ctx, args, timeMin, timeMax)
+		if err != nil {
+			return err
+		}
+
+		for _, exception := range exceptions {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.syncException(ctx, args, plan, filter, exception, destination.bySourceItemID)
+			}()
+		}
+
+		wg.Wait()
+	}
+
 	return nil
 }
 
-func toInsert(sourceCalendarID string, e *calendar.Event) *calendar.Event {
+// destinationCopies are the events in the destination which were copied from the source.
+type destinationCopies struct {
+	// bySourceItemID holds the copies of single events.
+	bySourceItemID map[string]*calendar.Event
+	// buffers holds the buffer events of copies, keyed by the source event ID too.
+	buffers map[string][]*calendar.Event
+	// blocks are the copies of merged events.
+	blocks []*calendar.Event
+	// byDuplicateKey holds the copies from every source, see duplicateKey.
+	byDuplicateKey map[string][]*calendar.Event
+}
+
+// getCopies lists the destination's copies of the source's events.
+func (w *Workflows) getCopies(ctx context.Context, args CopyCalendarWorkflowArgs, timeMin, timeMax time.Time) (destinationCopies, error) {
+	var result destinationCopies
+
+	destinationResult, err := w.a.GetCalendarEventsActivity(ctx, args.listArgs(args.DestinationCalendarID, timeMin, timeMax))
+	if err != nil {
+		return result, err
+	}
+	destinationCalendarItems := destinationResult.Calendar.Items
+
+	result.byDuplicateKey = make(map[string][]*calendar.Event)
+	for _, item := range destinationCalendarItems {
+		if key := getExtraByKey(item, pkg.SourceICalUIDKey); key != "" {
+			result.byDuplicateKey[key] = append(result.byDuplicateKey[key], item)
+		}
+	}
+
+	destinationCalendarItems = pkg.Filter(destinationCalendarItems, func(item *calendar.Event) bool {
+		return getExtraByKey(item, pkg.SourceCalendarIDKey) == args.SourceCalendarID
+	})
+
+	result.buffers = make(map[string][]*calendar.Event)
+	var copies []*calendar.Event
+	for _, item := range destinationCalendarItems {
+		switch {
+		case isBlock(item):
+			result.blocks = append(result.blocks, item)
+		case isBuffer(item):
+			key := getExtraByKey(item, pkg.SourceCalendarItemIDKey)
+			result.buffers[key] = append(result.buffers[key], item)
+		default:
+			copies = append(copies, item)
+		}
+	}
+	result.bySourceItemID = pkg.ToMap(copies, func(item *calendar.Event) string { return getExtraByKey(item, pkg.SourceCalendarItemIDKey) })
+
+	return result, nil
+}
+
+func (w *Workflows) createCopy(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, sourceItem *calendar.Event) {
+	w.insertCopy(ctx, args, plan, sourceItem.Id, toInsert(*logs.GetLogger(ctx), args, sourceItem))
+}
+
+func (w *Workflows) insertCopy(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, sourceItemID string, event *calendar.Event) {
+	plan.add(CopyChange{
+		Action:        CopyActionCreate,
+		SourceEventID: sourceItemID,
+		Summary:       event.Summary,
+		Start:         formatEventDateTime(event.Start),
+	})
+	if args.DryRun {
+		return
+	}
+
+	createArgs := activities.CreateCalendarItemArgs{
+		Event:      event,
+		CalendarID: args.DestinationCalendarID,
+	}
+	if _, err := w.a.CreateCalendarItem(ctx, createArgs); errors.Is(err, providers.ErrNotSupported) {
+		// retrying won't help, the destination can't store this event
+		logs.GetLogger(ctx).Warn().
+			Err(err).
+			Str("source-calendar-id", args.SourceCalendarID).
+			Str("destination-calendar-id", args.DestinationCalendarID).
+			Msg("destination doesn't support calendar item")
+	} else if err != nil {
+		plan.fail()
+		logs.GetLogger(ctx).Error().
+			Err(err).
+			Str("source-calendar-id", args.SourceCalendarID).
+			Str("destination-calendar-id", args.DestinationCalendarID).
+			Msg("failed to create calendar item")
+	}
+}
+
+func (w *Workflows) updateCopy(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, sourceItem, destItem *calendar.Event) {
+	log := logs.GetLogger(ctx)
+
+	source := args.prepare(*log, *sourceItem)
+	patch, diffs := diffEvents(*log, source, *destItem, args.optionalFields()...)
+	if args.Bidirectional {
+		patch = withFingerprint(patch, destItem, fingerprint(&source))
+	}
+	// copies from before duplicates were looked for don't have the key yet
+	if key := duplicateKey(sourceItem); key != "" {
+		patch = withProperty(patch, destItem, pkg.SourceICalUIDKey, key)
+	}
+	if patch == nil {
+		return
+	}
+
+	w.patchCopy(ctx, args, plan, sourceItem.Id, destItem, patch, diffs)
+}
+
+func (w *Workflows) patchCopy(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, sourceItemID string, destItem, patch *calendar.Event, diffs []FieldDiff) {
+	// a patch without diffs only updates the fingerprint
+	if len(diffs) > 0 {
+		plan.add(CopyChange{
+			Action:             CopyActionUpdate,
+			SourceEventID:      sourceItemID,
+			DestinationEventID: destItem.Id,
+			Summary:            destItem.Summary,
+			Start:              formatEventDateTime(destItem.Start),
+			Diffs:              diffs,
+		})
+	}
+	if args.DryRun {
+		return
+	}
+
+	// the patch is what the copy should look like, so it applies to a copy which was changed in the meantime just as
+	// well. Two-way copies may have been edited by hand though, which the next sync resolves.
+	err := w.retryWrite(ctx, args.DestinationCalendarID, destItem, func(destItem *calendar.Event) error {
+		updateArgs := activities.UpdateCalendarItemArgs{
+			CalendarID:     args.DestinationCalendarID,
+			CalendarItemID: destItem.Id,
+			Patch:          patch,
+			Etag:           destItem.Etag,
+		}
+		_, err := w.a.UpdateCalendarItem(ctx, updateArgs)
+		if args.Bidirectional && isWriteConflict(err) {
+			logs.GetLogger(ctx).Info().
+				Str("calendar-item-id", destItem.Id).
+				Msg("two-way copy was changed concurrently, leaving it to the next sync")
+			return nil
+		}
+		return err
+	})
+	w.handleCopyWriteError(ctx, args, plan, sourceItemID, destItem, err, "failed to update calendar")
+}
+
+// handleCopyWriteError logs failed writes to copies, and records the ones which were given up because of concurrent
+// edits. The others fail the plan, so the copy is synced again.
+func (w *Workflows) handleCopyWriteError(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, sourceItemID string, destItem *calendar.Event, err error, msg string) {
+	if err == nil {
+		return
+	}
+
+	if isWriteConflict(err) {
+		w.recordWriteConflict(ctx, persistence.Conflict{
+			CopyID:             args.CopyID,
+			SourceEventID:      sourceItemID,
+			DestinationEventID: destItem.Id,
+			Summary:            destItem.Summary,
+		})
+		return
+	}
+
+	if errors.Is(err, providers.ErrNotSupported) {
+		// retrying won't help, the destination can't store this change
+		logs.GetLogger(ctx).Warn().Err(err).
+			Str("calendar-id", args.DestinationCalendarID).
+			Str("calendar-item-id", destItem.Id).
+			Msg(msg)
+		return
+	}
+
+	plan.fail()
+	logs.GetLogger(ctx).Error().Err(err).
+		Str("calendar-id", args.DestinationCalendarID).
+		Str("calendar-item-id", destItem.Id).
+		Msg(msg)
+}
+
+func (w *Workflows) removeCopy(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, destItem *calendar.Event) {
+	plan.add(CopyChange{
+		Action:             CopyActionDelete,
+		SourceEventID:      getExtraByKey(destItem, pkg.SourceCalendarItemIDKey),
+		DestinationEventID: destItem.Id,
+		Summary:            destItem.Summary,
+		Start:              formatEventDateTime(destItem.Start),
+	})
+	if args.DryRun {
+		return
+	}
+
+	err := w.retryWrite(ctx, args.DestinationCalendarID, destItem, func(destItem *calendar.Event) error {
+		removeArgs := activities.RemoveCalendarItemArgs{
+			CalendarID: args.DestinationCalendarID,
+			EventID:    destItem.Id,
+			Etag:       destItem.Etag,
+		}
+		_, err := w.a.RemoveCalendarItem(ctx, removeArgs)
+		return err
+	})
+	// copies which are gone in the meantime don't need to be removed anymore
+	if errors.Is(err, providers.ErrNotFound) {
+		return
+	}
+	w.handleCopyWriteError(ctx, args, plan, getExtraByKey(destItem, pkg.SourceCalendarItemIDKey), destItem, err, "failed to remove calendar item")
+}
+
+func formatEventDateTime(dt *calendar.EventDateTime) string {
+	if dt == nil {
+		return ""
+	}
+	if dt.DateTime != "" {
+		return dt.DateTime
+	}
+	return dt.Date
+}
+
+func (w *Workflows) storeSyncToken(ctx context.Context, calendarID string, singleEvents bool, token string) {
+	args := activities.SetSyncTokenArgs{CalendarID: calendarID, SingleEvents: singleEvents, Token: token}
+	if _, err := w.a.SetSyncToken(ctx, args); err != nil {
+		logs.GetLogger(ctx).Warn().Err(err).
+			Str("calendar-id", calendarID).
+			Msg("failed to store sync token")
+	}
+}
+
+// reconcileOrphanedCopy handles a copy whose source event wasn't listed or was filtered out. The source may have been
+// removed, or it may have only moved outside the window, in which case the copy follows it rather than being removed.
+func (w *Workflows) reconcileOrphanedCopy(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, filter filters.Filter, sourceItemID string, destItem *calendar.Event, buffers []*calendar.Event, timeMin, timeMax time.Time) {
+	log := logs.GetLogger(ctx).With().
+		Str("source-event-id", sourceItemID).
+		Str("destination-event-id", destItem.Id).
+		Logger()
+
+	getArgs := activities.GetCalendarItemByItemIDArgs{
+		CalendarID: args.SourceCalendarID,
+		EventID:    sourceItemID,
+	}
+	getResult, err := w.a.GetCalendarItemByItemID(ctx, getArgs)
+	if err != nil && !errors.Is(err, providers.ErrNotFound) {
+		log.Error().Err(err).Msg("failed to check source event, leaving copy alone")
+		return
+	}
+
+	if err == nil && getResult.Event.Status != "cancelled" && filter.Matches(getResult.Event) {
+		w.syncCopy(ctx, args, plan, getResult.Event, destItem)
+		w.syncBuffers(ctx, args, plan, getResult.Event, buffers, timeMin, timeMax)
+		return
+	}
+
+	w.removeCopy(ctx, args, plan, destItem)
+	w.syncBuffers(ctx, args, plan, nil, buffers, timeMin, timeMax)
+}
+
+// prepare turns a source event into what its copy should look like.
+func (args CopyCalendarWorkflowArgs) prepare(log zerolog.Logger, source calendar.Event) calendar.Event {
+	return args.pad(&source, args.convertTimes(args.override(args.render(log, args.redact(source)))))
+}
+
+func toInsert(log zerolog.Logger, args CopyCalendarWorkflowArgs, source *calendar.Event) *calendar.Event {
+	e := args.prepare(log, *source)
 	event := calendar.Event{
 		Description: e.Description,
 		End:         e.End,
 		EventType:   e.EventType,
 		ExtendedProperties: &calendar.EventExtendedProperties{
-			Private: map[string]string{
-				pkg.SourceCalendarIDKey:     sourceCalendarID,
-				pkg.SourceCalendarItemIDKey: e.Id,
-			},
+			Private: copyProperties(args, &e),
 		},
-		Kind:     e.Kind,
-		Location: e.Location,
-		Start:    e.Start,
-		Status:   e.Status,
-		Summary:  e.Summary,
+		Kind:       e.Kind,
+		Location:   e.Location,
+		Recurrence: e.Recurrence,
+		Start:      e.Start,
+		Status:     e.Status,
+		Summary:    e.Summary,
+	}
+	copyOptionalFields(&event, e, args.optionalFields())
+
+	if key := duplicateKey(source); key != "" {
+		event.ExtendedProperties.Private[pkg.SourceICalUIDKey] = key
 	}
 
 	cleanEvent(&event)
@@ -143,8 +653,23 @@ func cleanEvent(e *calendar.Event) {
 
 type patchable[P any] struct {
 	log             zerolog.Logger
+	prefix          string
 	from, to, patch *P
 	shouldPatch     bool
+	diffs           *[]FieldDiff
+}
+
+// FieldDiff is a field which differs between a source event and its copy.
+type FieldDiff struct {
+	Field       string `json:"field"`
+	Source      any    `json:"source"`
+	Destination any    `json:"destination"`
+}
+
+func (p *patchable[P]) record(field string, from, to any) {
+	if p.diffs != nil {
+		*p.diffs = append(*p.diffs, FieldDiff{Field: p.prefix + field, Source: from, Destination: to})
+	}
 }
 
 func diffSlice[P any, T comparable](p *patchable[P], field string, fn func(e *P) *[]T) {
@@ -178,6 +703,8 @@ func diffSlice[P any, T comparable](p *patchable[P], field string, fn func(e *P)
 		return
 	}
 
+	p.record(field, from, to)
+
 	patch := fn(p.patch)
 	*patch = from
 	p.shouldPatch = true
@@ -196,18 +723,30 @@ func diff[P any, T comparable](p *patchable[P], field string, fn func(e *P) *T)
 		Any("destination", to).
 		Msgf("%s is different", field)
 
+	p.record(field, from, to)
+
 	patch := fn(p.patch)
 	*patch = from
 	p.shouldPatch = true
 }
 
-func buildPatch(log zerolog.Logger, from, to calendar.Event) *calendar.Event {
+func buildPatch(log zerolog.Logger, from, to calendar.Event, fields ...persistence.CopyField) *calendar.Event {
+	patch, _ := diffEvents(log, from, to, fields...)
+	return patch
+}
+
+// diffEvents builds the patch which makes the copy match the source, along with the fields it changes. Optional fields
+// are only compared when they're given.
+func diffEvents(log zerolog.Logger, from, to calendar.Event, fields ...persistence.CopyField) (*calendar.Event, []FieldDiff) {
 	logger := log.With().
 		Str("source_event_id", from.Id).
 		Str("destination_event_id", to.Id).
 		Logger()
 
-	var patch calendar.Event
+	var (
+		patch calendar.Event
+		diffs []FieldDiff
+	)
 
 	// perform some clean up
 	cleanEvent(&from)
@@ -218,6 +757,7 @@ func buildPatch(log zerolog.Logger, from, to calendar.Event) *calendar.Event {
 		from:  &from,
 		to:    &to,
 		patch: &patch,
+		diffs: &diffs,
 	}
 
 	// diff
@@ -228,20 +768,29 @@ func buildPatch(log zerolog.Logger, from, to calendar.Event) *calendar.Event {
 	diff(p, "description", func(e *calendar.Event) *string { return &e.Description })
 	diffSlice(p, "recurrence", func(e *calendar.Event) *[]string { return &e.Recurrence })
 
-	if update := patchDateTime(logger, "start", from.Start, to.Start); update != nil {
+	if update := patchDateTime(logger, "start", from.Start, to.Start, &diffs); update != nil {
 		patch.Start = update
 		p.shouldPatch = true
 	}
-	if update := patchDateTime(logger, "end", from.End, to.End); update != nil {
+	if update := patchDateTime(logger, "end", from.End, to.End, &diffs); update != nil {
 		patch.End = update
 		p.shouldPatch = true
 	}
+	diffOptionalFields(p, fields)
 
 	if !p.shouldPatch {
-		return nil
+		return nil, nil
 	}
 
-	return &patch
+	// empty fields are left out of a patch unless they're forced, so removed text would stay on the copy
+	if patch.Description == "" && to.Description != "" {
+		patch.ForceSendFields = append(patch.ForceSendFields, "Description")
+	}
+	if patch.Location == "" && to.Location != "" {
+		patch.ForceSendFields = append(patch.ForceSendFields, "Location")
+	}
+
+	return &patch, diffs
 }
 
 func getExtraByKey(item *calendar.Event, key string) string {
@@ -256,9 +805,38 @@ func getExtraByKey(item *calendar.Event, key string) string {
 	return item.ExtendedProperties.Private[key]
 }
 
-func (w *Workflows) getEvents(ctx context.Context, sourceID string) ([]*calendar.Event, error) {
+// withProperty adds a private extended property to a patch of the copy, unless the copy already has it. The patch
+// carries all of the copy's properties, including ones added to the patch before.
+func withProperty(patch *calendar.Event, destItem *calendar.Event, key, value string) *calendar.Event {
+	if getExtraByKey(destItem, key) == value {
+		return patch
+	}
+
+	if patch == nil {
+		patch = &calendar.Event{}
+	}
+
+	properties := map[string]string{}
+	current := destItem
+	if patch.ExtendedProperties != nil {
+		current = patch
+	}
+	if current.ExtendedProperties != nil {
+		for k, v := range current.ExtendedProperties.Private {
+			properties[k] = v
+		}
+	}
+	properties[key] = value
+	patch.ExtendedProperties = &calendar.EventExtendedProperties{Private: properties}
+
+	return patch
+}
+
+func (w *Workflows) getEvents(ctx context.Context, sourceID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
 	sourceEventsArgs := activities.GetCalendarEventsActivityArgs{
 		CalendarID: sourceID,
+		TimeMin:    timeMin,
+		TimeMax:    timeMax,
 	}
 	sourceEventsResult, err := w.a.GetCalendarEventsActivity(ctx, sourceEventsArgs)
 	if err != nil {
@@ -268,29 +846,36 @@ func (w *Workflows) getEvents(ctx context.Context, sourceID string) ([]*calendar
 	return sourceEventsResult.Calendar.Items, nil
 }
 
-func patchDateTime(log zerolog.Logger, field string, from *calendar.EventDateTime, to *calendar.EventDateTime) *calendar.EventDateTime {
+// patchDateTime returns the patch which makes a copy's time match the source's, or nil if they are the same moment in
+// equivalent time zones.
+func patchDateTime(log zerolog.Logger, field string, from *calendar.EventDateTime, to *calendar.EventDateTime, diffs *[]FieldDiff) *calendar.EventDateTime {
 	if from == nil {
 		return nil
 	}
 
 	if to == nil {
+		*diffs = append(*diffs, FieldDiff{Field: field, Source: from})
 		return from
 	}
 
-	patch := calendar.EventDateTime{}
-	p := &patchable[calendar.EventDateTime]{
-		log:   log.With().Str("datetime field", field).Logger(),
-		from:  from,
-		to:    to,
-		patch: &patch,
+	if sameDateTime(from, to) {
+		return nil
 	}
 
-	diff(p, "date", func(dt *calendar.EventDateTime) *string { return &dt.Date })
-	diff(p, "datetime", func(dt *calendar.EventDateTime) *string { return &dt.DateTime })
-	diff(p, "timezone", func(dt *calendar.EventDateTime) *string { return &dt.TimeZone })
+	log.Info().
+		Any("source", from).
+		Any("destination", to).
+		Msgf("%s is different", field)
 
-	if !p.shouldPatch {
-		return nil
+	*diffs = append(*diffs, FieldDiff{Field: field, Source: from, Destination: to})
+
+	patch := calendar.EventDateTime{Date: from.Date, DateTime: from.DateTime, TimeZone: from.TimeZone}
+	// switching between all day and timed events has to clear the other field
+	if patch.Date == "" && to.Date != "" {
+		patch.NullFields = append(patch.NullFields, "Date")
+	}
+	if patch.DateTime == "" && to.DateTime != "" {
+		patch.NullFields = append(patch.NullFields, "DateTime")
 	}
 
 	return &patch
@@ -2,37 +2,24 @@ package workflows
 
 import (
 	"context"
-	"sync"
 
 	"github.com/pkg/errors"
 )
 
+// CopyAllWorkflow queues a full sync of every copy config.
 func (w *Workflows) CopyAllWorkflow(ctx context.Context) error {
-	ctx, log := setupLogger(ctx, "CopyAllWorkflow")
+	ctx, _ = setupLogger(ctx, "CopyAllWorkflow")
 
 	copyConfigs, err := w.a.GetAllCopies(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to get copies")
 	}
 
-	var wg sync.WaitGroup
 	for _, copyConfig := range copyConfigs.CopyConfigs {
-		args := CopyCalendarWorkflowArgs{
-			copyConfig.SourceID,
-			copyConfig.DestinationID,
+		if err := w.EnqueueCopy(ctx, copyConfig.ID); err != nil {
+			return errors.Wrapf(err, "failed to queue copy %d", copyConfig.ID)
 		}
-		wg.Add(1)
-		go func(args CopyCalendarWorkflowArgs) {
-			err := w.CopyCalendarWorkflow(ctx, args)
-			if err != nil {
-				log.Error().Err(err).
-					Str("source-id", copyConfig.SourceID).
-					Str("destination-id", copyConfig.DestinationID).
-					Msg("failed to copy calendar")
-			}
-		}(args)
 	}
 
-	wg.Done()
 	return nil
 }
@@ -0,0 +1,130 @@
+package workflows
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/filters"
+	"calendar-sync/pkg/icalendar"
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/tasks/activities"
+)
+
+type CopyCalendarChangesWorkflowArgs struct {
+	CopyCalendarWorkflowArgs
+
+	// Changes are the source events that changed since the last sync, as returned by activities.GetChangedEvents.
+	Changes []*calendar.Event
+}
+
+// CopyCalendarChangesWorkflow applies changed source events to their copies, without listing either calendar.
+func (w *Workflows) CopyCalendarChangesWorkflow(ctx context.Context, args CopyCalendarChangesWorkflowArgs) error {
+	ctx, _ = setupLogger(ctx, "CopyCalendarChangesWorkflow")
+
+	filter, err := args.filter()
+	if err != nil {
+		return err
+	}
+
+	args.CopyCalendarWorkflowArgs, err = w.withDestination(ctx, args.CopyCalendarWorkflowArgs)
+	if err != nil {
+		return err
+	}
+
+	timeMin, timeMax := args.window(time.Now())
+
+	var (
+		wg   sync.WaitGroup
+		plan copyPlan
+	)
+
+	for _, sourceItem := range args.Changes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.copyChange(ctx, args.CopyCalendarWorkflowArgs, &plan, filter, sourceItem, timeMin, timeMax)
+		}()
+	}
+
+	wg.Wait()
+
+	if plan.failedWrites > 0 {
+		return errors.Errorf("failed to make %d of the copy's changes", plan.failedWrites)
+	}
+
+	return nil
+}
+
+func (w *Workflows) copyChange(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, filter filters.Filter, sourceItem *calendar.Event, timeMin, timeMax time.Time) {
+	log := logs.GetLogger(ctx).With().Str("source-event-id", sourceItem.Id).Logger()
+
+	findArgs := activities.FindWebcalEventsArgs{
+		DestinationCalendarID: args.DestinationCalendarID,
+		SourceCalendarID:      args.SourceCalendarID,
+		SourceCalendarItemID:  sourceItem.Id,
+	}
+	findResult, err := w.a.FindDestinationWebcalEvent(ctx, findArgs)
+	if err != nil {
+		plan.fail()
+		log.Error().Err(err).Msg("failed to find copies")
+		return
+	}
+
+	buffers := pkg.Filter(findResult.Items, isBuffer)
+	copies := pkg.Filter(findResult.Items, func(item *calendar.Event) bool { return !isBuffer(item) })
+
+	// events which no longer pass the filter are removed like deleted ones
+	if sourceItem.Status == "cancelled" || !filter.Matches(sourceItem) {
+		for _, destItem := range copies {
+			w.removeCopy(ctx, args, plan, destItem)
+		}
+		w.syncBuffers(ctx, args, plan, nil, buffers, timeMin, timeMax)
+		return
+	}
+
+	if w.isCopiedElsewhere(ctx, args, sourceItem, len(copies) > 0) {
+		for _, destItem := range copies {
+			w.removeCopy(ctx, args, plan, destItem)
+		}
+		w.syncBuffers(ctx, args, plan, nil, buffers, timeMin, timeMax)
+		return
+	}
+
+	if len(copies) == 0 {
+		// same as a full sync, events outside the window are not copied until they move into it
+		if !icalendar.Overlaps(sourceItem, timeMin, timeMax) {
+			w.syncBuffers(ctx, args, plan, nil, buffers, timeMin, timeMax)
+			return
+		}
+		w.createCopy(ctx, args, plan, sourceItem)
+	}
+
+	for _, destItem := range copies {
+		w.updateCopy(ctx, args, plan, sourceItem, destItem)
+	}
+	w.syncBuffers(ctx, args, plan, sourceItem, buffers, timeMin, timeMax)
+}
+
+// isCopiedElsewhere looks for copies of the same event from other sources, see copiedElsewhere.
+func (w *Workflows) isCopiedElsewhere(ctx context.Context, args CopyCalendarWorkflowArgs, sourceItem *calendar.Event, hasCopy bool) bool {
+	key := duplicateKey(sourceItem)
+	if !args.Deduplicate || key == "" {
+		return false
+	}
+
+	findResult, err := w.a.FindDestinationWebcalEvent(ctx, activities.FindWebcalEventsArgs{
+		DestinationCalendarID: args.DestinationCalendarID,
+		SourceICalUID:         key,
+	})
+	if err != nil {
+		logs.GetLogger(ctx).Warn().Err(err).Str("source-event-id", sourceItem.Id).Msg("failed to look for duplicates")
+		return false
+	}
+
+	return args.copiedElsewhere(args.otherSources(findResult.Items), hasCopy)
+}
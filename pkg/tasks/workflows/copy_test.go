@@ -1,12 +1,27 @@
 package workflows
 
 import (
+	"database/sql"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/container"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/persistence/sqlite"
+	"calendar-sync/pkg/providers"
+	"calendar-sync/pkg/providers/memory"
+	"calendar-sync/pkg/tasks/activities"
 )
 
 func TestBuildPatch(t *testing.T) {
@@ -14,6 +29,7 @@ func TestBuildPatch(t *testing.T) {
 
 	testcases := map[string]struct {
 		from, to calendar.Event
+		fields   []persistence.CopyField
 		expected *calendar.Event
 	}{
 		"minimal": {
@@ -115,6 +131,116 @@ func TestBuildPatch(t *testing.T) {
 				},
 			},
 		},
+		"same instant with different offsets": {
+			from: calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2030-01-02T10:00:00Z", TimeZone: "UTC"},
+				End:   &calendar.EventDateTime{DateTime: "2030-01-02T11:00:00Z"},
+			},
+			to: calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2030-01-02T11:00:00+01:00", TimeZone: "Etc/UTC"},
+				End:   &calendar.EventDateTime{DateTime: "2030-01-02T12:00:00+01:00", TimeZone: "Europe/Berlin"},
+			},
+		},
+		"equivalent time zones": {
+			from: calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2030-01-02T10:00:00+01:00", TimeZone: "Europe/Berlin"},
+			},
+			to: calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2030-01-02T10:00:00+01:00", TimeZone: "Europe/Paris"},
+			},
+		},
+		"different time zones": {
+			from: calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2030-01-02T10:00:00+01:00", TimeZone: "Europe/Berlin"},
+			},
+			to: calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2030-01-02T10:00:00+01:00", TimeZone: "Africa/Lagos"},
+			},
+			expected: &calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2030-01-02T10:00:00+01:00", TimeZone: "Europe/Berlin"},
+			},
+		},
+		"all day to timed": {
+			from: calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2030-01-02T00:00:00Z"},
+			},
+			to: calendar.Event{
+				Start: &calendar.EventDateTime{Date: "2030-01-02"},
+			},
+			expected: &calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2030-01-02T00:00:00Z", NullFields: []string{"Date"}},
+			},
+		},
+		"timed to all day": {
+			from: calendar.Event{
+				Start: &calendar.EventDateTime{Date: "2030-01-02"},
+			},
+			to: calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2030-01-02T00:00:00Z", TimeZone: "UTC"},
+			},
+			expected: &calendar.Event{
+				Start: &calendar.EventDateTime{Date: "2030-01-02", NullFields: []string{"DateTime"}},
+			},
+		},
+		"optional fields are ignored": {
+			from: calendar.Event{ColorId: "5", Attendees: []*calendar.EventAttendee{{Email: "a@example.com"}}},
+			to:   calendar.Event{},
+		},
+		"optional fields without differences": {
+			from: calendar.Event{
+				Attendees:  []*calendar.EventAttendee{{Email: "A@example.com"}, {Email: "b@example.com", Optional: true}},
+				Visibility: "default",
+				Reminders:  &calendar.EventReminders{UseDefault: true},
+			},
+			to: calendar.Event{
+				Attendees: []*calendar.EventAttendee{
+					{Email: "b@example.com", Optional: true, DisplayName: "B"},
+					{Email: "a@example.com", ResponseStatus: "accepted"},
+				},
+				Transparency: "opaque",
+			},
+			fields: persistence.CopyFields,
+		},
+		"optional fields are changed": {
+			from: calendar.Event{
+				Attendees:      []*calendar.EventAttendee{{Email: "a@example.com"}},
+				Reminders:      &calendar.EventReminders{Overrides: []*calendar.EventReminder{{Method: "popup", Minutes: 10}}},
+				ColorId:        "5",
+				Transparency:   "transparent",
+				Visibility:     "private",
+				ConferenceData: &calendar.ConferenceData{ConferenceId: "abc-defg-hij"},
+				Attachments:    []*calendar.EventAttachment{{FileUrl: "https://example.com/agenda"}},
+			},
+			to: calendar.Event{
+				Attendees: []*calendar.EventAttendee{{Email: "b@example.com"}},
+			},
+			fields: persistence.CopyFields,
+			expected: &calendar.Event{
+				Attendees:      []*calendar.EventAttendee{{Email: "a@example.com"}},
+				Reminders:      &calendar.EventReminders{Overrides: []*calendar.EventReminder{{Method: "popup", Minutes: 10}}},
+				ColorId:        "5",
+				Transparency:   "transparent",
+				Visibility:     "private",
+				ConferenceData: &calendar.ConferenceData{ConferenceId: "abc-defg-hij"},
+				Attachments:    []*calendar.EventAttachment{{FileUrl: "https://example.com/agenda"}},
+			},
+		},
+		"optional fields are removed": {
+			from: calendar.Event{},
+			to: calendar.Event{
+				Attendees:      []*calendar.EventAttendee{{Email: "a@example.com"}},
+				Reminders:      &calendar.EventReminders{},
+				ColorId:        "5",
+				ConferenceData: &calendar.ConferenceData{ConferenceId: "abc-defg-hij"},
+				Attachments:    []*calendar.EventAttachment{{FileUrl: "https://example.com/agenda"}},
+			},
+			fields: persistence.CopyFields,
+			expected: &calendar.Event{
+				Reminders:       &calendar.EventReminders{UseDefault: true},
+				ForceSendFields: []string{"Attendees", "ColorId", "Attachments"},
+				NullFields:      []string{"ConferenceData"},
+			},
+		},
 	}
 
 	for key, tc := range testcases {
@@ -122,7 +248,7 @@ func TestBuildPatch(t *testing.T) {
 			t.Parallel()
 
 			log := zerolog.New(os.Stdout)
-			actual := buildPatch(log, tc.from, tc.to)
+			actual := buildPatch(log, tc.from, tc.to, tc.fields...)
 			if tc.expected == nil {
 				assert.Nil(t, actual)
 			} else {
@@ -131,3 +257,1163 @@ func TestBuildPatch(t *testing.T) {
 		})
 	}
 }
+
+func newTestWorkflows(t *testing.T) (*Workflows, *memory.Provider) {
+	t.Helper()
+
+	w, provider, _ := newTestWorkflowsWithDatabase(t)
+	return w, provider
+}
+
+func newTestWorkflowsWithDatabase(t *testing.T) (*Workflows, *memory.Provider, *sqlite.Database) {
+	t.Helper()
+
+	provider := memory.New()
+	provider.AddCalendar(providers.CalendarInfo{ID: "source", Summary: "Source"})
+	provider.AddCalendar(providers.CalendarInfo{ID: "destination", Summary: "Destination"})
+
+	db, err := sqlite.NewDatabase(t.Context(), pkg.Config{
+		DatabaseDriver: "sqlite3",
+		DatabaseSource: filepath.Join(t.TempDir(), "test.db"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	a := activities.New(container.Container{Database: db, Provider: provider})
+
+	return New(a), provider, db
+}
+
+func timedEvent(summary string, start time.Time, duration time.Duration) *calendar.Event {
+	return &calendar.Event{
+		Summary: summary,
+		Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:     &calendar.EventDateTime{DateTime: start.Add(duration).Format(time.RFC3339)},
+	}
+}
+
+func TestCopyCalendarWorkflow(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := CopyCalendarWorkflowArgs{SourceCalendarID: "source", DestinationCalendarID: "destination"}
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	source, err := provider.InsertEvent(ctx, "source", timedEvent("meeting", start, time.Hour))
+	require.NoError(t, err)
+	_, err = provider.InsertEvent(ctx, "source", timedEvent("too far away", start.Add(activities.SearchWindow), time.Hour))
+	require.NoError(t, err)
+	_, err = provider.InsertEvent(ctx, "destination", timedEvent("unrelated", start, time.Hour))
+	require.NoError(t, err)
+
+	// create
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	copies := pkg.Filter(provider.Events("destination"), func(e *calendar.Event) bool {
+		return getExtraByKey(e, pkg.SourceCalendarIDKey) == "source"
+	})
+	require.Len(t, copies, 1)
+	assert.Equal(t, "meeting", copies[0].Summary)
+	assert.Equal(t, source.Id, getExtraByKey(copies[0], pkg.SourceCalendarItemIDKey))
+
+	// update
+	_, err = provider.PatchEvent(ctx, "source", source.Id, &calendar.Event{Summary: "renamed"})
+	require.NoError(t, err)
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	updated, err := provider.GetEvent(ctx, "destination", copies[0].Id)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", updated.Summary)
+
+	// delete
+	require.NoError(t, provider.DeleteEvent(ctx, "source", source.Id, ""))
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	remaining := provider.Events("destination")
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "unrelated", remaining[0].Summary)
+}
+
+func TestCopyCalendarWorkflowWindow(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := CopyCalendarWorkflowArgs{
+		SourceCalendarID:      "source",
+		DestinationCalendarID: "destination",
+		LookBack:              48 * time.Hour,
+		LookAhead:             24 * time.Hour,
+	}
+
+	now := time.Now().Truncate(time.Minute)
+	past, err := provider.InsertEvent(ctx, "source", timedEvent("yesterday", now.Add(-24*time.Hour), time.Hour))
+	require.NoError(t, err)
+	_, err = provider.InsertEvent(ctx, "source", timedEvent("next week", now.Add(7*24*time.Hour), time.Hour))
+	require.NoError(t, err)
+
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	copies := provider.Events("destination")
+	require.Len(t, copies, 1)
+	assert.Equal(t, "yesterday", copies[0].Summary)
+
+	// moving the source out of the window must not remove the copy
+	moved := timedEvent("", now.Add(-30*24*time.Hour), time.Hour)
+	_, err = provider.PatchEvent(ctx, "source", past.Id, &calendar.Event{Start: moved.Start, End: moved.End})
+	require.NoError(t, err)
+
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	copies = provider.Events("destination")
+	require.Len(t, copies, 1)
+	assert.Equal(t, moved.Start.DateTime, copies[0].Start.DateTime)
+}
+
+func TestCopyCalendarChanges(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := CopyCalendarWorkflowArgs{SourceCalendarID: "source", DestinationCalendarID: "destination"}
+
+	// without a sync token a full sync is required, and changes are listed from then on
+	changes, err := w.a.GetChangedEvents(ctx, activities.GetChangedEventsArgs{CalendarID: "source"})
+	require.NoError(t, err)
+	assert.True(t, changes.FullSyncRequired)
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	kept, err := provider.InsertEvent(ctx, "source", timedEvent("kept", start, time.Hour))
+	require.NoError(t, err)
+	removed, err := provider.InsertEvent(ctx, "source", timedEvent("removed", start, time.Hour))
+	require.NoError(t, err)
+
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	require.Len(t, provider.Events("destination"), 2)
+
+	// only the changes are applied
+	_, err = provider.PatchEvent(ctx, "source", kept.Id, &calendar.Event{Summary: "renamed"})
+	require.NoError(t, err)
+	require.NoError(t, provider.DeleteEvent(ctx, "source", removed.Id, ""))
+	_, err = provider.InsertEvent(ctx, "source", timedEvent("added", start, time.Hour))
+	require.NoError(t, err)
+	_, err = provider.InsertEvent(ctx, "source", timedEvent("too far away", start.Add(activities.SearchWindow), time.Hour))
+	require.NoError(t, err)
+
+	changes, err = w.a.GetChangedEvents(ctx, activities.GetChangedEventsArgs{CalendarID: "source"})
+	require.NoError(t, err)
+	require.False(t, changes.FullSyncRequired)
+	assert.Len(t, changes.Items, 4)
+
+	require.NoError(t, w.CopyCalendarChangesWorkflow(ctx, CopyCalendarChangesWorkflowArgs{
+		CopyCalendarWorkflowArgs: args,
+		Changes:                  changes.Items,
+	}))
+
+	summaries := pkg.ToSet(provider.Events("destination"), func(e *calendar.Event) string { return e.Summary })
+	assert.Equal(t, map[string]struct{}{"renamed": {}, "added": {}}, summaries)
+
+	// expired tokens require a full sync, and are replaced right away
+	provider.ExpireSyncTokens()
+
+	changes, err = w.a.GetChangedEvents(ctx, activities.GetChangedEventsArgs{CalendarID: "source"})
+	require.NoError(t, err)
+	assert.True(t, changes.FullSyncRequired)
+
+	changes, err = w.a.GetChangedEvents(ctx, activities.GetChangedEventsArgs{CalendarID: "source"})
+	require.NoError(t, err)
+	assert.False(t, changes.FullSyncRequired)
+	assert.Empty(t, changes.Items)
+
+	// expanded listings have a token of their own, which is replayed with singleEvents too
+	expanded := activities.GetChangedEventsArgs{CalendarID: "source", SingleEvents: true}
+	changes, err = w.a.GetChangedEvents(ctx, expanded)
+	require.NoError(t, err)
+	assert.True(t, changes.FullSyncRequired)
+
+	_, err = provider.PatchEvent(ctx, "source", kept.Id, &calendar.Event{Summary: "renamed again"})
+	require.NoError(t, err)
+
+	changes, err = w.a.GetChangedEvents(ctx, expanded)
+	require.NoError(t, err)
+	require.False(t, changes.FullSyncRequired)
+	require.Len(t, changes.Items, 1)
+	assert.Equal(t, "renamed again", changes.Items[0].Summary)
+
+	changes, err = w.a.GetChangedEvents(ctx, activities.GetChangedEventsArgs{CalendarID: "source"})
+	require.NoError(t, err)
+	require.False(t, changes.FullSyncRequired)
+	assert.Len(t, changes.Items, 1)
+}
+
+func TestCopyCalendarWorkflowPrivacy(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := CopyCalendarWorkflowArgs{SourceCalendarID: "source", DestinationCalendarID: "destination"}
+
+	event := timedEvent("1:1 with boss", time.Now().Add(time.Hour).Truncate(time.Minute), time.Hour)
+	event.Description = "performance review"
+	event.Location = "room 4"
+	_, err := provider.InsertEvent(ctx, "source", event)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		privacy                        persistence.PrivacyMode
+		summary, description, location string
+	}{
+		{persistence.PrivacyFull, "1:1 with boss", "performance review", "room 4"},
+		{persistence.PrivacyTitleOnly, "1:1 with boss", "", ""},
+		{persistence.PrivacyBusy, "Unavailable", "", ""},
+		{persistence.PrivacyFull, "1:1 with boss", "performance review", "room 4"},
+	}
+
+	// each mode is applied to the copy left behind by the previous one
+	for _, tc := range testcases {
+		args.Privacy = tc.privacy
+		args.BusyTitle = "Unavailable"
+		_, err = w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+
+		copies := provider.Events("destination")
+		require.Len(t, copies, 1)
+		assert.Equal(t, tc.summary, copies[0].Summary, tc.privacy)
+		assert.Equal(t, tc.description, copies[0].Description, tc.privacy)
+		assert.Equal(t, tc.location, copies[0].Location, tc.privacy)
+	}
+}
+
+func TestCopyCalendarWorkflowTemplates(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := CopyCalendarWorkflowArgs{SourceCalendarID: "source", DestinationCalendarID: "destination"}
+
+	event := timedEvent("1:1 with boss", time.Now().Add(time.Hour).Truncate(time.Minute), time.Hour)
+	event.Description = "performance review"
+	_, err := provider.InsertEvent(ctx, "source", event)
+	require.NoError(t, err)
+
+	testcases := []struct {
+		privacy              persistence.PrivacyMode
+		summaryTemplate      string
+		descriptionTemplate  string
+		summary, description string
+	}{
+		{persistence.PrivacyFull, "[Work] {{.Summary}}", "from {{.SourceCalendarID}}: {{.Description}}", "[Work] 1:1 with boss", "from source: performance review"},
+		{persistence.PrivacyFull, "[Job] {{.Summary}}", "", "[Job] 1:1 with boss", "performance review"},
+		{persistence.PrivacyBusy, "[Job] {{.Summary}}", "{{.Description}}", "[Job] Busy", ""},
+		// broken templates leave the text alone
+		{persistence.PrivacyFull, "{{.Unknown}}", "{{", "1:1 with boss", "performance review"},
+	}
+
+	// edited templates are applied to the copy left behind by the previous one
+	for _, tc := range testcases {
+		args.Privacy = tc.privacy
+		args.SummaryTemplate = tc.summaryTemplate
+		args.DescriptionTemplate = tc.descriptionTemplate
+		_, err = w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+
+		copies := provider.Events("destination")
+		require.Len(t, copies, 1)
+		assert.Equal(t, tc.summary, copies[0].Summary, tc.summaryTemplate)
+		assert.Equal(t, tc.description, copies[0].Description, tc.descriptionTemplate)
+	}
+
+	require.NoError(t, CheckTemplate("[Work] {{.Summary}} {{.Start.DateTime}}"))
+	require.Error(t, CheckTemplate("{{.Unknown}}"))
+	require.Error(t, CheckTemplate("{{"))
+}
+
+func TestCopyCalendarWorkflowOptionalFields(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := CopyCalendarWorkflowArgs{SourceCalendarID: "source", DestinationCalendarID: "destination"}
+
+	event := timedEvent("standup", time.Now().Add(time.Hour).Truncate(time.Minute), time.Hour)
+	event.Attendees = []*calendar.EventAttendee{{Email: "a@example.com"}, {Email: "b@example.com"}}
+	event.ColorId = "5"
+	event.ConferenceData = &calendar.ConferenceData{ConferenceId: "abc-defg-hij"}
+	_, err := provider.InsertEvent(ctx, "source", event)
+	require.NoError(t, err)
+
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	copies := provider.Events("destination")
+	require.Len(t, copies, 1)
+	assert.Empty(t, copies[0].Attendees)
+	assert.Empty(t, copies[0].ColorId)
+	assert.Nil(t, copies[0].ConferenceData)
+
+	// existing copies pick up fields which are turned on
+	args.Fields = []persistence.CopyField{persistence.CopyAttendees, persistence.CopyColor, persistence.CopyConferenceData}
+	args.ColorID = "11"
+	args.NoReminders = true
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	copies = provider.Events("destination")
+	require.Len(t, copies, 1)
+	assert.Len(t, copies[0].Attendees, 2)
+	assert.Equal(t, "11", copies[0].ColorId)
+	assert.Equal(t, "abc-defg-hij", copies[0].ConferenceData.ConferenceId)
+	require.NotNil(t, copies[0].Reminders)
+	assert.False(t, copies[0].Reminders.UseDefault)
+
+	// and lose the ones the privacy mode doesn't allow
+	args.Privacy = persistence.PrivacyBusy
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	copies = provider.Events("destination")
+	require.Len(t, copies, 1)
+	assert.Empty(t, copies[0].Attendees)
+	assert.Nil(t, copies[0].ConferenceData)
+	assert.Equal(t, "11", copies[0].ColorId)
+
+	// which leaves nothing to do
+	args.DryRun = true
+	result, err := w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	assert.Empty(t, result.Changes)
+}
+
+func TestCopyCalendarWorkflowAllDay(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	provider.AddCalendar(providers.CalendarInfo{ID: "third", Summary: "Third"})
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	day := time.Now().AddDate(0, 0, 2).In(berlin)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, berlin)
+
+	_, err = provider.InsertEvent(ctx, "source", &calendar.Event{
+		Summary: "vacation",
+		Start:   &calendar.EventDateTime{Date: day.Format(time.DateOnly)},
+		End:     &calendar.EventDateTime{Date: day.AddDate(0, 0, 1).Format(time.DateOnly)},
+	})
+	require.NoError(t, err)
+	_, err = provider.InsertEvent(ctx, "destination", timedEvent("meeting", day.Add(10*time.Hour).UTC(), time.Hour))
+	require.NoError(t, err)
+
+	// all day events become timed ones in the destination's time zone
+	toTimed := CopyCalendarWorkflowArgs{
+		SourceCalendarID:      "source",
+		DestinationCalendarID: "destination",
+		AllDay:                persistence.AllDayToTimed,
+		TimeZone:              "Europe/Berlin",
+	}
+	_, err = w.CopyCalendarWorkflow(ctx, toTimed)
+	require.NoError(t, err)
+
+	copies, err := provider.ListEvents(ctx, "destination", providers.ListEventsOptions{
+		PrivateExtendedProperties: map[string]string{pkg.SourceCalendarIDKey: "source"},
+	})
+	require.NoError(t, err)
+	require.Len(t, copies.Items, 1)
+	assert.Equal(t, day.Format(time.RFC3339), copies.Items[0].Start.DateTime)
+	assert.Equal(t, day.AddDate(0, 0, 1).Format(time.RFC3339), copies.Items[0].End.DateTime)
+	assert.Equal(t, "Europe/Berlin", copies.Items[0].Start.TimeZone)
+
+	// and timed ones cover the days they touch
+	toAllDay := CopyCalendarWorkflowArgs{
+		SourceCalendarID:      "destination",
+		DestinationCalendarID: "third",
+		AllDay:                persistence.AllDayFromTimed,
+		TimeZone:              "Europe/Berlin",
+		ChainMode:             persistence.ChainSkip,
+	}
+	_, err = w.CopyCalendarWorkflow(ctx, toAllDay)
+	require.NoError(t, err)
+
+	copies.Items = provider.Events("third")
+	require.Len(t, copies.Items, 1)
+	assert.Equal(t, day.Format(time.DateOnly), copies.Items[0].Start.Date)
+	assert.Equal(t, day.AddDate(0, 0, 1).Format(time.DateOnly), copies.Items[0].End.Date)
+
+	// converted copies aren't patched again
+	for _, args := range []CopyCalendarWorkflowArgs{toTimed, toAllDay} {
+		args.DryRun = true
+		result, err := w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+		assert.Empty(t, result.Changes)
+	}
+}
+
+func TestCopyCalendarWorkflowPadding(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now().Add(2 * time.Hour).Truncate(time.Minute).UTC()
+	format := func(t time.Time) string { return t.Format(time.RFC3339) }
+
+	setup := func(t *testing.T) (*Workflows, *memory.Provider, *calendar.Event) {
+		w, provider := newTestWorkflows(t)
+
+		office := timedEvent("meeting", start, time.Hour)
+		office.Location = "Office"
+		office, err := provider.InsertEvent(t.Context(), "source", office)
+		require.NoError(t, err)
+
+		online := timedEvent("call", start.Add(3*time.Hour), time.Hour)
+		online.Location = "https://meet.example.com/abc"
+		_, err = provider.InsertEvent(t.Context(), "source", online)
+		require.NoError(t, err)
+
+		return w, provider, office
+	}
+
+	t.Run("extend", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := t.Context()
+		w, provider, _ := setup(t)
+		args := CopyCalendarWorkflowArgs{
+			SourceCalendarID:      "source",
+			DestinationCalendarID: "destination",
+			PadBefore:             15 * time.Minute,
+			PadAfter:              30 * time.Minute,
+			PadMode:               persistence.PadExtend,
+			PadLocationOnly:       true,
+		}
+
+		_, err := w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+
+		times := make(map[string][2]string)
+		for _, e := range provider.Events("destination") {
+			times[e.Summary] = [2]string{e.Start.DateTime, e.End.DateTime}
+		}
+		assert.Equal(t, map[string][2]string{
+			"meeting": {format(start.Add(-15 * time.Minute)), format(start.Add(90 * time.Minute))},
+			"call":    {format(start.Add(3 * time.Hour)), format(start.Add(4 * time.Hour))},
+		}, times)
+
+		args.DryRun = true
+		result, err := w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+		assert.Empty(t, result.Changes)
+	})
+
+	t.Run("separate", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := t.Context()
+		w, provider, office := setup(t)
+		args := CopyCalendarWorkflowArgs{
+			SourceCalendarID:      "source",
+			DestinationCalendarID: "destination",
+			PadBefore:             15 * time.Minute,
+			PadAfter:              30 * time.Minute,
+			PadMode:               persistence.PadSeparate,
+		}
+
+		buffers := func() map[string][2]string {
+			result := make(map[string][2]string)
+			for _, e := range provider.Events("destination") {
+				if side := getExtraByKey(e, pkg.BufferKey); side != "" {
+					result[e.Summary+" "+side] = [2]string{e.Start.DateTime, e.End.DateTime}
+				}
+			}
+			return result
+		}
+
+		_, err := w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+		assert.Len(t, provider.Events("destination"), 6)
+		assert.Equal(t, map[string][2]string{
+			"Buffer: meeting before": {format(start.Add(-15 * time.Minute)), format(start)},
+			"Buffer: meeting after":  {format(start.Add(time.Hour)), format(start.Add(90 * time.Minute))},
+			"Buffer: call before":    {format(start.Add(165 * time.Minute)), format(start.Add(3 * time.Hour))},
+			"Buffer: call after":     {format(start.Add(4 * time.Hour)), format(start.Add(270 * time.Minute))},
+		}, buffers())
+
+		dryRun := args
+		dryRun.DryRun = true
+		result, err := w.CopyCalendarWorkflow(ctx, dryRun)
+		require.NoError(t, err)
+		assert.Empty(t, result.Changes)
+
+		// buffers follow their event, also when only the changes are copied
+		moved := start.Add(time.Hour)
+		changed, err := provider.PatchEvent(ctx, "source", office.Id, timedEvent("meeting", moved, time.Hour))
+		require.NoError(t, err)
+		require.NoError(t, w.CopyCalendarChangesWorkflow(ctx, CopyCalendarChangesWorkflowArgs{
+			CopyCalendarWorkflowArgs: args,
+			Changes:                  []*calendar.Event{changed},
+		}))
+		assert.Equal(t, [2]string{format(moved.Add(-15 * time.Minute)), format(moved)}, buffers()["Buffer: meeting before"])
+
+		require.NoError(t, provider.DeleteEvent(ctx, "source", office.Id, ""))
+		_, err = w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+		assert.Len(t, provider.Events("destination"), 3)
+
+		// and are removed when their event is no longer padded
+		args.PadLocationOnly = true
+		_, err = w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+		assert.Empty(t, buffers())
+		assert.Len(t, provider.Events("destination"), 1)
+	})
+}
+
+func TestCopyCalendarWorkflowMergeBusy(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := CopyCalendarWorkflowArgs{
+		SourceCalendarID:      "source",
+		DestinationCalendarID: "destination",
+		Privacy:               persistence.PrivacyBusy,
+		MergeBusy:             true,
+		MergeGap:              15 * time.Minute,
+	}
+
+	start := time.Now().Add(2 * time.Hour).Truncate(time.Minute).UTC()
+	format := func(t time.Time) string { return t.Format(time.RFC3339) }
+
+	insert := func(summary string, offset, duration time.Duration) *calendar.Event {
+		e, err := provider.InsertEvent(ctx, "source", timedEvent(summary, start.Add(offset), duration))
+		require.NoError(t, err)
+		return e
+	}
+	first := insert("first", 0, time.Hour)
+	second := insert("second", time.Hour, time.Hour)
+	third := insert("third", 2*time.Hour+10*time.Minute, time.Hour)
+	insert("later", 5*time.Hour, time.Hour)
+	free := timedEvent("free", start.Add(4*time.Hour), 2*time.Hour)
+	free.Transparency = "transparent"
+	_, err := provider.InsertEvent(ctx, "source", free)
+	require.NoError(t, err)
+
+	blocks := func() map[string]string {
+		result := make(map[string]string)
+		for _, e := range provider.Events("destination") {
+			assert.Equal(t, "Busy", e.Summary)
+			result[e.Start.DateTime] = e.End.DateTime
+		}
+		return result
+	}
+
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		format(start):                    format(start.Add(3*time.Hour + 10*time.Minute)),
+		format(start.Add(5 * time.Hour)): format(start.Add(6 * time.Hour)),
+	}, blocks())
+
+	dryRun := args
+	dryRun.DryRun = true
+	result, err := w.CopyCalendarWorkflow(ctx, dryRun)
+	require.NoError(t, err)
+	assert.Empty(t, result.Changes)
+
+	// moving an event away splits its block, and the block it joins grows
+	_, err = provider.PatchEvent(ctx, "source", second.Id, timedEvent("second", start.Add(6*time.Hour), time.Hour))
+	require.NoError(t, err)
+
+	result, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		format(start): format(start.Add(time.Hour)),
+		format(start.Add(2*time.Hour + 10*time.Minute)): format(start.Add(3*time.Hour + 10*time.Minute)),
+		format(start.Add(5 * time.Hour)):                format(start.Add(7 * time.Hour)),
+	}, blocks())
+
+	actions := make(map[CopyAction]int)
+	for _, change := range result.Changes {
+		actions[change.Action]++
+	}
+	assert.Equal(t, map[CopyAction]int{CopyActionCreate: 1, CopyActionUpdate: 2}, actions)
+
+	// blocks which merge again are updated and removed
+	require.NoError(t, provider.DeleteEvent(ctx, "source", third.Id, ""))
+	_, err = provider.PatchEvent(ctx, "source", first.Id, timedEvent("first", start.Add(4*time.Hour), time.Hour))
+	require.NoError(t, err)
+
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		format(start.Add(4 * time.Hour)): format(start.Add(7 * time.Hour)),
+	}, blocks())
+
+	// and turning merging off copies the events again
+	args.MergeBusy = false
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	assert.Len(t, provider.Events("destination"), 4)
+}
+
+func TestCopyCalendarWorkflowFanIn(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	provider.AddCalendar(providers.CalendarInfo{ID: "second", Summary: "Second"})
+
+	first := CopyCalendarWorkflowArgs{SourceCalendarID: "source", DestinationCalendarID: "destination", Label: "A", Deduplicate: true}
+	second := CopyCalendarWorkflowArgs{SourceCalendarID: "second", DestinationCalendarID: "destination", Label: "B", Deduplicate: true}
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	insert := func(calendarID, summary, uid string) *calendar.Event {
+		e := timedEvent(summary, start, time.Hour)
+		e.ICalUID = uid
+		e, err := provider.InsertEvent(ctx, calendarID, e)
+		require.NoError(t, err)
+		return e
+	}
+	shared := insert("source", "all hands", "all-hands@example.com")
+	sharedCopy := insert("second", "all hands", "all-hands@example.com")
+	insert("source", "1:1", "one-on-one@example.com")
+	insert("second", "review", "review@example.com")
+
+	summaries := func() []string {
+		var result []string
+		for _, e := range provider.Events("destination") {
+			result = append(result, e.Summary)
+		}
+		slices.Sort(result)
+		return result
+	}
+
+	// events in both sources are copied once, with the label of the source which copied them first
+	for _, args := range []CopyCalendarWorkflowArgs{first, second, first, second} {
+		_, err := w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"[A] 1:1", "[A] all hands", "[B] review"}, summaries())
+
+	// also when only the changes are copied
+	changed, err := provider.PatchEvent(ctx, "second", sharedCopy.Id, &calendar.Event{Description: "agenda"})
+	require.NoError(t, err)
+	require.NoError(t, w.CopyCalendarChangesWorkflow(ctx, CopyCalendarChangesWorkflowArgs{
+		CopyCalendarWorkflowArgs: second,
+		Changes:                  []*calendar.Event{changed},
+	}))
+	assert.Equal(t, []string{"[A] 1:1", "[A] all hands", "[B] review"}, summaries())
+
+	// once the copy is gone, the other source copies the event
+	require.NoError(t, provider.DeleteEvent(ctx, "source", shared.Id, ""))
+	for _, args := range []CopyCalendarWorkflowArgs{first, second} {
+		_, err := w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"[A] 1:1", "[B] all hands", "[B] review"}, summaries())
+}
+
+func TestCopyCalendarWorkflowFilters(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := CopyCalendarWorkflowArgs{SourceCalendarID: "source", DestinationCalendarID: "destination"}
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	_, err := provider.InsertEvent(ctx, "source", timedEvent("meeting", start, time.Hour))
+	require.NoError(t, err)
+	_, err = provider.InsertEvent(ctx, "source", timedEvent("lunch", start, time.Hour))
+	require.NoError(t, err)
+
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	require.Len(t, provider.Events("destination"), 2)
+
+	// copies of events which are filtered out later are removed
+	args.Filters = []persistence.FilterRule{{Action: persistence.FilterExclude, Field: persistence.FilterSummary, Value: "^lunch$"}}
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	copies := provider.Events("destination")
+	require.Len(t, copies, 1)
+	assert.Equal(t, "meeting", copies[0].Summary)
+}
+
+func TestCopyCalendarWorkflowConcurrentEdits(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider, db := newTestWorkflowsWithDatabase(t)
+	args := CopyCalendarWorkflowArgs{CopyID: 1, SourceCalendarID: "source", DestinationCalendarID: "destination"}
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	source, err := provider.InsertEvent(ctx, "source", timedEvent("meeting", start, time.Hour))
+	require.NoError(t, err)
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	// a copy changed in between the listing and the patch is patched again
+	var edited atomic.Bool
+	provider.OnWrite(func(calendarID, eventID string) {
+		if calendarID == "destination" && edited.CompareAndSwap(false, true) {
+			_, err := provider.PatchEvent(ctx, calendarID, eventID, &calendar.Event{ColorId: "5"})
+			require.NoError(t, err)
+		}
+	})
+
+	_, err = provider.PatchEvent(ctx, "source", source.Id, &calendar.Event{Summary: "renamed"})
+	require.NoError(t, err)
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	copies := provider.Events("destination")
+	require.Len(t, copies, 1)
+	assert.Equal(t, "renamed", copies[0].Summary)
+	assert.Equal(t, "5", copies[0].ColorId)
+
+	// a copy which keeps changing is given up, and recorded
+	var editing atomic.Bool
+	provider.OnWrite(func(calendarID, eventID string) {
+		if calendarID == "destination" && editing.CompareAndSwap(false, true) {
+			defer editing.Store(false)
+			_, err := provider.PatchEvent(ctx, calendarID, eventID, &calendar.Event{Description: time.Now().String()})
+			require.NoError(t, err)
+		}
+	})
+
+	_, err = provider.PatchEvent(ctx, "source", source.Id, &calendar.Event{Summary: "renamed again"})
+	require.NoError(t, err)
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	copies = provider.Events("destination")
+	require.Len(t, copies, 1)
+	assert.Equal(t, "renamed", copies[0].Summary)
+
+	conflicts, err := db.GetConflicts(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, source.Id, conflicts[0].SourceEventID)
+	assert.Equal(t, copies[0].Id, conflicts[0].DestinationEventID)
+	assert.Equal(t, persistence.ConflictNoWinner, conflicts[0].Winner)
+}
+
+func TestCopyCalendarWorkflowDryRun(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := CopyCalendarWorkflowArgs{SourceCalendarID: "source", DestinationCalendarID: "destination"}
+	dryRun := args
+	dryRun.DryRun = true
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	renamed, err := provider.InsertEvent(ctx, "source", timedEvent("meeting", start, time.Hour))
+	require.NoError(t, err)
+	removed, err := provider.InsertEvent(ctx, "source", timedEvent("lunch", start, time.Hour))
+	require.NoError(t, err)
+
+	result, err := w.CopyCalendarWorkflow(ctx, dryRun)
+	require.NoError(t, err)
+	require.Len(t, result.Changes, 2)
+	assert.Equal(t, CopyActionCreate, result.Changes[0].Action)
+	assert.Equal(t, CopyActionCreate, result.Changes[1].Action)
+	assert.Empty(t, provider.Events("destination"))
+
+	// a dry run must not skip changes the next incremental sync would need
+	changes, err := w.a.GetChangedEvents(ctx, activities.GetChangedEventsArgs{CalendarID: "source"})
+	require.NoError(t, err)
+	assert.True(t, changes.FullSyncRequired)
+
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	require.Len(t, provider.Events("destination"), 2)
+
+	_, err = provider.PatchEvent(ctx, "source", renamed.Id, &calendar.Event{Summary: "renamed"})
+	require.NoError(t, err)
+	require.NoError(t, provider.DeleteEvent(ctx, "source", removed.Id, ""))
+
+	result, err = w.CopyCalendarWorkflow(ctx, dryRun)
+	require.NoError(t, err)
+	require.Len(t, result.Changes, 2)
+
+	assert.Equal(t, CopyActionDelete, result.Changes[0].Action)
+	assert.Equal(t, "lunch", result.Changes[0].Summary)
+
+	assert.Equal(t, CopyActionUpdate, result.Changes[1].Action)
+	assert.Equal(t, []FieldDiff{{Field: "summary", Source: "renamed", Destination: "meeting"}}, result.Changes[1].Diffs)
+
+	summaries := pkg.ToSet(provider.Events("destination"), func(e *calendar.Event) string { return e.Summary })
+	assert.Equal(t, map[string]struct{}{"meeting": {}, "lunch": {}}, summaries)
+}
+
+func TestCopyCalendarWorkflowBidirectional(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := CopyCalendarWorkflowArgs{
+		SourceCalendarID:      "source",
+		DestinationCalendarID: "destination",
+		Bidirectional:         true,
+		ConflictPolicy:        persistence.ConflictSourceWins,
+	}
+
+	source, err := provider.InsertEvent(ctx, "source", timedEvent("meeting", time.Now().Add(time.Hour).Truncate(time.Minute), time.Hour))
+	require.NoError(t, err)
+
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	copies := provider.Events("destination")
+	require.Len(t, copies, 1)
+	destID := copies[0].Id
+	assert.NotEmpty(t, getExtraByKey(copies[0], pkg.SyncedFingerprintKey))
+
+	getSummaries := func() (string, string) {
+		s, err := provider.GetEvent(ctx, "source", source.Id)
+		require.NoError(t, err)
+		d, err := provider.GetEvent(ctx, "destination", destID)
+		require.NoError(t, err)
+		return s.Summary, d.Summary
+	}
+
+	// edits of the copy are copied back, once
+	_, err = provider.PatchEvent(ctx, "destination", destID, &calendar.Event{Summary: "edited copy"})
+	require.NoError(t, err)
+
+	result, err := w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	require.Len(t, result.Changes, 1)
+	assert.Equal(t, CopyActionUpdateSource, result.Changes[0].Action)
+
+	sourceSummary, destSummary := getSummaries()
+	assert.Equal(t, "edited copy", sourceSummary)
+	assert.Equal(t, "edited copy", destSummary)
+
+	result, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	assert.Empty(t, result.Changes)
+
+	// edits of the source are copied as usual
+	_, err = provider.PatchEvent(ctx, "source", source.Id, &calendar.Event{Summary: "edited source"})
+	require.NoError(t, err)
+
+	result, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+	require.Len(t, result.Changes, 1)
+	assert.Equal(t, CopyActionUpdate, result.Changes[0].Action)
+
+	sourceSummary, destSummary = getSummaries()
+	assert.Equal(t, "edited source", sourceSummary)
+	assert.Equal(t, "edited source", destSummary)
+
+	// the source wins conflicts by default
+	_, err = provider.PatchEvent(ctx, "destination", destID, &calendar.Event{Summary: "conflict copy"})
+	require.NoError(t, err)
+	_, err = provider.PatchEvent(ctx, "source", source.Id, &calendar.Event{Summary: "conflict source"})
+	require.NoError(t, err)
+
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	sourceSummary, destSummary = getSummaries()
+	assert.Equal(t, "conflict source", sourceSummary)
+	assert.Equal(t, "conflict source", destSummary)
+
+	// or the last edit wins
+	args.ConflictPolicy = persistence.ConflictLastModifiedWins
+
+	_, err = provider.PatchEvent(ctx, "source", source.Id, &calendar.Event{Summary: "older source"})
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+	_, err = provider.PatchEvent(ctx, "destination", destID, &calendar.Event{Summary: "newer copy"})
+	require.NoError(t, err)
+
+	_, err = w.CopyCalendarWorkflow(ctx, args)
+	require.NoError(t, err)
+
+	sourceSummary, destSummary = getSummaries()
+	assert.Equal(t, "newer copy", sourceSummary)
+	assert.Equal(t, "newer copy", destSummary)
+
+	// edits which can't be copied back fail the copy, so it's retried
+	_, err = provider.PatchEvent(ctx, "destination", destID, &calendar.Event{Summary: "lost edit"})
+	require.NoError(t, err)
+
+	var deleted atomic.Bool
+	provider.OnWrite(func(calendarID, eventID string) {
+		if calendarID == "source" && deleted.CompareAndSwap(false, true) {
+			require.NoError(t, provider.DeleteEvent(ctx, calendarID, eventID, ""))
+		}
+	})
+
+	result, err = w.CopyCalendarWorkflow(ctx, args)
+	require.Error(t, err)
+	assert.Equal(t, 1, result.FailedWrites)
+}
+
+func TestCopyCalendarWorkflowChains(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	provider.AddCalendar(providers.CalendarInfo{ID: "third", Summary: "Third"})
+
+	forward := CopyCalendarWorkflowArgs{SourceCalendarID: "source", DestinationCalendarID: "destination"}
+	backward := CopyCalendarWorkflowArgs{SourceCalendarID: "destination", DestinationCalendarID: "source"}
+	onward := CopyCalendarWorkflowArgs{SourceCalendarID: "destination", DestinationCalendarID: "third"}
+
+	original, err := provider.InsertEvent(ctx, "source", timedEvent("meeting", time.Now().Add(time.Hour).Truncate(time.Minute), time.Hour))
+	require.NoError(t, err)
+
+	// copies are never copied back to where they came from
+	for range 3 {
+		for _, args := range []CopyCalendarWorkflowArgs{forward, backward} {
+			_, err = w.CopyCalendarWorkflow(ctx, args)
+			require.NoError(t, err)
+		}
+	}
+	assert.Len(t, provider.Events("source"), 1)
+	assert.Len(t, provider.Events("destination"), 1)
+
+	// copies are skipped
+	onward.ChainMode = persistence.ChainSkip
+	_, err = w.CopyCalendarWorkflow(ctx, onward)
+	require.NoError(t, err)
+	assert.Empty(t, provider.Events("third"))
+
+	// or copied with their origin
+	onward.ChainMode = persistence.ChainTransitive
+	_, err = w.CopyCalendarWorkflow(ctx, onward)
+	require.NoError(t, err)
+
+	copies := provider.Events("third")
+	require.Len(t, copies, 1)
+	assert.Equal(t, "destination", getExtraByKey(copies[0], pkg.SourceCalendarIDKey))
+	assert.Equal(t, "source", getExtraByKey(copies[0], pkg.OriginCalendarIDKey))
+	assert.Equal(t, original.Id, getExtraByKey(copies[0], pkg.OriginCalendarItemIDKey))
+}
+
+func TestCopyCalendarWorkflowRecurring(t *testing.T) {
+	t.Parallel()
+
+	const day = 24 * time.Hour
+	start := time.Now().Add(day).Truncate(time.Hour).UTC()
+
+	instance := func(masterID string, idx int, e *calendar.Event) *calendar.Event {
+		e.RecurringEventId = masterID
+		e.OriginalStartTime = &calendar.EventDateTime{DateTime: start.Add(time.Duration(idx) * day).Format(time.RFC3339)}
+		return e
+	}
+
+	// a daily series of five, with the second instance moved, the third cancelled and the fourth excluded
+	setup := func(t *testing.T) (*Workflows, *memory.Provider, *calendar.Event) {
+		ctx := t.Context()
+		w, provider := newTestWorkflows(t)
+
+		master := timedEvent("standup", start, 15*time.Minute)
+		master.Recurrence = []string{"RRULE:FREQ=DAILY;COUNT=5", "EXDATE:" + start.Add(3*day).Format("20060102T150405Z")}
+		master, err := provider.InsertEvent(ctx, "source", master)
+		require.NoError(t, err)
+
+		moved := instance(master.Id, 1, timedEvent("late standup", start.Add(day+time.Hour), 15*time.Minute))
+		_, err = provider.InsertEvent(ctx, "source", moved)
+		require.NoError(t, err)
+
+		cancelled := instance(master.Id, 2, timedEvent("standup", start.Add(2*day), 15*time.Minute))
+		cancelled.Status = "cancelled"
+		_, err = provider.InsertEvent(ctx, "source", cancelled)
+		require.NoError(t, err)
+
+		return w, provider, master
+	}
+
+	summaries := func(events []*calendar.Event) map[string]string {
+		result := make(map[string]string)
+		for _, e := range events {
+			result[e.Start.DateTime] = e.Summary
+			if e.Status == "cancelled" {
+				result[e.Start.DateTime] = "cancelled"
+			}
+		}
+		return result
+	}
+
+	t.Run("expand", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := t.Context()
+		w, provider, master := setup(t)
+		args := CopyCalendarWorkflowArgs{
+			SourceCalendarID:      "source",
+			DestinationCalendarID: "destination",
+			Recurrence:            persistence.RecurrenceExpand,
+		}
+
+		_, err := w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+
+		copies := provider.Events("destination")
+		assert.Equal(t, map[string]string{
+			start.Format(time.RFC3339):                      "standup",
+			start.Add(day + time.Hour).Format(time.RFC3339): "late standup",
+			start.Add(4 * day).Format(time.RFC3339):         "standup",
+		}, summaries(copies))
+		for _, e := range copies {
+			assert.Empty(t, e.Recurrence)
+			assert.Empty(t, e.RecurringEventId)
+		}
+
+		// cancelling an instance removes its copy
+		cancelled := instance(master.Id, 4, timedEvent("standup", start.Add(4*day), 15*time.Minute))
+		cancelled.Status = "cancelled"
+		_, err = provider.InsertEvent(ctx, "source", cancelled)
+		require.NoError(t, err)
+
+		_, err = w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+		assert.Len(t, provider.Events("destination"), 2)
+	})
+
+	t.Run("series", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := t.Context()
+		w, provider, master := setup(t)
+		args := CopyCalendarWorkflowArgs{SourceCalendarID: "source", DestinationCalendarID: "destination"}
+
+		_, err := w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+
+		copies := provider.Events("destination")
+		require.Len(t, copies, 3)
+		assert.Equal(t, map[string]string{
+			start.Format(time.RFC3339):                      "standup",
+			start.Add(day + time.Hour).Format(time.RFC3339): "late standup",
+			start.Add(2 * day).Format(time.RFC3339):         "cancelled",
+		}, summaries(copies))
+
+		var copiedMaster *calendar.Event
+		for _, e := range copies {
+			if e.RecurringEventId == "" {
+				copiedMaster = e
+			}
+		}
+		require.NotNil(t, copiedMaster)
+		assert.Equal(t, master.Recurrence, copiedMaster.Recurrence)
+		for _, e := range copies {
+			if e != copiedMaster {
+				assert.Equal(t, copiedMaster.Id, e.RecurringEventId)
+			}
+		}
+
+		// which is stable
+		result, err := w.CopyCalendarWorkflow(ctx, CopyCalendarWorkflowArgs{
+			SourceCalendarID:      "source",
+			DestinationCalendarID: "destination",
+			DryRun:                true,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, result.Changes)
+
+		// instances cancelled later, or filtered out, are cancelled on the copy
+		cancelled := instance(master.Id, 4, timedEvent("standup", start.Add(4*day), 15*time.Minute))
+		cancelled.Status = "cancelled"
+		_, err = provider.InsertEvent(ctx, "source", cancelled)
+		require.NoError(t, err)
+		assert.True(t, args.changesNeedFullSync([]*calendar.Event{cancelled}))
+
+		args.Filters = []persistence.FilterRule{{Action: persistence.FilterExclude, Field: persistence.FilterSummary, Value: "^late"}}
+		_, err = w.CopyCalendarWorkflow(ctx, args)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{
+			start.Format(time.RFC3339):                      "standup",
+			start.Add(day + time.Hour).Format(time.RFC3339): "cancelled",
+			start.Add(2 * day).Format(time.RFC3339):         "cancelled",
+			start.Add(4 * day).Format(time.RFC3339):         "cancelled",
+		}, summaries(provider.Events("destination")))
+	})
+
+	t.Run("series into a calendar without recurrence", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := t.Context()
+		w, provider, _ := setup(t)
+		provider.AddCalendar(providers.CalendarInfo{ID: "caldav"})
+		provider.DisableRecurrence("caldav")
+
+		// like caldav, the calendar can't store instances on their own, so series are expanded
+		result, err := w.CopyCalendarWorkflow(ctx, CopyCalendarWorkflowArgs{
+			SourceCalendarID:      "source",
+			DestinationCalendarID: "caldav",
+		})
+		require.NoError(t, err)
+		assert.Zero(t, result.FailedWrites)
+
+		copies := provider.Events("caldav")
+		assert.Len(t, copies, 3)
+		for _, e := range copies {
+			assert.Empty(t, e.RecurringEventId)
+		}
+	})
+}
+
+func TestCopyAllWorkflow(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider, db := newTestWorkflowsWithDatabase(t)
+	require.NoError(t, db.CreateCopyConfig(ctx, "source", "destination"))
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	_, err := provider.InsertEvent(ctx, "source", timedEvent("meeting", start, time.Hour))
+	require.NoError(t, err)
+
+	// copies are synced by queued jobs, which are only queued once
+	require.NoError(t, w.CopyAllWorkflow(ctx))
+	require.NoError(t, w.CopyAllWorkflow(ctx))
+	assert.Empty(t, provider.Events("destination"))
+
+	job, err := db.ClaimJob(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, JobCopyCalendar, job.Kind)
+	_, err = db.ClaimJob(ctx, time.Now())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, w.Jobs()[job.Kind](ctx, json.RawMessage(job.Args)))
+	copies := provider.Events("destination")
+	require.Len(t, copies, 1)
+	assert.Equal(t, "meeting", copies[0].Summary)
+}
+
+func TestBuildFeedWorkflow(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider, db := newTestWorkflowsWithDatabase(t)
+	require.NoError(t, db.CreateCopyConfig(ctx, "source", "destination"))
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	_, err := provider.InsertEvent(ctx, "source", timedEvent("meeting", start, time.Hour))
+	require.NoError(t, err)
+	_, err = w.CopyCalendarWorkflow(ctx, CopyCalendarWorkflowArgs{SourceCalendarID: "source", DestinationCalendarID: "destination"})
+	require.NoError(t, err)
+
+	// feeds don't reveal the calendars which were copied
+	for _, merged := range []bool{false, true} {
+		result, err := w.BuildFeedWorkflow(ctx, BuildFeedWorkflowArgs{CalendarID: "destination", Merged: merged})
+		require.NoError(t, err)
+		require.Len(t, result.Items, 1)
+		assert.Equal(t, "meeting", result.Items[0].Summary)
+		assert.Nil(t, result.Items[0].ExtendedProperties)
+		assert.Empty(t, result.Items[0].Attendees)
+	}
+}
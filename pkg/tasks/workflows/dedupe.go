@@ -0,0 +1,49 @@
+package workflows
+
+import (
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg"
+)
+
+// duplicateKey identifies an event across the calendars it appears in. Instances of a series share the series'
+// iCalUID, so they're told apart by when they were meant to start.
+func duplicateKey(e *calendar.Event) string {
+	if e.ICalUID == "" {
+		return ""
+	}
+	if e.OriginalStartTime == nil {
+		return e.ICalUID
+	}
+
+	return e.ICalUID + " " + formatEventDateTime(normalizeDateTime(e.OriginalStartTime))
+}
+
+// copiedElsewhere reports whether an event is left to the copies from other sources. A copy which already exists is
+// kept, and of copies made at the same time the one from the first source. Once the other copies are gone, the event
+// is copied again on the next sync.
+func (args CopyCalendarWorkflowArgs) copiedElsewhere(otherSources []string, hasCopy bool) bool {
+	if !args.Deduplicate {
+		return false
+	}
+
+	for _, other := range otherSources {
+		if !hasCopy || other < args.SourceCalendarID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// otherSources returns the sources of the copies which aren't the copy's own.
+func (args CopyCalendarWorkflowArgs) otherSources(copies []*calendar.Event) []string {
+	var sources []string
+	for _, e := range copies {
+		if source := getExtraByKey(e, pkg.SourceCalendarIDKey); source != "" && source != args.SourceCalendarID && !isBuffer(e) && !isBlock(e) {
+			sources = append(sources, source)
+		}
+	}
+
+	return sources
+}
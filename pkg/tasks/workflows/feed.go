@@ -0,0 +1,98 @@
+package workflows
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/tasks/activities"
+)
+
+type BuildFeedWorkflowArgs struct {
+	CalendarID string
+	Merged     bool
+}
+
+type BuildFeedWorkflowResult struct {
+	Summary string
+	Items   []*calendar.Event
+}
+
+// BuildFeedWorkflow collects the events to publish for a calendar. A merged feed also includes the events of every
+// calendar copied into it, in place of the copies themselves. Private extended properties and guests are left out.
+func (w *Workflows) BuildFeedWorkflow(ctx context.Context, args BuildFeedWorkflowArgs) (BuildFeedWorkflowResult, error) {
+	ctx, _ = setupLogger(ctx, "BuildFeedWorkflow")
+
+	var result BuildFeedWorkflowResult
+
+	eventsResult, err := w.a.GetCalendarEventsActivity(ctx, activities.GetCalendarEventsActivityArgs{
+		CalendarID: args.CalendarID,
+	})
+	if err != nil {
+		return result, errors.Wrap(err, "failed to get calendar events")
+	}
+
+	result.Summary = eventsResult.Calendar.Summary
+	items := eventsResult.Calendar.Items
+
+	if args.Merged {
+		copyConfigsResult, err := w.a.GetCopyConfigsForDestinationCalendar(ctx, activities.GetCopyConfigsForDestinationCalendarArgs{
+			CalendarID: args.CalendarID,
+		})
+		if err != nil {
+			return result, errors.Wrap(err, "failed to get copy configs")
+		}
+
+		sources := pkg.ToMap(copyConfigsResult.CopyConfigs, func(c persistence.CopyConfig) string { return c.SourceID })
+		items = pkg.Filter(items, func(item *calendar.Event) bool {
+			_, isCopy := sources[getExtraByKey(item, pkg.SourceCalendarIDKey)]
+			return !isCopy
+		})
+
+		for sourceID, config := range sources {
+			sourceItems, err := w.getEvents(ctx, sourceID, time.Time{}, time.Time{})
+			if err != nil {
+				return result, errors.Wrapf(err, "failed to get events from %s", sourceID)
+			}
+
+			// the feed must not show more than the copies would
+			copyArgs := CopyCalendarWorkflowArgsFromConfig(config)
+			filter, err := copyArgs.filter()
+			if err != nil {
+				return result, errors.Wrapf(err, "failed to build filter for %s", sourceID)
+			}
+
+			for _, item := range pkg.Filter(sourceItems, filter.Matches) {
+				redacted := copyArgs.render(*logs.GetLogger(ctx), copyArgs.redact(*item))
+				items = append(items, &redacted)
+			}
+		}
+	}
+
+	// the same event may be visible in more than one calendar
+	seen := make(map[string]struct{})
+	for _, item := range items {
+		uid := item.ICalUID
+		if uid == "" {
+			uid = item.Id
+		}
+
+		if _, ok := seen[uid]; ok {
+			continue
+		}
+		seen[uid] = struct{}{}
+
+		// feeds are public, the private properties hold the IDs of other calendars and guests are left private too
+		item.ExtendedProperties = nil
+		item.Attendees = nil
+
+		result.Items = append(result.Items, item)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,188 @@
+package workflows
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/persistence"
+)
+
+// optionalFields are the parts of events which copies are kept in sync on besides their text and time, including
+// the ones which are set to the same value on every copy.
+func (args CopyCalendarWorkflowArgs) optionalFields() []persistence.CopyField {
+	fields := slices.Clone(args.Fields)
+	if args.ColorID != "" {
+		fields = append(fields, persistence.CopyColor)
+	}
+	if args.NoReminders {
+		fields = append(fields, persistence.CopyReminders)
+	}
+
+	slices.Sort(fields)
+	return slices.Compact(fields)
+}
+
+// override sets the values the copy config gives every copy.
+func (args CopyCalendarWorkflowArgs) override(e calendar.Event) calendar.Event {
+	if args.ColorID != "" {
+		e.ColorId = args.ColorID
+	}
+	if args.NoReminders {
+		e.Reminders = &calendar.EventReminders{ForceSendFields: []string{"UseDefault"}}
+	}
+	return e
+}
+
+func copyOptionalFields(to *calendar.Event, from calendar.Event, fields []persistence.CopyField) {
+	for _, field := range fields {
+		switch field {
+		case persistence.CopyAttendees:
+			to.Attendees = from.Attendees
+		case persistence.CopyReminders:
+			to.Reminders = from.Reminders
+		case persistence.CopyColor:
+			to.ColorId = from.ColorId
+		case persistence.CopyTransparency:
+			to.Transparency = from.Transparency
+		case persistence.CopyVisibility:
+			to.Visibility = from.Visibility
+		case persistence.CopyConferenceData:
+			to.ConferenceData = from.ConferenceData
+		case persistence.CopyAttachments:
+			to.Attachments = from.Attachments
+		}
+	}
+}
+
+// diffOptionalFields adds the optional fields which differ to the patch. Fields the calendar fills in on its own, like
+// the display names of attendees, aren't compared.
+func diffOptionalFields(p *patchable[calendar.Event], fields []persistence.CopyField) {
+	patch := p.patch
+
+	for _, field := range fields {
+		switch field {
+		case persistence.CopyAttendees:
+			patched := diffKey(p, "attendees", attendeesKey, func(e *calendar.Event) *[]*calendar.EventAttendee { return &e.Attendees })
+			if patched && len(patch.Attendees) == 0 {
+				patch.ForceSendFields = append(patch.ForceSendFields, "Attendees")
+			}
+
+		case persistence.CopyReminders:
+			patched := diffKey(p, "reminders", remindersKey, func(e *calendar.Event) **calendar.EventReminders { return &e.Reminders })
+			if patched && patch.Reminders == nil {
+				// copies of events without reminders get the calendar's default reminders
+				patch.Reminders = &calendar.EventReminders{UseDefault: true}
+			}
+
+		case persistence.CopyColor:
+			diff(p, "color", func(e *calendar.Event) *string { return &e.ColorId })
+			if p.from.ColorId == "" && p.to.ColorId != "" {
+				patch.ForceSendFields = append(patch.ForceSendFields, "ColorId")
+			}
+
+		case persistence.CopyTransparency:
+			p.from.Transparency = cmp.Or(p.from.Transparency, "opaque")
+			p.to.Transparency = cmp.Or(p.to.Transparency, "opaque")
+			diff(p, "transparency", func(e *calendar.Event) *string { return &e.Transparency })
+
+		case persistence.CopyVisibility:
+			p.from.Visibility = cmp.Or(p.from.Visibility, "default")
+			p.to.Visibility = cmp.Or(p.to.Visibility, "default")
+			diff(p, "visibility", func(e *calendar.Event) *string { return &e.Visibility })
+
+		case persistence.CopyConferenceData:
+			patched := diffKey(p, "conference_data", conferenceKey, func(e *calendar.Event) **calendar.ConferenceData { return &e.ConferenceData })
+			if patched && patch.ConferenceData == nil {
+				patch.NullFields = append(patch.NullFields, "ConferenceData")
+			}
+
+		case persistence.CopyAttachments:
+			patched := diffKey(p, "attachments", attachmentsKey, func(e *calendar.Event) *[]*calendar.EventAttachment { return &e.Attachments })
+			if patched && len(patch.Attachments) == 0 {
+				patch.ForceSendFields = append(patch.ForceSendFields, "Attachments")
+			}
+		}
+	}
+}
+
+// diffKey diffs fields which are compared by what identifies them rather than their whole value, and returns whether
+// the field is patched.
+func diffKey[T any](p *patchable[calendar.Event], field string, key func(T) string, fn func(e *calendar.Event) *T) bool {
+	from := *fn(p.from)
+	fromKey := key(from)
+	toKey := key(*fn(p.to))
+
+	if fromKey == toKey {
+		return false
+	}
+
+	p.log.Info().
+		Str("source", fromKey).
+		Str("destination", toKey).
+		Msgf("%s is different", field)
+
+	p.record(field, fromKey, toKey)
+
+	*fn(p.patch) = from
+	p.shouldPatch = true
+
+	return true
+}
+
+func attendeesKey(attendees []*calendar.EventAttendee) string {
+	keys := make([]string, 0, len(attendees))
+	for _, attendee := range attendees {
+		key := strings.ToLower(attendee.Email)
+		if attendee.Optional {
+			key += " (optional)"
+		}
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+	return strings.Join(keys, ", ")
+}
+
+func remindersKey(reminders *calendar.EventReminders) string {
+	if reminders == nil || reminders.UseDefault {
+		return "default"
+	}
+
+	keys := make([]string, 0, len(reminders.Overrides))
+	for _, reminder := range reminders.Overrides {
+		keys = append(keys, fmt.Sprintf("%s %dm", reminder.Method, reminder.Minutes))
+	}
+	if len(keys) == 0 {
+		return "none"
+	}
+
+	slices.Sort(keys)
+	return strings.Join(keys, ", ")
+}
+
+func conferenceKey(conference *calendar.ConferenceData) string {
+	if conference == nil {
+		return ""
+	}
+
+	keys := []string{conference.ConferenceId}
+	for _, entryPoint := range conference.EntryPoints {
+		keys = append(keys, entryPoint.Uri)
+	}
+
+	return strings.Join(keys, " ")
+}
+
+func attachmentsKey(attachments []*calendar.EventAttachment) string {
+	keys := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		keys = append(keys, attachment.FileUrl)
+	}
+
+	slices.Sort(keys)
+	return strings.Join(keys, ", ")
+}
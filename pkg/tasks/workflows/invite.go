@@ -2,21 +2,67 @@ package workflows
 
 import (
 	"context"
+	"slices"
+	"strings"
 	"sync"
 
+	"github.com/pkg/errors"
 	"google.golang.org/api/calendar/v3"
 
+	"calendar-sync/pkg/filters"
+	"calendar-sync/pkg/persistence"
 	"calendar-sync/pkg/tasks/activities"
 )
 
 type InviteCalendarWorkflowArgs struct {
+	// InviteID is the invite config the guests are recorded for. Without it guests aren't recorded, and aren't removed
+	// from events which stop matching.
+	InviteID   int
 	CalendarID string
 	EmailToAdd string
+	// GroupID invites the group's members as well.
+	GroupID int
+
+	// the conditions events must meet to be invited to, see persistence.InviteConfig
+	SummaryPattern    string
+	OnlyWithAttendees bool
+	OnlyOwnEvents     bool
+	WeekdaysOnly      bool
+	ExcludeAllDay     bool
+
+	Optional    bool
+	SendUpdates persistence.SendUpdates
+}
+
+func InviteCalendarWorkflowArgsFromConfig(config persistence.InviteConfig) InviteCalendarWorkflowArgs {
+	return InviteCalendarWorkflowArgs{
+		InviteID:          config.ID,
+		CalendarID:        config.CalendarID,
+		EmailToAdd:        config.EmailAddress,
+		GroupID:           config.GroupID,
+		SummaryPattern:    config.SummaryPattern,
+		OnlyWithAttendees: config.OnlyWithAttendees,
+		OnlyOwnEvents:     config.OnlyOwnEvents,
+		WeekdaysOnly:      config.WeekdaysOnly,
+		ExcludeAllDay:     config.ExcludeAllDay,
+		Optional:          config.Optional,
+		SendUpdates:       config.SendUpdates,
+	}
 }
 
 func (w *Workflows) InviteCalendarWorkflow(ctx context.Context, args InviteCalendarWorkflowArgs) error {
 	ctx, log := setupLogger(ctx, "InviteCalendarWorkflow")
 
+	emails, err := w.invitees(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	filter, err := args.filter(emails)
+	if err != nil {
+		return err
+	}
+
 	// get events from calendar
 	eventArgs := activities.GetCalendarEventsActivityArgs{
 		CalendarID: args.CalendarID,
@@ -27,27 +73,55 @@ func (w *Workflows) InviteCalendarWorkflow(ctx context.Context, args InviteCalen
 		return err
 	}
 
-	// find missing guests
+	invited := map[string][]string{}
+	if args.InviteID != 0 {
+		guestsResult, err := w.a.GetInvitedGuests(ctx, activities.GetInvitedGuestsArgs{InviteID: args.InviteID})
+		if err != nil {
+			return err
+		}
+		for _, guest := range guestsResult.Guests {
+			invited[guest.EventID] = append(invited[guest.EventID], guest.EmailAddress)
+		}
+	}
+
+	// diff the guests each event should have against the ones it has, and change them in a single patch
 	var wg sync.WaitGroup
 	for _, item := range eventResult.Calendar.Items {
-		if guestsContains(item.Attendees, args.EmailToAdd) {
+		if add, remove := guestChanges(item, filter, emails, invited[item.Id]); len(add) == 0 && len(remove) == 0 {
 			continue
 		}
 
-		inviteArgs := activities.InviteGuestArgs{
-			CalendarID:           args.CalendarID,
-			CalendarItemID:       item.Id,
-			EmailAddressToInvite: args.EmailToAdd,
-			Attendees:            item.Attendees,
-		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if _, err := w.a.UpdateGuestList(ctx, inviteArgs); err != nil {
+
+			// events changed in the meantime are diffed again, so the other changes to their guests are kept
+			err := w.retryWrite(ctx, args.CalendarID, item, func(item *calendar.Event) error {
+				add, remove := guestChanges(item, filter, emails, invited[item.Id])
+				if len(add) == 0 && len(remove) == 0 {
+					return nil
+				}
+
+				inviteArgs := activities.InviteGuestArgs{
+					InviteID:               args.InviteID,
+					CalendarID:             args.CalendarID,
+					CalendarItemID:         item.Id,
+					Etag:                   item.Etag,
+					Attendees:              item.Attendees,
+					EmailAddressesToInvite: add,
+					EmailAddressesToRemove: remove,
+					Optional:               args.Optional,
+					SendUpdates:            string(args.SendUpdates),
+				}
+				_, err := w.a.UpdateGuestList(ctx, inviteArgs)
+				return err
+			})
+			if isWriteConflict(err) {
+				w.recordWriteConflict(ctx, persistence.Conflict{DestinationEventID: item.Id, Summary: item.Summary})
+			} else if err != nil {
 				log.Error().Err(err).
 					Str("calendar-id", args.CalendarID).
 					Str("calendar-item-id", item.Id).
-					Str("email-address", args.EmailToAdd).
 					Msg("failed to update guest list")
 			}
 		}()
@@ -57,9 +131,96 @@ func (w *Workflows) InviteCalendarWorkflow(ctx context.Context, args InviteCalen
 	return nil
 }
 
+// guestChanges are the invitees an event is missing, and the ones which were invited but aren't wanted anymore.
+func guestChanges(item *calendar.Event, filter filters.Filter, invitees, invited []string) (add, remove []string) {
+	var want []string
+	if filter.Matches(item) {
+		want = invitees
+	}
+
+	for _, email := range want {
+		if !guestsContains(item.Attendees, email) {
+			add = append(add, email)
+		}
+	}
+	for _, email := range invited {
+		if !slices.ContainsFunc(want, func(w string) bool { return strings.EqualFold(w, email) }) {
+			remove = append(remove, email)
+		}
+	}
+
+	return add, remove
+}
+
+// invitees are the addresses the invite adds to events, its own and its group's.
+func (w *Workflows) invitees(ctx context.Context, args InviteCalendarWorkflowArgs) ([]string, error) {
+	var emails []string
+	if args.EmailToAdd != "" {
+		emails = append(emails, args.EmailToAdd)
+	}
+
+	if args.GroupID != 0 {
+		groupResult, err := w.a.GetGroup(ctx, activities.GetGroupArgs{GroupID: args.GroupID})
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range groupResult.Group.Members {
+			if !slices.ContainsFunc(emails, func(email string) bool { return strings.EqualFold(email, member) }) {
+				emails = append(emails, member)
+			}
+		}
+	}
+
+	return emails, nil
+}
+
+// filter turns the invite's conditions into a filter of the events the guest is invited to.
+func (args InviteCalendarWorkflowArgs) filter(invitees []string) (filters.Filter, error) {
+	var rules []persistence.FilterRule
+	if args.SummaryPattern != "" {
+		rules = append(rules, persistence.FilterRule{Action: persistence.FilterInclude, Field: persistence.FilterSummary, Value: args.SummaryPattern})
+	}
+	if args.WeekdaysOnly {
+		rules = append(rules, persistence.FilterRule{Action: persistence.FilterExclude, Field: persistence.FilterWeekday, Value: "sat,sun"})
+	}
+	if args.ExcludeAllDay {
+		rules = append(rules, persistence.FilterRule{Action: persistence.FilterExclude, Field: persistence.FilterAllDay, Value: "true"})
+	}
+
+	f, err := filters.New(rules)
+	if err != nil {
+		return f, errors.Wrap(err, "failed to build filter")
+	}
+
+	if args.OnlyWithAttendees {
+		f = f.Exclude(func(e *calendar.Event) bool { return !hasOtherAttendees(e, invitees) })
+	}
+	if args.OnlyOwnEvents {
+		f = f.Exclude(func(e *calendar.Event) bool { return !isOwnEvent(e) })
+	}
+
+	return f, nil
+}
+
+// hasOtherAttendees reports whether anyone besides the calendar's owner and the invitees is invited.
+func hasOtherAttendees(e *calendar.Event, invitees []string) bool {
+	for _, attendee := range e.Attendees {
+		if !attendee.Self && !slices.ContainsFunc(invitees, func(email string) bool { return strings.EqualFold(email, attendee.Email) }) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOwnEvent reports whether the calendar's owner organised or created the event, rather than being invited to it.
+func isOwnEvent(e *calendar.Event) bool {
+	return (e.Organizer != nil && e.Organizer.Self) || (e.Creator != nil && e.Creator.Self)
+}
+
 func guestsContains(guests []*calendar.EventAttendee, add string) bool {
 	for _, guest := range guests {
-		if guest.Email == add {
+		if strings.EqualFold(guest.Email, add) {
 			return true
 		}
 	}
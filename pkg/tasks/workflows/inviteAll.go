@@ -15,10 +15,7 @@ func (w *Workflows) InviteAllWorkflow(ctx context.Context) error {
 	}
 
 	for _, inviteConfig := range inviteConfigs.InviteConfigs {
-		args := InviteCalendarWorkflowArgs{
-			inviteConfig.CalendarID,
-			inviteConfig.EmailAddress,
-		}
+		args := InviteCalendarWorkflowArgsFromConfig(inviteConfig)
 		if err := w.InviteCalendarWorkflow(ctx, args); err != nil {
 			log.Error().Err(err).Msg("failed to trigger child workflow")
 		}
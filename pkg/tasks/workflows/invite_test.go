@@ -0,0 +1,218 @@
+package workflows
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/providers/memory"
+)
+
+func TestInviteCalendarWorkflow(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := InviteCalendarWorkflowArgs{
+		CalendarID:        "source",
+		EmailToAdd:        "assistant@example.com",
+		SummaryPattern:    "^1:1",
+		OnlyWithAttendees: true,
+		OnlyOwnEvents:     true,
+		WeekdaysOnly:      true,
+		ExcludeAllDay:     true,
+		Optional:          true,
+		SendUpdates:       persistence.SendUpdatesExternal,
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	monday := today.AddDate(0, 0, 1)
+	for monday.Weekday() != time.Monday {
+		monday = monday.AddDate(0, 0, 1)
+	}
+	monday = monday.Add(10 * time.Hour)
+	saturday := monday.AddDate(0, 0, 5)
+
+	event := func(summary string, start time.Time, own bool, attendees ...string) *calendar.Event {
+		e := timedEvent(summary, start, time.Hour)
+		e.Organizer = &calendar.EventOrganizer{Self: own}
+		for _, email := range attendees {
+			e.Attendees = append(e.Attendees, &calendar.EventAttendee{Email: email})
+		}
+		return e
+	}
+
+	allDay := event("1:1 offsite", monday, true, "alice@example.com")
+	allDay.Start = &calendar.EventDateTime{Date: monday.Format(time.DateOnly)}
+	allDay.End = &calendar.EventDateTime{Date: monday.AddDate(0, 0, 1).Format(time.DateOnly)}
+
+	ids := map[string]string{}
+	for _, e := range []*calendar.Event{
+		event("1:1 alice", monday, true, "alice@example.com"),
+		event("1:1 alone", monday, true),
+		event("1:1 weekend", saturday, true, "alice@example.com"),
+		event("standup", monday, true, "alice@example.com"),
+		event("1:1 theirs", monday, false, "alice@example.com"),
+		allDay,
+	} {
+		inserted, err := provider.InsertEvent(ctx, "source", e)
+		require.NoError(t, err)
+		ids[e.Summary] = inserted.Id
+	}
+
+	require.NoError(t, w.InviteCalendarWorkflow(ctx, args))
+
+	for summary, id := range ids {
+		e, err := provider.GetEvent(ctx, "source", id)
+		require.NoError(t, err)
+
+		if summary != "1:1 alice" {
+			assert.False(t, guestsContains(e.Attendees, args.EmailToAdd), summary)
+			continue
+		}
+
+		require.Len(t, e.Attendees, 2)
+		assert.Equal(t, "alice@example.com", e.Attendees[0].Email)
+		assert.Equal(t, args.EmailToAdd, e.Attendees[1].Email)
+		assert.True(t, e.Attendees[1].Optional)
+		assert.Equal(t, "externalOnly", provider.SentUpdates("source", id))
+	}
+}
+
+func TestInviteCalendarWorkflowRemovesGuests(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider := newTestWorkflows(t)
+	args := InviteCalendarWorkflowArgs{InviteID: 1, CalendarID: "source", EmailToAdd: "assistant@example.com", SummaryPattern: "^1:1"}
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	matching, err := provider.InsertEvent(ctx, "source", timedEvent("1:1 alice", start, time.Hour))
+	require.NoError(t, err)
+	other, err := provider.InsertEvent(ctx, "source", timedEvent("1:1 bob", start, time.Hour))
+	require.NoError(t, err)
+	byHand := timedEvent("planning", start, time.Hour)
+	byHand.Attendees = []*calendar.EventAttendee{{Email: "alice@example.com"}, {Email: args.EmailToAdd}}
+	byHand, err = provider.InsertEvent(ctx, "source", byHand)
+	require.NoError(t, err)
+
+	attendees := func(id string) []string { return attendeeEmails(t, provider, id) }
+
+	require.NoError(t, w.InviteCalendarWorkflow(ctx, args))
+	assert.Equal(t, []string{args.EmailToAdd}, attendees(matching.Id))
+	assert.Equal(t, []string{args.EmailToAdd}, attendees(other.Id))
+
+	// events which stop matching lose the guest, but only if it was added by the invite
+	_, err = provider.PatchEvent(ctx, "source", matching.Id, &calendar.Event{Summary: "standup"})
+	require.NoError(t, err)
+	require.NoError(t, w.InviteCalendarWorkflow(ctx, args))
+	assert.Empty(t, attendees(matching.Id))
+	assert.Equal(t, []string{"alice@example.com", args.EmailToAdd}, attendees(byHand.Id))
+
+	// revoking removes every guest which is left
+	require.NoError(t, w.RevokeInviteWorkflow(ctx, RevokeInviteWorkflowArgs{InviteID: args.InviteID}))
+	assert.Empty(t, attendees(other.Id))
+	assert.Equal(t, []string{"alice@example.com", args.EmailToAdd}, attendees(byHand.Id))
+}
+
+func TestInviteCalendarWorkflowGroup(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider, db := newTestWorkflowsWithDatabase(t)
+
+	require.NoError(t, db.CreateGroup(ctx, "team"))
+	groups, err := db.GetGroups(ctx)
+	require.NoError(t, err)
+	groupID := groups[0].ID
+	require.NoError(t, db.AddGroupMember(ctx, groupID, "alice@example.com"))
+	require.NoError(t, db.AddGroupMember(ctx, groupID, "bob@example.com"))
+
+	args := InviteCalendarWorkflowArgs{InviteID: 1, CalendarID: "source", GroupID: groupID}
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	meeting := timedEvent("meeting", start, time.Hour)
+	meeting.Attendees = []*calendar.EventAttendee{{Email: "carol@example.com"}}
+	meeting, err = provider.InsertEvent(ctx, "source", meeting)
+	require.NoError(t, err)
+	withBob := timedEvent("with bob", start, time.Hour)
+	withBob.Attendees = []*calendar.EventAttendee{{Email: "bob@example.com"}}
+	withBob, err = provider.InsertEvent(ctx, "source", withBob)
+	require.NoError(t, err)
+
+	attendees := func(id string) []string { return attendeeEmails(t, provider, id) }
+
+	require.NoError(t, w.InviteCalendarWorkflow(ctx, args))
+	assert.Equal(t, []string{"carol@example.com", "alice@example.com", "bob@example.com"}, attendees(meeting.Id))
+	assert.Equal(t, []string{"bob@example.com", "alice@example.com"}, attendees(withBob.Id))
+
+	// members who leave the group are removed from the events they were added to
+	require.NoError(t, db.RemoveGroupMember(ctx, groupID, "bob@example.com"))
+	require.NoError(t, w.InviteCalendarWorkflow(ctx, args))
+	assert.Equal(t, []string{"carol@example.com", "alice@example.com"}, attendees(meeting.Id))
+	assert.Equal(t, []string{"bob@example.com", "alice@example.com"}, attendees(withBob.Id))
+}
+
+func attendeeEmails(t *testing.T, provider *memory.Provider, eventID string) []string {
+	t.Helper()
+
+	e, err := provider.GetEvent(t.Context(), "source", eventID)
+	require.NoError(t, err)
+
+	var emails []string
+	for _, attendee := range e.Attendees {
+		emails = append(emails, attendee.Email)
+	}
+	return emails
+}
+
+func TestInviteCalendarWorkflowConcurrentEdits(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	w, provider, db := newTestWorkflowsWithDatabase(t)
+	args := InviteCalendarWorkflowArgs{CalendarID: "source", EmailToAdd: "assistant@example.com"}
+
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+	event, err := provider.InsertEvent(ctx, "source", timedEvent("meeting", start, time.Hour))
+	require.NoError(t, err)
+
+	// someone adds a guest in between the listing and the patch, once
+	var edited atomic.Bool
+	provider.OnWrite(func(calendarID, eventID string) {
+		if edited.CompareAndSwap(false, true) {
+			_, err := provider.PatchEvent(ctx, calendarID, eventID, &calendar.Event{Attendees: []*calendar.EventAttendee{{Email: "carol@example.com"}}})
+			require.NoError(t, err)
+		}
+	})
+
+	require.NoError(t, w.InviteCalendarWorkflow(ctx, args))
+	assert.Equal(t, []string{"carol@example.com", args.EmailToAdd}, attendeeEmails(t, provider, event.Id))
+
+	// an event which keeps changing is given up, and recorded
+	other, err := provider.InsertEvent(ctx, "source", timedEvent("busy", start, time.Hour))
+	require.NoError(t, err)
+
+	var editing atomic.Bool
+	provider.OnWrite(func(calendarID, eventID string) {
+		if editing.CompareAndSwap(false, true) {
+			defer editing.Store(false)
+			_, err := provider.PatchEvent(ctx, calendarID, eventID, &calendar.Event{Description: time.Now().String()})
+			require.NoError(t, err)
+		}
+	})
+
+	require.NoError(t, w.InviteCalendarWorkflow(ctx, args))
+	assert.Empty(t, attendeeEmails(t, provider, other.Id))
+
+	conflicts, err := db.GetConflicts(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, other.Id, conflicts[0].DestinationEventID)
+	assert.Equal(t, persistence.ConflictNoWinner, conflicts[0].Winner)
+}
@@ -0,0 +1,95 @@
+package workflows
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/providers"
+	"calendar-sync/pkg/tasks/activities"
+	"calendar-sync/pkg/tasks/queue"
+)
+
+// kinds of the jobs which are run by the queue.
+const (
+	JobProcessWebhookEvent = "process-webhook-event"
+	JobCopyCalendar        = "copy-calendar"
+	JobWatchCalendar       = "watch-calendar"
+	JobDeleteWatch         = "delete-watch"
+)
+
+type CopyCalendarJobArgs struct {
+	CopyID int
+}
+
+// Jobs are the handlers of the jobs workflows queue.
+func (w *Workflows) Jobs() map[string]queue.Handler {
+	return map[string]queue.Handler{
+		JobProcessWebhookEvent: handle(w.ProcessWebhookEvent),
+		JobCopyCalendar:        handle(w.copyCalendarJob),
+		JobWatchCalendar:       handle(w.watchCalendarJob),
+		JobDeleteWatch:         handle(w.deleteWatchJob),
+	}
+}
+
+func handle[A any](fn func(ctx context.Context, args A) error) queue.Handler {
+	return func(ctx context.Context, encoded json.RawMessage) error {
+		var args A
+		if err := json.Unmarshal(encoded, &args); err != nil {
+			return queue.Permanent(errors.Wrap(err, "failed to decode job arguments"))
+		}
+		return fn(ctx, args)
+	}
+}
+
+func (w *Workflows) enqueue(ctx context.Context, kind, idempotencyKey string, args any) error {
+	_, err := w.a.EnqueueJob(ctx, activities.EnqueueJobArgs{
+		Kind:           kind,
+		IdempotencyKey: idempotencyKey,
+		Args:           args,
+	})
+	return err
+}
+
+// EnqueueWebhookEvent processes a webhook notification in the background. Notifications which are delivered twice are
+// only queued once.
+func (w *Workflows) EnqueueWebhookEvent(ctx context.Context, args ProcessWebhookEventArgs) error {
+	return w.enqueue(ctx, JobProcessWebhookEvent, "webhook-"+args.ChannelID+"-"+args.MessageNumber, args)
+}
+
+// EnqueueCopy runs a full sync of a copy config in the background. A copy is only queued once until it runs.
+func (w *Workflows) EnqueueCopy(ctx context.Context, copyID int) error {
+	return w.enqueue(ctx, JobCopyCalendar, fmt.Sprintf("copy-%d", copyID), CopyCalendarJobArgs{CopyID: copyID})
+}
+
+// copyCalendarJob syncs a copy with its current config. Copies which failed to make some of their changes are retried.
+func (w *Workflows) copyCalendarJob(ctx context.Context, args CopyCalendarJobArgs) error {
+	configResult, err := w.a.GetCopyConfig(ctx, activities.GetCopyConfigArgs{CopyID: args.CopyID})
+	if errors.Is(err, sql.ErrNoRows) {
+		// the copy was deleted in the meantime
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = w.CopyCalendarWorkflow(ctx, CopyCalendarWorkflowArgsFromConfig(configResult.CopyConfig))
+	return err
+}
+
+func (w *Workflows) watchCalendarJob(ctx context.Context, args activities.WatchCalendarArgs) error {
+	_, err := w.a.WatchCalendar(ctx, args)
+	if errors.Is(err, providers.ErrNotSupported) {
+		// nothing to watch, these calendars are only synced on a schedule
+		return nil
+	}
+	return err
+}
+
+func (w *Workflows) deleteWatchJob(ctx context.Context, args activities.DeleteWatchConfigArgs) error {
+	_, err := w.a.DeleteWatchConfig(ctx, args)
+	return err
+}
@@ -0,0 +1,143 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/icalendar"
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+)
+
+// padded reports whether the copy of a source event gets padding. Only timed events are padded, and series only when
+// they're expanded, since each instance would need buffers of its own.
+func (args CopyCalendarWorkflowArgs) padded(source *calendar.Event, e calendar.Event) bool {
+	if args.PadBefore <= 0 && args.PadAfter <= 0 {
+		return false
+	}
+	if e.Start == nil || e.End == nil || e.Start.DateTime == "" || e.End.DateTime == "" {
+		return false
+	}
+	if len(e.Recurrence) > 0 || args.isException(source) {
+		return false
+	}
+	if args.PadLocationOnly && !isPhysicalLocation(source.Location) {
+		return false
+	}
+
+	return true
+}
+
+// isPhysicalLocation tells places apart from the meeting links some calendars put into the location.
+func isPhysicalLocation(location string) bool {
+	location = strings.ToLower(strings.TrimSpace(location))
+	return location != "" && !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://")
+}
+
+// pad extends a copy by the padding, if the copy config doesn't ask for separate buffer events.
+func (args CopyCalendarWorkflowArgs) pad(source *calendar.Event, e calendar.Event) calendar.Event {
+	if args.PadMode == persistence.PadSeparate || !args.padded(source, e) {
+		return e
+	}
+
+	e.Start = shiftDateTime(e.Start, -args.PadBefore)
+	e.End = shiftDateTime(e.End, args.PadAfter)
+	return e
+}
+
+// shiftDateTime moves a timed event time, keeping the offset and time zone it is written with.
+func shiftDateTime(dt *calendar.EventDateTime, d time.Duration) *calendar.EventDateTime {
+	t, err := time.Parse(time.RFC3339, dt.DateTime)
+	if err != nil || d == 0 {
+		return dt
+	}
+
+	return &calendar.EventDateTime{DateTime: t.Add(d).Format(time.RFC3339), TimeZone: dt.TimeZone}
+}
+
+// buffers returns the separate buffer events the copy of a source event should have, keyed by their side. Buffers
+// outside the window aren't listed with the copies, so they're left alone like copies outside it are.
+func (args CopyCalendarWorkflowArgs) buffers(log zerolog.Logger, source *calendar.Event, timeMin, timeMax time.Time) map[string]*calendar.Event {
+	if args.PadMode != persistence.PadSeparate {
+		return nil
+	}
+
+	e := args.prepare(log, *source)
+	if !args.padded(source, e) {
+		return nil
+	}
+
+	buffers := make(map[string]*calendar.Event)
+	add := func(side string, start, end *calendar.EventDateTime) {
+		buffer := &calendar.Event{
+			Summary: fmt.Sprintf("Buffer: %s", e.Summary),
+			Start:   start,
+			End:     end,
+			Status:  e.Status,
+			ExtendedProperties: &calendar.EventExtendedProperties{
+				Private: map[string]string{
+					pkg.SourceCalendarIDKey:     args.SourceCalendarID,
+					pkg.SourceCalendarItemIDKey: source.Id,
+					pkg.BufferKey:               side,
+				},
+			},
+		}
+		cleanEvent(buffer)
+
+		if icalendar.Overlaps(buffer, timeMin, timeMax) {
+			buffers[side] = buffer
+		}
+	}
+
+	if args.PadBefore > 0 {
+		add(pkg.BufferBefore, shiftDateTime(e.Start, -args.PadBefore), e.Start)
+	}
+	if args.PadAfter > 0 {
+		add(pkg.BufferAfter, e.End, shiftDateTime(e.End, args.PadAfter))
+	}
+
+	return buffers
+}
+
+func isBuffer(e *calendar.Event) bool {
+	return getExtraByKey(e, pkg.BufferKey) != ""
+}
+
+// syncBuffers creates, updates and removes the buffer events of a copy so they match its source event. Without a
+// source event all of them are removed.
+func (w *Workflows) syncBuffers(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, sourceItem *calendar.Event, existing []*calendar.Event, timeMin, timeMax time.Time) {
+	log := logs.GetLogger(ctx)
+
+	var want map[string]*calendar.Event
+	if sourceItem != nil {
+		want = args.buffers(*log, sourceItem, timeMin, timeMax)
+	}
+
+	synced := make(map[string]bool)
+	for _, buffer := range existing {
+		side := getExtraByKey(buffer, pkg.BufferKey)
+		wanted, ok := want[side]
+		if !ok || synced[side] {
+			w.removeCopy(ctx, args, plan, buffer)
+			continue
+		}
+		synced[side] = true
+
+		patch, diffs := diffEvents(*log, *wanted, *buffer)
+		if patch != nil {
+			w.patchCopy(ctx, args, plan, sourceItem.Id, buffer, patch, diffs)
+		}
+	}
+
+	for side, buffer := range want {
+		if !synced[side] {
+			w.insertCopy(ctx, args, plan, sourceItem.Id, buffer)
+		}
+	}
+}
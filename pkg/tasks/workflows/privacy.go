@@ -0,0 +1,47 @@
+package workflows
+
+import (
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/persistence"
+)
+
+const defaultBusyTitle = "Busy"
+
+// redact removes the parts of a source event which the copy's privacy mode doesn't allow to be copied. Copies are
+// always built and diffed from the redacted event, so changing the mode redacts existing copies on the next sync.
+func (args CopyCalendarWorkflowArgs) redact(e calendar.Event) calendar.Event {
+	busyTitle := args.busyTitle()
+
+	switch args.Privacy {
+	case persistence.PrivacyBusy:
+		e.Summary = busyTitle
+		e.Description = ""
+		e.Location = ""
+		redactDetails(&e)
+	case persistence.PrivacyTitleOnly:
+		e.Description = ""
+		e.Location = ""
+		redactDetails(&e)
+	}
+
+	if e.Summary == "" {
+		e.Summary = busyTitle
+	}
+
+	return e
+}
+
+func (args CopyCalendarWorkflowArgs) busyTitle() string {
+	if args.BusyTitle == "" {
+		return defaultBusyTitle
+	}
+	return args.BusyTitle
+}
+
+// redactDetails removes the optional fields which tell as much as the description does.
+func redactDetails(e *calendar.Event) {
+	e.Attendees = nil
+	e.ConferenceData = nil
+	e.Attachments = nil
+}
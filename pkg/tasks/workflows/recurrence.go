@@ -0,0 +1,97 @@
+package workflows
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/filters"
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/tasks/activities"
+)
+
+// withDestination asks the provider whether the destination can store series, see expands.
+func (w *Workflows) withDestination(ctx context.Context, args CopyCalendarWorkflowArgs) (CopyCalendarWorkflowArgs, error) {
+	result, err := w.a.SupportsRecurrence(ctx, activities.SupportsRecurrenceArgs{CalendarID: args.DestinationCalendarID})
+	if err != nil {
+		return args, err
+	}
+
+	args.seriesUnsupported = !result.Supported
+	return args, nil
+}
+
+// expands reports whether series are copied as their instances. Busy blocks are made of instances too, and so are
+// copies into calendars which can't store modified or cancelled instances on their own, like CalDAV ones.
+func (args CopyCalendarWorkflowArgs) expands() bool {
+	return args.Recurrence == persistence.RecurrenceExpand || args.MergeBusy || args.seriesUnsupported
+}
+
+// listArgs lists a calendar the way the copy's recurrence mode needs. Only the source is ever expanded, copies of
+// instances are single events already.
+func (args CopyCalendarWorkflowArgs) listArgs(calendarID string, timeMin, timeMax time.Time) activities.GetCalendarEventsActivityArgs {
+	expand := args.expands()
+
+	return activities.GetCalendarEventsActivityArgs{
+		CalendarID:         calendarID,
+		TimeMin:            timeMin,
+		TimeMax:            timeMax,
+		SingleEvents:       expand && calendarID == args.SourceCalendarID,
+		CancelledInstances: !expand,
+	}
+}
+
+// isException reports whether an event is a modified or cancelled instance of a series, if series are copied whole.
+func (args CopyCalendarWorkflowArgs) isException(e *calendar.Event) bool {
+	return !args.expands() && e.RecurringEventId != ""
+}
+
+// changesNeedFullSync reports whether changes include recurring events which can't be copied on their own. Exceptions
+// need the copy of their series, and a change to an expanded series can change any of its instances. Busy blocks
+// depend on the events around them, so they always need a full sync.
+func (args CopyCalendarWorkflowArgs) changesNeedFullSync(changes []*calendar.Event) bool {
+	if args.MergeBusy {
+		return true
+	}
+
+	for _, change := range changes {
+		if change.RecurringEventId != "" {
+			return true
+		}
+
+		// deleted events don't say whether they were recurring
+		if args.expands() && (len(change.Recurrence) > 0 || change.Status == "cancelled") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// syncException copies a modified or cancelled instance of a series onto the copy of the series. Instances which are
+// filtered out are cancelled on the copy, since the copied series would show them otherwise.
+func (w *Workflows) syncException(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, filter filters.Filter, exception *calendar.Event, copies map[string]*calendar.Event) {
+	if exception.Status != "cancelled" && !filter.Matches(exception) {
+		cancelled := *exception
+		cancelled.Status = "cancelled"
+		exception = &cancelled
+	}
+
+	if destItem, ok := copies[exception.Id]; ok {
+		w.syncCopy(ctx, args, plan, exception, destItem)
+		return
+	}
+
+	// the series isn't copied, or was only planned on a dry run
+	destMaster, ok := copies[exception.RecurringEventId]
+	if !ok {
+		return
+	}
+
+	event := toInsert(*logs.GetLogger(ctx), args, exception)
+	event.RecurringEventId = destMaster.Id
+	event.OriginalStartTime = args.convertDateTime(exception.OriginalStartTime, false)
+	w.insertCopy(ctx, args, plan, exception.Id, event)
+}
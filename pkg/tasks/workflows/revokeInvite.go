@@ -0,0 +1,81 @@
+package workflows
+
+import (
+	"context"
+	"sync"
+
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/tasks/activities"
+)
+
+type RevokeInviteWorkflowArgs struct {
+	InviteID    int
+	SendUpdates persistence.SendUpdates
+}
+
+// RevokeInviteWorkflow removes every guest the invite config added, and leaves guests who were invited otherwise.
+func (w *Workflows) RevokeInviteWorkflow(ctx context.Context, args RevokeInviteWorkflowArgs) error {
+	ctx, log := setupLogger(ctx, "RevokeInviteWorkflow")
+
+	guestsResult, err := w.a.GetInvitedGuests(ctx, activities.GetInvitedGuestsArgs{InviteID: args.InviteID})
+	if err != nil {
+		return err
+	}
+
+	// guests of the same event are removed together, so they don't overwrite each other's patches
+	byEvent := map[string]activities.RemoveGuestsArgs{}
+	for _, guest := range guestsResult.Guests {
+		removeArgs, ok := byEvent[guest.EventID]
+		if !ok {
+			removeArgs = activities.RemoveGuestsArgs{
+				InviteID:       args.InviteID,
+				CalendarID:     guest.CalendarID,
+				CalendarItemID: guest.EventID,
+				SendUpdates:    string(args.SendUpdates),
+			}
+		}
+		removeArgs.EmailAddresses = append(removeArgs.EmailAddresses, guest.EmailAddress)
+		byEvent[guest.EventID] = removeArgs
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for _, removeArgs := range byEvent {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// the activity reads the event itself, so trying again is based on its latest version
+			var err error
+			for attempt := 0; attempt < maxWriteAttempts; attempt++ {
+				if _, err = w.a.RemoveGuests(ctx, removeArgs); !isWriteConflict(err) {
+					break
+				}
+			}
+			if err == nil {
+				return
+			}
+			if isWriteConflict(err) {
+				w.recordWriteConflict(ctx, persistence.Conflict{DestinationEventID: removeArgs.CalendarItemID})
+			}
+
+			log.Error().Err(err).
+				Str("calendar-id", removeArgs.CalendarID).
+				Str("calendar-item-id", removeArgs.CalendarItemID).
+				Strs("email-addresses", removeArgs.EmailAddresses).
+				Msg("failed to remove guests")
+
+			mu.Lock()
+			defer mu.Unlock()
+			if firstErr == nil {
+				firstErr = err
+			}
+		}()
+	}
+
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,90 @@
+package workflows
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"google.golang.org/api/calendar/v3"
+)
+
+// TemplateData is what copy templates are rendered from, e.g. "[Work] {{.Summary}}" or "{{.HtmlLink}}". The event is
+// the redacted source event, so templates can't reveal more than the privacy mode allows.
+type TemplateData struct {
+	*calendar.Event
+
+	CopyID                int
+	Label                 string
+	SourceCalendarID      string
+	DestinationCalendarID string
+}
+
+// CheckTemplate checks a copy template by rendering it for an empty event, which catches unknown fields as well as
+// syntax errors.
+func CheckTemplate(text string) error {
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return err
+	}
+
+	empty := TemplateData{Event: &calendar.Event{Start: &calendar.EventDateTime{}, End: &calendar.EventDateTime{}}}
+	return errors.Wrap(tmpl.Execute(&strings.Builder{}, empty), "failed to render template")
+}
+
+// labelTemplate is the summary template of copies with a label.
+const labelTemplate = "[{{ .Label }}] {{ .Summary }}"
+
+// render replaces the text of a redacted source event with the copy's templates. A template which fails leaves the
+// text it would have replaced alone.
+func (args CopyCalendarWorkflowArgs) render(log zerolog.Logger, e calendar.Event) calendar.Event {
+	data := TemplateData{
+		Event:                 &e,
+		CopyID:                args.CopyID,
+		Label:                 args.Label,
+		SourceCalendarID:      args.SourceCalendarID,
+		DestinationCalendarID: args.DestinationCalendarID,
+	}
+
+	summaryTemplate := args.SummaryTemplate
+	if summaryTemplate == "" && args.Label != "" {
+		summaryTemplate = labelTemplate
+	}
+
+	summary, err := renderTemplate(summaryTemplate, data, e.Summary)
+	if err != nil {
+		log.Warn().Err(err).Str("event-id", e.Id).Msg("failed to render summary template")
+	}
+	description, err := renderTemplate(args.DescriptionTemplate, data, e.Description)
+	if err != nil {
+		log.Warn().Err(err).Str("event-id", e.Id).Msg("failed to render description template")
+	}
+
+	e.Summary = summary
+	e.Description = description
+
+	return e
+}
+
+func renderTemplate(text string, data TemplateData, fallback string) (string, error) {
+	if text == "" {
+		return fallback, nil
+	}
+
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return fallback, err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return fallback, errors.Wrap(err, "failed to render template")
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("copy").Parse(text)
+	return tmpl, errors.Wrap(err, "failed to parse template")
+}
@@ -0,0 +1,128 @@
+package workflows
+
+import (
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/persistence"
+)
+
+// sameDateTime reports whether two event times are the same, regardless of the offset the time is written with.
+// Times which can't be parsed are compared as they are.
+func sameDateTime(a, b *calendar.EventDateTime) bool {
+	if a.DateTime == "" && b.DateTime == "" {
+		return a.Date == b.Date
+	}
+	if a.DateTime == "" || b.DateTime == "" {
+		return false
+	}
+
+	at, errA := time.Parse(time.RFC3339, a.DateTime)
+	bt, errB := time.Parse(time.RFC3339, b.DateTime)
+	if errA != nil || errB != nil {
+		return a.DateTime == b.DateTime && a.TimeZone == b.TimeZone
+	}
+
+	return at.Equal(bt) && sameZone(a.TimeZone, b.TimeZone, at)
+}
+
+var utcAliases = map[string]bool{
+	"UTC": true, "Etc/UTC": true, "Etc/UCT": true, "UCT": true, "Etc/Universal": true, "Universal": true,
+	"Etc/Zulu": true, "Zulu": true, "GMT": true, "Etc/GMT": true, "Etc/GMT0": true, "Etc/GMT+0": true,
+	"Etc/GMT-0": true, "Etc/Greenwich": true, "Greenwich": true,
+}
+
+// sameZone reports whether two time zones show an event at the same local time. A missing zone means the calendar's
+// default applies, which doesn't make a difference. Zones are equivalent when they have the same offset at the time
+// and in both halves of its year, which catches aliases without comparing their whole history.
+func sameZone(a, b string, at time.Time) bool {
+	if a == b || a == "" || b == "" {
+		return true
+	}
+	if utcAliases[a] && utcAliases[b] {
+		return true
+	}
+
+	la, errA := time.LoadLocation(a)
+	lb, errB := time.LoadLocation(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	for _, t := range []time.Time{at, time.Date(at.Year(), time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(at.Year(), time.July, 1, 0, 0, 0, 0, time.UTC)} {
+		_, offsetA := t.In(la).Zone()
+		_, offsetB := t.In(lb).Zone()
+		if offsetA != offsetB {
+			return false
+		}
+	}
+
+	return true
+}
+
+// location is the time zone all day events are converted in.
+func (args CopyCalendarWorkflowArgs) location() *time.Location {
+	loc, err := time.LoadLocation(args.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// convertTimes turns all day events into timed ones, or the other way around, as the copy config asks.
+func (args CopyCalendarWorkflowArgs) convertTimes(e calendar.Event) calendar.Event {
+	e.Start = args.convertDateTime(e.Start, false)
+	e.End = args.convertDateTime(e.End, true)
+	e.OriginalStartTime = args.convertDateTime(e.OriginalStartTime, false)
+
+	// events without a duration still take up their day
+	if args.AllDay == persistence.AllDayFromTimed && e.Start != nil && e.End != nil && e.End.Date <= e.Start.Date {
+		if day, err := time.Parse(time.DateOnly, e.Start.Date); err == nil {
+			e.End = &calendar.EventDateTime{Date: day.AddDate(0, 0, 1).Format(time.DateOnly)}
+		}
+	}
+
+	return e
+}
+
+// convertDateTime converts a single time. All days become midnight to midnight, and timed events cover every day they
+// touch.
+func (args CopyCalendarWorkflowArgs) convertDateTime(dt *calendar.EventDateTime, isEnd bool) *calendar.EventDateTime {
+	if dt == nil {
+		return nil
+	}
+
+	loc := args.location()
+
+	switch args.AllDay {
+	case persistence.AllDayToTimed:
+		if dt.DateTime != "" {
+			return dt
+		}
+		day, err := time.ParseInLocation(time.DateOnly, dt.Date, loc)
+		if err != nil {
+			return dt
+		}
+		return &calendar.EventDateTime{DateTime: day.Format(time.RFC3339), TimeZone: loc.String()}
+
+	case persistence.AllDayFromTimed:
+		if dt.DateTime == "" {
+			return dt
+		}
+		t, err := time.Parse(time.RFC3339, dt.DateTime)
+		if err != nil {
+			return dt
+		}
+		t = t.In(loc)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		// end dates are exclusive
+		if isEnd && t.After(day) {
+			day = day.AddDate(0, 0, 1)
+		}
+		return &calendar.EventDateTime{Date: day.Format(time.DateOnly)}
+
+	default:
+		return dt
+	}
+}
@@ -0,0 +1,191 @@
+package workflows
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/tasks/activities"
+)
+
+// syncCopy brings an existing copy up to date, in both directions for two-way copies.
+func (w *Workflows) syncCopy(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, sourceItem, destItem *calendar.Event) {
+	if !args.Bidirectional {
+		w.updateCopy(ctx, args, plan, sourceItem, destItem)
+		return
+	}
+
+	source := args.redact(*sourceItem)
+	sourceFingerprint := fingerprint(&source)
+	destFingerprint := fingerprint(destItem)
+	synced := getExtraByKey(destItem, pkg.SyncedFingerprintKey)
+
+	switch {
+	case sourceFingerprint == destFingerprint, synced == "", destFingerprint == synced:
+		// nothing was edited, the copy predates two-way syncing, or only the source was edited
+		w.updateCopy(ctx, args, plan, sourceItem, destItem)
+	case sourceFingerprint == synced:
+		w.updateSource(ctx, args, plan, sourceItem, destItem)
+	default:
+		w.resolveConflict(ctx, args, plan, sourceItem, destItem)
+	}
+}
+
+// updateSource copies the edits of a copy back to its source, and marks both as in sync. Copying the edit back again
+// when the source's change is noticed is prevented by the fingerprint. Writes which fail fail the copy, so the edit
+// isn't lost until the copy is edited again.
+func (w *Workflows) updateSource(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, sourceItem, destItem *calendar.Event) {
+	log := logs.GetLogger(ctx)
+
+	patch, diffs := diffEvents(*log, twoWayFields(*destItem), twoWayFields(args.redact(*sourceItem)))
+
+	plan.add(CopyChange{
+		Action:             CopyActionUpdateSource,
+		SourceEventID:      sourceItem.Id,
+		DestinationEventID: destItem.Id,
+		Summary:            sourceItem.Summary,
+		Start:              formatEventDateTime(sourceItem.Start),
+		Diffs:              diffs,
+	})
+	if args.DryRun {
+		return
+	}
+
+	// either side being changed in the meantime is left to the next sync, which sees the change
+	if patch != nil {
+		updateArgs := activities.UpdateCalendarItemArgs{
+			CalendarID:     args.SourceCalendarID,
+			CalendarItemID: sourceItem.Id,
+			Patch:          patch,
+			Etag:           sourceItem.Etag,
+		}
+		if _, err := w.a.UpdateCalendarItem(ctx, updateArgs); isWriteConflict(err) {
+			log.Info().
+				Str("calendar-item-id", updateArgs.CalendarItemID).
+				Msg("source was changed concurrently, leaving it to the next sync")
+			return
+		} else if err != nil {
+			plan.fail()
+			log.Error().Err(err).
+				Str("calendar-id", updateArgs.CalendarID).
+				Str("calendar-item-id", updateArgs.CalendarItemID).
+				Msg("failed to update source")
+			return
+		}
+	}
+
+	markArgs := activities.UpdateCalendarItemArgs{
+		CalendarID:     args.DestinationCalendarID,
+		CalendarItemID: destItem.Id,
+		Patch:          withFingerprint(nil, destItem, fingerprint(destItem)),
+		Etag:           destItem.Etag,
+	}
+	if _, err := w.a.UpdateCalendarItem(ctx, markArgs); isWriteConflict(err) {
+		log.Info().
+			Str("calendar-item-id", markArgs.CalendarItemID).
+			Msg("copy was changed concurrently, leaving it to the next sync")
+	} else if err != nil {
+		plan.fail()
+		log.Error().Err(err).
+			Str("calendar-id", markArgs.CalendarID).
+			Str("calendar-item-id", markArgs.CalendarItemID).
+			Msg("failed to mark copy as synced")
+	}
+}
+
+// resolveConflict keeps one side of an event which was edited on both sides since they were last in sync.
+func (w *Workflows) resolveConflict(ctx context.Context, args CopyCalendarWorkflowArgs, plan *copyPlan, sourceItem, destItem *calendar.Event) {
+	winner := "source"
+	if args.ConflictPolicy == persistence.ConflictLastModifiedWins && updatedAfter(destItem, sourceItem) {
+		winner = "destination"
+	}
+
+	logs.GetLogger(ctx).Info().
+		Str("source-event-id", sourceItem.Id).
+		Str("destination-event-id", destItem.Id).
+		Str("winner", winner).
+		Msg("event was edited on both sides")
+
+	if !args.DryRun {
+		recordArgs := activities.RecordConflictArgs{Conflict: persistence.Conflict{
+			CopyID:             args.CopyID,
+			SourceEventID:      sourceItem.Id,
+			DestinationEventID: destItem.Id,
+			Summary:            sourceItem.Summary,
+			Winner:             winner,
+			CreatedAt:          time.Now(),
+		}}
+		if _, err := w.a.RecordConflict(ctx, recordArgs); err != nil {
+			logs.GetLogger(ctx).Warn().Err(err).Msg("failed to record conflict")
+		}
+	}
+
+	if winner == "destination" {
+		w.updateSource(ctx, args, plan, sourceItem, destItem)
+		return
+	}
+
+	w.updateCopy(ctx, args, plan, sourceItem, destItem)
+}
+
+func updatedAfter(a, b *calendar.Event) bool {
+	aUpdated, err := time.Parse(time.RFC3339, a.Updated)
+	if err != nil {
+		return false
+	}
+	bUpdated, err := time.Parse(time.RFC3339, b.Updated)
+	if err != nil {
+		return false
+	}
+
+	return aUpdated.After(bUpdated)
+}
+
+// withFingerprint adds the fingerprint to a patch of the copy, unless the copy already has it.
+func withFingerprint(patch *calendar.Event, destItem *calendar.Event, value string) *calendar.Event {
+	return withProperty(patch, destItem, pkg.SyncedFingerprintKey, value)
+}
+
+// twoWayFields are the fields which are copied back to the source, in a form which is comparable across calendars.
+func twoWayFields(e calendar.Event) calendar.Event {
+	return calendar.Event{
+		Summary:     e.Summary,
+		Description: e.Description,
+		Location:    e.Location,
+		Start:       normalizeDateTime(e.Start),
+		End:         normalizeDateTime(e.End),
+	}
+}
+
+func normalizeDateTime(dt *calendar.EventDateTime) *calendar.EventDateTime {
+	if dt == nil {
+		return nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, dt.DateTime); err == nil {
+		return &calendar.EventDateTime{DateTime: t.UTC().Format(time.RFC3339)}
+	}
+
+	return &calendar.EventDateTime{Date: dt.Date, DateTime: dt.DateTime}
+}
+
+func fingerprint(e *calendar.Event) string {
+	if e == nil {
+		return ""
+	}
+
+	data, err := json.Marshal(twoWayFields(*e))
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
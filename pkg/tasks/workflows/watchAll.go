@@ -2,16 +2,17 @@ package workflows
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/pkg/errors"
 
 	"calendar-sync/pkg"
-	"calendar-sync/pkg/logs"
 	"calendar-sync/pkg/persistence"
 	"calendar-sync/pkg/tasks/activities"
 )
 
+// WatchAll queues removing expired watches and watching the calendars which aren't watched anymore.
 func (w *Workflows) WatchAll(ctx context.Context) error {
 	ctx, _ = setupLogger(ctx, "WatchAll")
 
@@ -23,10 +24,13 @@ func (w *Workflows) WatchAll(ctx context.Context) error {
 	goodWatches, badWatches := splitWatches(watchConfigs.WatchConfigs)
 
 	for _, watch := range badWatches {
-		go w.deleteCalendar(ctx, watch.ID)
+		args := activities.DeleteWatchConfigArgs{WatchID: watch.ID}
+		if err := w.enqueue(ctx, JobDeleteWatch, fmt.Sprintf("delete-watch-%d", watch.ID), args); err != nil {
+			return errors.Wrap(err, "failed to queue deleting watch")
+		}
 	}
 
-	watchConfigsByCalendarID := pkg.ToSet(goodWatches, func(i persistence.WatchConfig) string {
+	watched := pkg.ToSet(goodWatches, func(i persistence.WatchConfig) string {
 		return i.CalendarID
 	})
 
@@ -35,18 +39,29 @@ func (w *Workflows) WatchAll(ctx context.Context) error {
 		return errors.Wrap(err, "failed to get invites")
 	}
 
-	for _, inviteConfig := range inviteConfigs.InviteConfigs {
-		go w.watchCalendar(ctx, watchConfigsByCalendarID, inviteConfig.CalendarID)
-	}
-
 	copyConfigs, err := w.a.GetAllCopies(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to get copies")
 	}
 
+	var calendarIDs []string
+	for _, inviteConfig := range inviteConfigs.InviteConfigs {
+		calendarIDs = append(calendarIDs, inviteConfig.CalendarID)
+	}
 	for _, copyConfig := range copyConfigs.CopyConfigs {
-		go w.watchCalendar(ctx, watchConfigsByCalendarID, copyConfig.SourceID)
-		go w.watchCalendar(ctx, watchConfigsByCalendarID, copyConfig.DestinationID)
+		calendarIDs = append(calendarIDs, copyConfig.SourceID, copyConfig.DestinationID)
+	}
+
+	for _, calendarID := range calendarIDs {
+		if _, ok := watched[calendarID]; ok {
+			continue
+		}
+		watched[calendarID] = struct{}{}
+
+		args := activities.WatchCalendarArgs{CalendarID: calendarID}
+		if err := w.enqueue(ctx, JobWatchCalendar, "watch-"+calendarID, args); err != nil {
+			return errors.Wrap(err, "failed to queue watching calendar")
+		}
 	}
 
 	return nil
@@ -66,33 +81,3 @@ func splitWatches(configs []persistence.WatchConfig) ([]persistence.WatchConfig,
 
 	return good, bad
 }
-
-func (w *Workflows) watchCalendar(
-	ctx context.Context, existingWatches map[string]struct{}, calendarID string,
-) {
-	_, ok := existingWatches[calendarID]
-	if ok {
-		return
-	}
-
-	args := activities.WatchCalendarArgs{CalendarID: calendarID}
-	if _, err := w.a.WatchCalendar(ctx, args); err != nil {
-		log := logs.GetLogger(ctx)
-		log.Warn().Err(err).
-			Str("calendar-id", calendarID).
-			Msg("failed to watch calendar")
-	}
-	existingWatches[calendarID] = struct{}{}
-}
-
-func (w *Workflows) deleteCalendar(ctx context.Context, watchID int) {
-	args := activities.DeleteWatchConfigArgs{
-		WatchID: watchID,
-	}
-	if _, err := w.a.DeleteWatchConfig(ctx, args); err != nil {
-		log := logs.GetLogger(ctx)
-		log.Error().Err(err).
-			Int("watch-id", watchID).
-			Msg("failed to delete watch config")
-	}
-}
@@ -47,6 +47,7 @@ func (w *Workflows) ProcessWebhookEvent(ctx context.Context, args ProcessWebhook
 		// resource was created or modified, do something!
 		w.processInvites(ctx, watch.CalendarID)
 		w.processCopyConfigs(ctx, watch.CalendarID)
+		w.processTwoWayCopyConfigs(ctx, watch.CalendarID)
 	default:
 		log.Warn().
 			Str("state", args.ResourceState).
@@ -68,20 +69,92 @@ func (w *Workflows) processCopyConfigs(ctx context.Context, calendarID string) {
 		return
 	}
 
+	if len(copyConfigResult.CopyConfigs) == 0 {
+		return
+	}
+
+	// changes are fetched once per listing mode and shared, since the sync tokens belong to the source calendar
+	changesByMode := make(map[bool]activities.GetChangedEventsResult)
 	for _, config := range copyConfigResult.CopyConfigs {
-		if err = w.CopyCalendarWorkflow(ctx, CopyCalendarWorkflowArgs{
-			SourceCalendarID:      config.SourceID,
-			DestinationCalendarID: config.DestinationID,
-		}); err != nil {
+		args, err := w.withDestination(ctx, CopyCalendarWorkflowArgsFromConfig(config))
+		if err != nil {
+			log.Error().Err(err).Str("destination-calendar-id", config.DestinationID).Msg("failed to check destination calendar")
+			continue
+		}
+		singleEvents := args.expands()
+
+		changesResult, ok := changesByMode[singleEvents]
+		if !ok {
+			changesResult, err = w.a.GetChangedEvents(ctx, activities.GetChangedEventsArgs{CalendarID: calendarID, SingleEvents: singleEvents})
+			if err != nil {
+				log.Error().Err(err).Str("calendar-id", calendarID).Msg("failed to get changed events")
+				continue
+			}
+			changesByMode[singleEvents] = changesResult
+		}
+
+		// two-way copies compare both sides, which changes alone can't do
+		if changesResult.FullSyncRequired || config.Bidirectional || args.changesNeedFullSync(changesResult.Items) {
+			_, err = w.CopyCalendarWorkflow(ctx, args)
+		} else {
+			err = w.CopyCalendarChangesWorkflow(ctx, CopyCalendarChangesWorkflowArgs{
+				CopyCalendarWorkflowArgs: args,
+				Changes:                  changesResult.Items,
+			})
+		}
+		if err != nil {
 			log.Error().
 				Err(err).
 				Str("destination-calendar-id", config.DestinationID).
 				Str("source-calendar-id", config.SourceID).
 				Msg("failed to copy calendar events")
+			w.retryCopy(ctx, config.ID)
+		}
+	}
+
+	// full syncs start from the token which was listed before them
+	for singleEvents, changesResult := range changesByMode {
+		if !changesResult.FullSyncRequired && changesResult.NextSyncToken != "" {
+			w.storeSyncToken(ctx, calendarID, singleEvents, changesResult.NextSyncToken)
+		}
+	}
+}
+
+// processTwoWayCopyConfigs copies edits made in a destination calendar back to the sources of two-way copies.
+func (w *Workflows) processTwoWayCopyConfigs(ctx context.Context, calendarID string) {
+	log := logs.GetLogger(ctx)
+
+	copyConfigResult, err := w.a.GetCopyConfigsForDestinationCalendar(ctx, activities.GetCopyConfigsForDestinationCalendarArgs{
+		CalendarID: calendarID,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("calendar-id", calendarID).Msg("failed to get copy configs")
+		return
+	}
+
+	for _, config := range copyConfigResult.CopyConfigs {
+		if !config.Bidirectional {
+			continue
+		}
+
+		if _, err = w.CopyCalendarWorkflow(ctx, CopyCalendarWorkflowArgsFromConfig(config)); err != nil {
+			log.Error().
+				Err(err).
+				Str("destination-calendar-id", config.DestinationID).
+				Str("source-calendar-id", config.SourceID).
+				Msg("failed to sync two-way copy")
+			w.retryCopy(ctx, config.ID)
 		}
 	}
 }
 
+// retryCopy queues a full sync of a copy which failed to sync, which is retried until it succeeds.
+func (w *Workflows) retryCopy(ctx context.Context, copyID int) {
+	if err := w.EnqueueCopy(ctx, copyID); err != nil {
+		logs.GetLogger(ctx).Error().Err(err).Int("copy-id", copyID).Msg("failed to queue copy")
+	}
+}
+
 func (w *Workflows) processInvites(ctx context.Context, calendarID string) {
 	log := logs.GetLogger(ctx)
 
@@ -94,10 +167,7 @@ func (w *Workflows) processInvites(ctx context.Context, calendarID string) {
 	}
 
 	for _, config := range inviteConfigResult.Configs {
-		args := InviteCalendarWorkflowArgs{
-			CalendarID: calendarID,
-			EmailToAdd: config.EmailAddress,
-		}
+		args := InviteCalendarWorkflowArgsFromConfig(config)
 		if err = w.InviteCalendarWorkflow(ctx, args); err != nil {
 			log.Error().Err(err).
 				Str("calendar-id", config.CalendarID).
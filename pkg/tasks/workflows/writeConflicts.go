@@ -0,0 +1,58 @@
+package workflows
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/calendar/v3"
+
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/providers"
+	"calendar-sync/pkg/tasks/activities"
+)
+
+// maxWriteAttempts is how often a write which runs into concurrent edits is tried before it's given up.
+const maxWriteAttempts = 3
+
+func isWriteConflict(err error) bool {
+	return errors.Is(err, providers.ErrPreconditionFailed)
+}
+
+// retryWrite writes based on the event, and refetches the event to write again if it was changed in the meantime.
+// The error of the last attempt is returned, which is a write conflict if every attempt ran into one.
+func (w *Workflows) retryWrite(ctx context.Context, calendarID string, event *calendar.Event, write func(event *calendar.Event) error) error {
+	for attempt := 1; ; attempt++ {
+		err := write(event)
+		if !isWriteConflict(err) || attempt == maxWriteAttempts {
+			return err
+		}
+
+		logs.GetLogger(ctx).Info().
+			Str("calendar-id", calendarID).
+			Str("calendar-item-id", event.Id).
+			Int("attempt", attempt).
+			Msg("event was changed concurrently, retrying")
+
+		result, err := w.a.GetCalendarItemByItemID(ctx, activities.GetCalendarItemByItemIDArgs{CalendarID: calendarID, EventID: event.Id})
+		if err != nil {
+			return err
+		}
+		event = result.Event
+	}
+}
+
+// recordWriteConflict reports a write which was given up in the conflict history, where neither side won.
+func (w *Workflows) recordWriteConflict(ctx context.Context, conflict persistence.Conflict) {
+	conflict.Winner = persistence.ConflictNoWinner
+	conflict.CreatedAt = time.Now()
+
+	logs.GetLogger(ctx).Warn().
+		Str("destination-event-id", conflict.DestinationEventID).
+		Msg("giving up on write which keeps conflicting with concurrent edits")
+
+	if _, err := w.a.RecordConflict(ctx, activities.RecordConflictArgs{Conflict: conflict}); err != nil {
+		logs.GetLogger(ctx).Warn().Err(err).Msg("failed to record conflict")
+	}
+}
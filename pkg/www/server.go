@@ -27,7 +27,7 @@ func NewServer(ctr container.Container, workflows *workflows.Workflows) *echo.Ec
 	e.Use(logs.CreateRequestLogger(ctr.Logger))
 	e.Use(logs.LogRequest())
 	e.Use(middleware.Recover())
-	e.Use(v.RequireClientToken("/auth/begin", "/auth/end", "/hooks/calendar", "/-/status"))
+	e.Use(v.RequireClientToken("/auth/begin", "/auth/end", "/hooks/calendar", "/-/status", "/feeds/"))
 	e.Use(v.WipeTokenIfInvalid)
 
 	e.GET("/auth/begin", v.BeginAuth)
@@ -35,6 +35,9 @@ func NewServer(ctr container.Container, workflows *workflows.Workflows) *echo.Ec
 	e.GET("/", v.Dashboard)
 	e.GET("/-/status", v.Status)
 	e.POST("/hooks/calendar", v.Webhook)
+	e.GET("/feeds/:token", v.Feed)
+	e.GET("/copies/preview", v.PreviewCopyJSON)
+	e.GET("/copies/:copyID/preview", v.PreviewCopyJSON)
 	e.POST("/", func(c echo.Context) error {
 		vals, err := c.FormParams()
 		if err != nil {
@@ -48,12 +51,40 @@ func NewServer(ctr container.Container, workflows *workflows.Workflows) *echo.Ec
 			return v.CreateInviteConfig(c, vals)
 		case "sync copy":
 			return v.SyncCopy(c, vals)
+		case "preview copy":
+			return v.PreviewCopy(c, vals)
 		case "sync invite":
 			return v.SyncInvite(c, vals)
+		case "update invite":
+			return v.UpdateInviteConfig(c, vals)
 		case "delete invite":
 			return v.DeleteInviteConfig(c, vals)
+		case "create group":
+			return v.CreateGroup(c, vals)
+		case "delete group":
+			return v.DeleteGroup(c, vals)
+		case "add member":
+			return v.AddGroupMember(c, vals)
+		case "remove member":
+			return v.RemoveGroupMember(c, vals)
+		case "update copy":
+			return v.UpdateCopyConfig(c, vals)
 		case "delete copy":
 			return v.DeleteCopyConfig(c, vals)
+		case "add filter":
+			return v.CreateCopyFilter(c, vals)
+		case "delete filter":
+			return v.DeleteCopyFilter(c, vals)
+		case "publish feed":
+			return v.CreateFeedConfig(c, vals)
+		case "revoke feed":
+			return v.DeleteFeedConfig(c, vals)
+		case "run job":
+			return v.RunScheduledJob(c, vals)
+		case "retry job":
+			return v.RetryJob(c, vals)
+		case "delete job":
+			return v.DeleteJob(c, vals)
 		case "renew token":
 			return v.RenewToken(c)
 		default:
@@ -10,19 +10,154 @@ type InvitationStub struct {
 	ID           int
 	Calendar     CalendarStub
 	EmailAddress string
+	Group        string
+
+	SummaryPattern    string
+	OnlyWithAttendees bool
+	OnlyOwnEvents     bool
+	WeekdaysOnly      bool
+	ExcludeAllDay     bool
+	Optional          bool
+	SendUpdates       string
+}
+
+type GroupStub struct {
+	ID      int
+	Name    string
+	Members []string
 }
 
 type CopyStub struct {
-	ID          int
+	ID                  int
+	Source              CalendarStub
+	Destination         CalendarStub
+	LookBackDays        int
+	LookAheadDays       int
+	Privacy             string
+	BusyTitle           string
+	Filters             []FilterStub
+	Bidirectional       bool
+	ConflictPolicy      string
+	ChainMode           string
+	SummaryTemplate     string
+	DescriptionTemplate string
+	Fields              []FieldStub
+	ColorID             string
+	NoReminders         bool
+	Recurrence          string
+	AllDay              string
+	TimeZone            string
+	PadBeforeMinutes    int
+	PadAfterMinutes     int
+	PadLocationOnly     bool
+	PadMode             string
+	MergeBusy           bool
+	MergeGapMinutes     int
+	Label               string
+	Deduplicate         bool
+}
+
+// DestinationStub is a calendar with the copies into it.
+type DestinationStub struct {
+	Calendar CalendarStub
+	Copies   []CopyStub
+}
+
+type FieldStub struct {
+	Name   string
+	Copied bool
+}
+
+type FilterStub struct {
+	ID     int
+	Action string
+	Field  string
+	Value  string
+}
+
+type FeedStub struct {
+	ID       int
+	Calendar CalendarStub
+	Merged   bool
+	Path     string
+}
+
+type Dashboard struct {
+	IsAuthenticated  bool
+	AuthExpiration   string
+	AuthDuration     string
+	Calendars        []CalendarStub
+	Invitations      []InvitationStub
+	Groups           []GroupStub
+	Destinations     []DestinationStub
+	Feeds            []FeedStub
+	PrivacyModes     []string
+	FilterActions    []string
+	FilterFields     []string
+	ConflictPolicies []string
+	Conflicts        []ConflictStub
+	Jobs             []JobStub
+	ScheduledJobs    []ScheduledJobStub
+	// ScheduledOnTemporal hides the scheduled jobs, which Temporal runs and keeps the state of.
+	ScheduledOnTemporal bool
+	ChainModes          []string
+	RecurrenceModes     []string
+	AllDayModes         []string
+	PadModes            []string
+	SendUpdatesModes    []string
+	CopyGraph           []GraphNode
+}
+
+type GraphNode struct {
+	Calendar      CalendarStub
+	Bidirectional bool
+	Cycle         bool
+	Children      []GraphNode
+}
+
+type ConflictStub struct {
+	CreatedAt   string
 	Source      CalendarStub
 	Destination CalendarStub
+	Summary     string
+	Winner      string
 }
 
-type Dashboard struct {
-	IsAuthenticated bool
-	AuthExpiration  string
-	AuthDuration    string
-	Calendars       []CalendarStub
-	Invitations     []InvitationStub
-	Copies          []CopyStub
+type JobStub struct {
+	ID             int
+	Kind           string
+	IdempotencyKey string
+	State          string
+	Attempts       int
+	RunAt          string
+	LastError      string
+}
+
+type ScheduledJobStub struct {
+	Name         string
+	Spec         string
+	Running      bool
+	LastRunAt    string
+	LastDuration string
+	LastError    string
+	NextRunAt    string
+}
+
+type DiffStub struct {
+	Field       string
+	Source      string
+	Destination string
+}
+
+type ChangeStub struct {
+	Action  string
+	Summary string
+	Start   string
+	Diffs   []DiffStub
+}
+
+type Preview struct {
+	Source      CalendarStub
+	Destination CalendarStub
+	Changes     []ChangeStub
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -15,6 +16,71 @@ func TestTemplates(t *testing.T) {
 	var buf bytes.Buffer
 	err := templates.Render(&buf, "index.html", Dashboard{
 		IsAuthenticated: true,
+		Destinations: []DestinationStub{{
+			Calendar: CalendarStub{Label: "team"},
+			Copies: []CopyStub{{
+				ID:              1,
+				Privacy:         "busy",
+				SummaryTemplate: "[Work] {{ .Summary }}",
+				PadMode:         "separate",
+				Fields:          []FieldStub{{Name: "attendees", Copied: true}, {Name: "color"}},
+				Filters:         []FilterStub{{ID: 2, Action: "exclude", Field: "summary", Value: "^Lunch$"}},
+			}, {
+				ID:          3,
+				Source:      CalendarStub{Label: "alice"},
+				Label:       "Alice",
+				Deduplicate: true,
+			}},
+		}},
+		PrivacyModes:     []string{"full", "busy"},
+		FilterActions:    []string{"include", "exclude"},
+		FilterFields:     []string{"summary"},
+		ConflictPolicies: []string{"source-wins"},
+		Conflicts:        []ConflictStub{{Summary: "meeting", Winner: "source"}},
+		Jobs:             []JobStub{{ID: 6, Kind: "copy-calendar", State: "dead", Attempts: 10, LastError: "boom"}},
+		ScheduledJobs:    []ScheduledJobStub{{Name: "copy-all", Spec: "@every 1h", NextRunAt: "2026-01-01 10:00:00"}},
+		ChainModes:       []string{"transitive"},
+		RecurrenceModes:  []string{"series", "expand"},
+		AllDayModes:      []string{"keep", "to-timed"},
+		PadModes:         []string{"extend", "separate"},
+		Invitations:      []InvitationStub{{ID: 4, EmailAddress: "bob@example.com", Group: "team", SummaryPattern: "^1:1", SendUpdates: "externalOnly"}},
+		Groups:           []GroupStub{{ID: 5, Name: "team", Members: []string{"carol@example.com"}}},
+		SendUpdatesModes: []string{"none", "externalOnly", "all"},
+		CopyGraph: []GraphNode{{
+			Calendar: CalendarStub{Label: "a"},
+			Children: []GraphNode{{
+				Calendar: CalendarStub{Label: "b"},
+				Children: []GraphNode{{Calendar: CalendarStub{Label: "a"}, Cycle: true}},
+			}},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `value="[Work] {{ .Summary }}"`)
+	assert.Contains(t, buf.String(), `value="attendees" checked`)
+	assert.Contains(t, buf.String(), `value="separate" selected`)
+	assert.Contains(t, buf.String(), "into team, from 2 source(s)")
+	assert.Contains(t, buf.String(), "alice [Alice]")
+	assert.Contains(t, buf.String(), `value="^1:1"`)
+	assert.Contains(t, buf.String(), `value="externalOnly" selected`)
+	assert.Contains(t, buf.String(), "bob@example.com, group team")
+	assert.Contains(t, buf.String(), `value="carol@example.com"`)
+	assert.Contains(t, buf.String(), `value="retry job"`)
+	assert.Contains(t, buf.String(), `value="run job"`)
+
+	// jobs scheduled on temporal can't be run from here
+	buf.Reset()
+	err = templates.Render(&buf, "index.html", Dashboard{IsAuthenticated: true, ScheduledOnTemporal: true}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "scheduled on Temporal")
+	assert.NotContains(t, buf.String(), `value="run job"`)
+
+	buf.Reset()
+	err = templates.Render(&buf, "preview.html", Preview{
+		Changes: []ChangeStub{{
+			Action:  "update",
+			Summary: "meeting",
+			Diffs:   []DiffStub{{Field: "summary", Source: "meeting", Destination: "Busy"}},
+		}},
 	}, nil)
 	require.NoError(t, err)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -100,7 +101,7 @@ func (v Views) RequireClientToken(noAuthPages ...string) echo.MiddlewareFunc {
 
 			if cookie == nil {
 				// the only acceptable time to have no cookie is if you're trying to login
-				if !slices.Contains(noAuthPages, request.URL.Path) {
+				if !isNoAuthPage(noAuthPages, request.URL.Path) {
 					return c.Redirect(302, "/auth/begin")
 				}
 			}
@@ -110,6 +111,21 @@ func (v Views) RequireClientToken(noAuthPages ...string) echo.MiddlewareFunc {
 	}
 }
 
+// isNoAuthPage matches paths exactly, or by prefix when the page ends with a slash.
+func isNoAuthPage(noAuthPages []string, path string) bool {
+	if slices.Contains(noAuthPages, path) {
+		return true
+	}
+
+	for _, page := range noAuthPages {
+		if strings.HasSuffix(page, "/") && strings.HasPrefix(path, page) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (v Views) isValidUser(ctx context.Context, client *calendar.Service) bool {
 	var (
 		err   error
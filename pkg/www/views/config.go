@@ -1,31 +1,350 @@
 package views
 
 import (
+	"context"
 	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/copygraph"
+	"calendar-sync/pkg/filters"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/providers/ics"
+	"calendar-sync/pkg/tasks/workflows"
 )
 
 func (v Views) CreateCopyConfig(c echo.Context, values url.Values) error {
 	ctx := c.Request().Context()
 
+	source, destination, err := v.parseCopyCalendars(ctx, values)
+	if err != nil {
+		return err
+	}
+
+	if err := v.ctr.Database.CreateCopyConfig(ctx, source, destination); err != nil {
+		return errors.Wrap(err, "failed to create invite config")
+	}
+
+	return c.Redirect(302, "/")
+}
+
+// parseCopyCalendars reads the calendars of a new copy.
+func (v Views) parseCopyCalendars(ctx context.Context, values url.Values) (string, string, error) {
 	source := values.Get("source")
+	if feedURL := values.Get("sourceURL"); feedURL != "" {
+		if !ics.IsFeedURL(feedURL) {
+			return "", "", errors.Errorf("%q is not an http(s) or webcal url", feedURL)
+		}
+		source = feedURL
+	}
 	if source == "" {
-		return errors.New("missing required field 'source'")
+		return "", "", errors.New("missing required field 'source'")
 	}
 	destination := values.Get("destination")
 	if destination == "" {
-		return errors.New("missing required field 'destination'")
+		return "", "", errors.New("missing required field 'destination'")
 	}
 
-	if err := v.ctr.Database.CreateCopyConfig(ctx, source, destination); err != nil {
-		return errors.Wrap(err, "failed to create invite config")
+	if source == destination {
+		return "", "", errors.New("a calendar cannot be copied into itself")
+	}
+	if err := v.checkForCycle(ctx, 0, copygraph.Edge{From: source, To: destination}); err != nil {
+		return "", "", err
+	}
+
+	return source, destination, nil
+}
+
+func (v Views) UpdateCopyConfig(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	copyID, err := strconv.ParseInt(values.Get("copyID"), 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse copyID")
+	}
+
+	config, err := v.ctr.Database.GetCopyConfig(ctx, copyID)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve copy row")
+	}
+
+	if err := v.parseCopyOptions(ctx, &config, values); err != nil {
+		return err
+	}
+
+	if err := v.ctr.Database.UpdateCopyConfig(ctx, config); err != nil {
+		return errors.Wrap(err, "failed to update copy config")
+	}
+
+	if err := v.resyncCopy(ctx, config.ID); err != nil {
+		return err
+	}
+
+	return c.Redirect(302, "/")
+}
+
+// parseCopyOptions reads the settings of a copy from the fields of its form.
+func (v Views) parseCopyOptions(ctx context.Context, config *persistence.CopyConfig, values url.Values) error {
+	var err error
+	if config.LookBack, err = parseDays(values, "lookBackDays"); err != nil {
+		return err
+	}
+	if config.LookAhead, err = parseDays(values, "lookAheadDays"); err != nil {
+		return err
+	}
+	if config.LookAhead <= 0 {
+		return errors.New("'lookAheadDays' must be positive")
+	}
+
+	config.Privacy = persistence.PrivacyMode(values.Get("privacy"))
+	if !slices.Contains(persistence.PrivacyModes, config.Privacy) {
+		return errors.Errorf("unknown privacy mode %q", config.Privacy)
+	}
+	config.BusyTitle = strings.TrimSpace(values.Get("busyTitle"))
+	if config.BusyTitle == "" {
+		return errors.New("missing required field 'busyTitle'")
+	}
+
+	config.SummaryTemplate = strings.TrimSpace(values.Get("summaryTemplate"))
+	if err := workflows.CheckTemplate(config.SummaryTemplate); err != nil {
+		return errors.Wrap(err, "invalid summary template")
+	}
+	config.DescriptionTemplate = strings.TrimSpace(values.Get("descriptionTemplate"))
+	if err := workflows.CheckTemplate(config.DescriptionTemplate); err != nil {
+		return errors.Wrap(err, "invalid description template")
+	}
+
+	config.Fields = nil
+	for _, field := range values["fields"] {
+		if !slices.Contains(persistence.CopyFields, persistence.CopyField(field)) {
+			return errors.Errorf("unknown field %q", field)
+		}
+		config.Fields = append(config.Fields, persistence.CopyField(field))
+	}
+	config.ColorID = strings.TrimSpace(values.Get("colorID"))
+	config.NoReminders = values.Get("noReminders") == "true"
+
+	config.AllDay = persistence.AllDayMode(values.Get("allDay"))
+	if !slices.Contains(persistence.AllDayModes, config.AllDay) {
+		return errors.Errorf("unknown all day mode %q", config.AllDay)
+	}
+	config.TimeZone = strings.TrimSpace(values.Get("timeZone"))
+	if _, err := time.LoadLocation(config.TimeZone); err != nil {
+		return errors.Wrapf(err, "unknown time zone %q", config.TimeZone)
+	}
+
+	if config.PadBefore, err = parseMinutes(values, "padBeforeMinutes"); err != nil {
+		return err
+	}
+	if config.PadAfter, err = parseMinutes(values, "padAfterMinutes"); err != nil {
+		return err
+	}
+	config.PadLocationOnly = values.Get("padLocationOnly") == "true"
+	config.PadMode = persistence.PadMode(values.Get("padMode"))
+	if !slices.Contains(persistence.PadModes, config.PadMode) {
+		return errors.Errorf("unknown padding mode %q", config.PadMode)
+	}
+
+	config.Label = strings.TrimSpace(values.Get("label"))
+	config.Deduplicate = values.Get("deduplicate") == "true"
+
+	config.MergeBusy = values.Get("mergeBusy") == "true"
+	if config.MergeGap, err = parseMinutes(values, "mergeGapMinutes"); err != nil {
+		return err
+	}
+	if config.MergeBusy && config.Privacy != persistence.PrivacyBusy {
+		return errors.New("only busy copies can be merged")
+	}
+
+	config.Bidirectional = values.Get("bidirectional") == "true"
+	config.ConflictPolicy = persistence.ConflictPolicy(values.Get("conflictPolicy"))
+	if !slices.Contains(persistence.ConflictPolicies, config.ConflictPolicy) {
+		return errors.Errorf("unknown conflict policy %q", config.ConflictPolicy)
+	}
+	if config.Bidirectional {
+		// redacted or templated text would overwrite the source's, and feeds can't be written to
+		if config.Privacy != persistence.PrivacyFull {
+			return errors.New("two-way copies must copy full events")
+		}
+		if config.SummaryTemplate != "" || config.DescriptionTemplate != "" || config.Label != "" {
+			return errors.New("two-way copies cannot use templates or labels")
+		}
+		if config.AllDay != persistence.AllDayKeep {
+			return errors.New("two-way copies cannot convert all day events")
+		}
+		if config.PadMode == persistence.PadExtend && (config.PadBefore > 0 || config.PadAfter > 0) {
+			return errors.New("two-way copies cannot be extended by padding")
+		}
+		if config.MergeBusy {
+			return errors.New("two-way copies cannot be merged")
+		}
+		if ics.IsFeedURL(config.SourceID) {
+			return errors.New("feeds cannot be copied two-way")
+		}
+
+		edge := copygraph.Edge{From: config.SourceID, To: config.DestinationID, Bidirectional: true}
+		if err := v.checkForCycle(ctx, config.ID, edge); err != nil {
+			return err
+		}
+	}
+
+	config.Recurrence = persistence.RecurrenceMode(values.Get("recurrence"))
+	if !slices.Contains(persistence.RecurrenceModes, config.Recurrence) {
+		return errors.Errorf("unknown recurrence mode %q", config.Recurrence)
+	}
+
+	config.ChainMode = persistence.ChainMode(values.Get("chainMode"))
+	if !slices.Contains(persistence.ChainModes, config.ChainMode) {
+		return errors.Errorf("unknown chain mode %q", config.ChainMode)
+	}
+
+	return nil
+}
+
+// copyOptionValues are the form fields of a copy's settings, the inverse of parseCopyOptions.
+func copyOptionValues(config persistence.CopyConfig) url.Values {
+	values := url.Values{
+		"lookBackDays":        {strconv.Itoa(int(config.LookBack.Hours() / 24))},
+		"lookAheadDays":       {strconv.Itoa(int(config.LookAhead.Hours() / 24))},
+		"privacy":             {string(config.Privacy)},
+		"busyTitle":           {config.BusyTitle},
+		"summaryTemplate":     {config.SummaryTemplate},
+		"descriptionTemplate": {config.DescriptionTemplate},
+		"colorID":             {config.ColorID},
+		"noReminders":         {strconv.FormatBool(config.NoReminders)},
+		"allDay":              {string(config.AllDay)},
+		"timeZone":            {config.TimeZone},
+		"padBeforeMinutes":    {strconv.Itoa(int(config.PadBefore.Minutes()))},
+		"padAfterMinutes":     {strconv.Itoa(int(config.PadAfter.Minutes()))},
+		"padLocationOnly":     {strconv.FormatBool(config.PadLocationOnly)},
+		"padMode":             {string(config.PadMode)},
+		"label":               {config.Label},
+		"deduplicate":         {strconv.FormatBool(config.Deduplicate)},
+		"mergeBusy":           {strconv.FormatBool(config.MergeBusy)},
+		"mergeGapMinutes":     {strconv.Itoa(int(config.MergeGap.Minutes()))},
+		"bidirectional":       {strconv.FormatBool(config.Bidirectional)},
+		"conflictPolicy":      {string(config.ConflictPolicy)},
+		"recurrence":          {string(config.Recurrence)},
+		"chainMode":           {string(config.ChainMode)},
+	}
+	for _, field := range config.Fields {
+		values.Add("fields", string(field))
+	}
+
+	return values
+}
+
+// resyncCopy queues a full sync, since existing copies only pick up new settings on a full sync.
+func (v Views) resyncCopy(ctx context.Context, copyID int) error {
+	if err := v.workflows.EnqueueCopy(ctx, copyID); err != nil {
+		return errors.Wrap(err, "failed to queue copy sync")
+	}
+	return nil
+}
+
+func (v Views) CreateCopyFilter(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	copyID, err := strconv.ParseInt(values.Get("copyID"), 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse copyID")
+	}
+
+	config, err := v.ctr.Database.GetCopyConfig(ctx, copyID)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve copy row")
+	}
+
+	rule := persistence.FilterRule{
+		CopyID: config.ID,
+		Action: persistence.FilterAction(values.Get("action")),
+		Field:  persistence.FilterField(values.Get("field")),
+		Value:  strings.TrimSpace(values.Get("value")),
+	}
+	if _, err := filters.New([]persistence.FilterRule{rule}); err != nil {
+		return errors.Wrap(err, "invalid filter")
+	}
+
+	if err := v.ctr.Database.CreateCopyFilter(ctx, rule); err != nil {
+		return errors.Wrap(err, "failed to create copy filter")
+	}
+
+	if err := v.resyncCopy(ctx, config.ID); err != nil {
+		return err
 	}
 
 	return c.Redirect(302, "/")
 }
 
+func (v Views) DeleteCopyFilter(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	filterID := values.Get("filterID")
+	if filterID == "" {
+		return errors.New("missing required field 'filterID'")
+	}
+	copyID, err := strconv.ParseInt(values.Get("copyID"), 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse copyID")
+	}
+
+	if err := v.ctr.Database.DeleteCopyFilter(ctx, filterID); err != nil {
+		return errors.Wrap(err, "failed to delete copy filter")
+	}
+
+	if err := v.resyncCopy(ctx, int(copyID)); err != nil {
+		return err
+	}
+
+	return c.Redirect(302, "/")
+}
+
+// checkForCycle refuses copies which would copy events around in a circle. The copy being changed, if any, is replaced
+// by edge.
+func (v Views) checkForCycle(ctx context.Context, copyID int, edge copygraph.Edge) error {
+	configs, err := v.ctr.Database.GetCopyConfigs(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get copy configs")
+	}
+
+	configs = slices.DeleteFunc(configs, func(c persistence.CopyConfig) bool { return c.ID == copyID })
+	if cycle := copygraph.FindCycle(copygraph.FromConfigs(configs), edge); cycle != nil {
+		return errors.Errorf("copy would create a cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	return nil
+}
+
+func parseDays(values url.Values, field string) (time.Duration, error) {
+	days, err := strconv.Atoi(values.Get(field))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse '%s'", field)
+	}
+	if days < 0 {
+		return 0, errors.Errorf("'%s' must not be negative", field)
+	}
+
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+func parseMinutes(values url.Values, field string) (time.Duration, error) {
+	minutes, err := strconv.Atoi(values.Get(field))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse '%s'", field)
+	}
+	if minutes < 0 {
+		return 0, errors.Errorf("'%s' must not be negative", field)
+	}
+
+	return time.Duration(minutes) * time.Minute, nil
+}
+
 func (v Views) CreateInviteConfig(c echo.Context, values url.Values) error {
 	ctx := c.Request().Context()
 
@@ -33,18 +352,65 @@ func (v Views) CreateInviteConfig(c echo.Context, values url.Values) error {
 	if calendarID == "" {
 		return errors.New("missing required field 'calendar'")
 	}
-	email := values.Get("email")
-	if email == "" {
-		return errors.New("missing required field 'email'")
+	email := strings.TrimSpace(values.Get("email"))
+
+	var groupID int
+	if value := values.Get("group"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse 'group'")
+		}
+		if _, err := v.ctr.Database.GetGroup(ctx, id); err != nil {
+			return errors.Wrap(err, "failed to retrieve group")
+		}
+		groupID = id
+	}
+	if email == "" && groupID == 0 {
+		return errors.New("missing required field 'email' or 'group'")
 	}
 
-	if err := v.ctr.Database.CreateInviteConfig(ctx, calendarID, email); err != nil {
+	if err := v.ctr.Database.CreateInviteConfig(ctx, calendarID, email, groupID); err != nil {
 		return errors.Wrap(err, "failed to create invite config")
 	}
 
 	return c.Redirect(302, "/")
 }
 
+func (v Views) UpdateInviteConfig(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	inviteID, err := strconv.ParseInt(values.Get("inviteID"), 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse inviteID")
+	}
+
+	config, err := v.ctr.Database.GetInviteConfig(ctx, inviteID)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve invite row")
+	}
+
+	config.SummaryPattern = strings.TrimSpace(values.Get("summaryPattern"))
+	if _, err := regexp.Compile(config.SummaryPattern); err != nil {
+		return errors.Wrap(err, "invalid summary pattern")
+	}
+	config.OnlyWithAttendees = values.Get("onlyWithAttendees") == "true"
+	config.OnlyOwnEvents = values.Get("onlyOwnEvents") == "true"
+	config.WeekdaysOnly = values.Get("weekdaysOnly") == "true"
+	config.ExcludeAllDay = values.Get("excludeAllDay") == "true"
+
+	config.Optional = values.Get("optional") == "true"
+	config.SendUpdates = persistence.SendUpdates(values.Get("sendUpdates"))
+	if !slices.Contains(persistence.SendUpdatesModes, config.SendUpdates) {
+		return errors.Errorf("unknown send updates mode %q", config.SendUpdates)
+	}
+
+	if err := v.ctr.Database.UpdateInviteConfig(ctx, config); err != nil {
+		return errors.Wrap(err, "failed to update invite config")
+	}
+
+	return c.Redirect(302, "/")
+}
+
 func (v Views) DeleteCopyConfig(c echo.Context, values url.Values) error {
 	ctx := c.Request().Context()
 
@@ -60,15 +426,27 @@ func (v Views) DeleteCopyConfig(c echo.Context, values url.Values) error {
 	return c.Redirect(302, "/")
 }
 
+// DeleteInviteConfig removes the guests the invite config added from their events, then deletes the config. The config
+// is kept if any guest couldn't be removed, so deleting it again can finish the job.
 func (v Views) DeleteInviteConfig(c echo.Context, values url.Values) error {
 	ctx := c.Request().Context()
 
-	inviteID := values.Get("inviteID")
-	if inviteID == "" {
-		return errors.New("missing required field 'inviteID'")
+	inviteID, err := strconv.ParseInt(values.Get("inviteID"), 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse inviteID")
+	}
+
+	config, err := v.ctr.Database.GetInviteConfig(ctx, inviteID)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve invite row")
+	}
+
+	args := workflows.RevokeInviteWorkflowArgs{InviteID: config.ID, SendUpdates: config.SendUpdates}
+	if err := v.workflows.RevokeInviteWorkflow(ctx, args); err != nil {
+		return errors.Wrap(err, "failed to remove invited guests")
 	}
 
-	if err := v.ctr.Database.DeleteInviteConfig(ctx, inviteID); err != nil {
+	if err := v.ctr.Database.DeleteInviteConfig(ctx, strconv.Itoa(config.ID)); err != nil {
 		return errors.Wrap(err, "failed to delete invite config")
 	}
 
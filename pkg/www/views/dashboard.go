@@ -2,44 +2,33 @@ package views
 
 import (
 	"database/sql"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
-	"google.golang.org/api/calendar/v3"
 
+	"calendar-sync/pkg"
+	"calendar-sync/pkg/copygraph"
 	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
 	"calendar-sync/pkg/www/templates"
 )
 
+const (
+	maxConflicts = 50
+	maxJobs      = 50
+)
+
 func (v Views) Dashboard(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	client, err := v.ctr.GetCalendarClient(ctx)
+	calendars, err := v.ctr.Provider.ListCalendars(ctx)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return c.Redirect(302, "/auth/begin")
 		}
-
-		return errors.Wrap(err, "failed to create client")
-	}
-
-	var calendarStubs []templates.CalendarStub
-	calendarStubsById := make(map[string]templates.CalendarStub)
-	if err = client.CalendarList.List().Pages(ctx, func(list *calendar.CalendarList) error {
-		for _, c := range list.Items {
-			stub := templates.CalendarStub{
-				AccessRole: c.AccessRole,
-				ID:         c.Id,
-				Label:      c.Summary,
-			}
-			calendarStubsById[c.Id] = stub
-			calendarStubs = append(calendarStubs, stub)
-		}
-
-		return nil
-	}); err != nil {
 		if strings.Contains(err.Error(), "oauth2: token expired and refresh token is not set") {
 			if err = v.ctr.Database.RemoveTokens(ctx); err != nil {
 				log := logs.GetLogger(ctx)
@@ -50,6 +39,29 @@ func (v Views) Dashboard(c echo.Context) error {
 		return errors.Wrap(err, "failed to list calendars")
 	}
 
+	var calendarStubs []templates.CalendarStub
+	calendarStubsById := make(map[string]templates.CalendarStub)
+	for _, c := range calendars {
+		stub := templates.CalendarStub{
+			AccessRole: c.AccessRole,
+			ID:         c.ID,
+			Label:      c.Summary,
+		}
+		calendarStubsById[c.ID] = stub
+		calendarStubs = append(calendarStubs, stub)
+	}
+
+	var groupStubs []templates.GroupStub
+	groupNames := map[int]string{}
+	groups, err := v.ctr.Database.GetGroups(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to collect groups")
+	}
+	for _, g := range groups {
+		groupStubs = append(groupStubs, templates.GroupStub{ID: g.ID, Name: g.Name, Members: g.Members})
+		groupNames[g.ID] = g.Name
+	}
+
 	var inviteStubs []templates.InvitationStub
 	invites, err := v.ctr.Database.GetInviteConfigs(ctx)
 	if err != nil {
@@ -60,6 +72,15 @@ func (v Views) Dashboard(c echo.Context) error {
 			ID:           i.ID,
 			Calendar:     calendarStubsById[i.CalendarID],
 			EmailAddress: i.EmailAddress,
+			Group:        groupNames[i.GroupID],
+
+			SummaryPattern:    i.SummaryPattern,
+			OnlyWithAttendees: i.OnlyWithAttendees,
+			OnlyOwnEvents:     i.OnlyOwnEvents,
+			WeekdaysOnly:      i.WeekdaysOnly,
+			ExcludeAllDay:     i.ExcludeAllDay,
+			Optional:          i.Optional,
+			SendUpdates:       string(i.SendUpdates),
 		})
 	}
 
@@ -69,10 +90,125 @@ func (v Views) Dashboard(c echo.Context) error {
 		return errors.Wrap(err, "failed to collect copies")
 	}
 	for _, cs := range copies {
+		var filterStubs []templates.FilterStub
+		for _, f := range cs.Filters {
+			filterStubs = append(filterStubs, templates.FilterStub{
+				ID:     f.ID,
+				Action: string(f.Action),
+				Field:  string(f.Field),
+				Value:  f.Value,
+			})
+		}
+
+		var fieldStubs []templates.FieldStub
+		for _, field := range persistence.CopyFields {
+			fieldStubs = append(fieldStubs, templates.FieldStub{
+				Name:   string(field),
+				Copied: slices.Contains(cs.Fields, field),
+			})
+		}
+
 		copyStubs = append(copyStubs, templates.CopyStub{
-			ID:          cs.ID,
-			Source:      calendarStubsById[cs.SourceID],
-			Destination: calendarStubsById[cs.DestinationID],
+			ID:                  cs.ID,
+			Source:              findCalendarStub(calendarStubsById, cs.SourceID),
+			Destination:         findCalendarStub(calendarStubsById, cs.DestinationID),
+			LookBackDays:        int(cs.LookBack.Hours() / 24),
+			LookAheadDays:       int(cs.LookAhead.Hours() / 24),
+			Privacy:             string(cs.Privacy),
+			BusyTitle:           cs.BusyTitle,
+			Filters:             filterStubs,
+			Bidirectional:       cs.Bidirectional,
+			ConflictPolicy:      string(cs.ConflictPolicy),
+			ChainMode:           string(cs.ChainMode),
+			SummaryTemplate:     cs.SummaryTemplate,
+			DescriptionTemplate: cs.DescriptionTemplate,
+			Fields:              fieldStubs,
+			ColorID:             cs.ColorID,
+			NoReminders:         cs.NoReminders,
+			Recurrence:          string(cs.Recurrence),
+			AllDay:              string(cs.AllDay),
+			TimeZone:            cs.TimeZone,
+			PadBeforeMinutes:    int(cs.PadBefore.Minutes()),
+			PadAfterMinutes:     int(cs.PadAfter.Minutes()),
+			PadLocationOnly:     cs.PadLocationOnly,
+			PadMode:             string(cs.PadMode),
+			MergeBusy:           cs.MergeBusy,
+			MergeGapMinutes:     int(cs.MergeGap.Minutes()),
+			Label:               cs.Label,
+			Deduplicate:         cs.Deduplicate,
+		})
+	}
+
+	var conflictStubs []templates.ConflictStub
+	conflicts, err := v.ctr.Database.GetConflicts(ctx, maxConflicts)
+	if err != nil {
+		return errors.Wrap(err, "failed to collect conflicts")
+	}
+	copiesByID := pkg.ToMap(copies, func(c persistence.CopyConfig) int { return c.ID })
+	for _, conflict := range conflicts {
+		cs := copiesByID[conflict.CopyID]
+		conflictStubs = append(conflictStubs, templates.ConflictStub{
+			CreatedAt:   conflict.CreatedAt.Format(time.DateTime),
+			Source:      findCalendarStub(calendarStubsById, cs.SourceID),
+			Destination: findCalendarStub(calendarStubsById, cs.DestinationID),
+			Summary:     conflict.Summary,
+			Winner:      conflict.Winner,
+		})
+	}
+
+	var jobStubs []templates.JobStub
+	jobs, err := v.ctr.Database.GetFailedJobs(ctx, maxJobs)
+	if err != nil {
+		return errors.Wrap(err, "failed to collect jobs")
+	}
+	for _, job := range jobs {
+		jobStubs = append(jobStubs, templates.JobStub{
+			ID:             job.ID,
+			Kind:           job.Kind,
+			IdempotencyKey: job.IdempotencyKey,
+			State:          string(job.State),
+			Attempts:       job.Attempts,
+			RunAt:          job.RunAt.Local().Format(time.DateTime),
+			LastError:      job.LastError,
+		})
+	}
+
+	// jobs scheduled on temporal leave no state here, what's stored is left over from running them in-process
+	var scheduledJobStubs []templates.ScheduledJobStub
+	var scheduledJobs []persistence.ScheduledJob
+	scheduledOnTemporal := v.ctr.Config.TemporalHostPort != ""
+	if !scheduledOnTemporal {
+		scheduledJobs, err = v.ctr.Database.GetScheduledJobs(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to collect scheduled jobs")
+		}
+	}
+	for _, job := range scheduledJobs {
+		stub := templates.ScheduledJobStub{
+			Name:      job.Name,
+			Spec:      job.Spec,
+			Running:   job.Running,
+			LastError: job.LastError,
+			NextRunAt: job.NextRunAt.Local().Format(time.DateTime),
+		}
+		if !job.LastRunAt.IsZero() {
+			stub.LastRunAt = job.LastRunAt.Local().Format(time.DateTime)
+			stub.LastDuration = job.LastDuration.Round(time.Millisecond).String()
+		}
+		scheduledJobStubs = append(scheduledJobStubs, stub)
+	}
+
+	var feedStubs []templates.FeedStub
+	feeds, err := v.ctr.Database.GetFeedConfigs(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to collect feeds")
+	}
+	for _, f := range feeds {
+		feedStubs = append(feedStubs, templates.FeedStub{
+			ID:       f.ID,
+			Calendar: findCalendarStub(calendarStubsById, f.CalendarID),
+			Merged:   f.Merged,
+			Path:     feedPath(f.Token),
 		})
 	}
 
@@ -82,13 +218,89 @@ func (v Views) Dashboard(c echo.Context) error {
 	}
 
 	model := templates.Dashboard{
-		AuthDuration:    time.Until(tokens.Expiry).String(),
-		AuthExpiration:  tokens.Expiry.String(),
-		Calendars:       calendarStubs,
-		Copies:          copyStubs,
-		Invitations:     inviteStubs,
-		IsAuthenticated: true,
+		AuthDuration:        time.Until(tokens.Expiry).String(),
+		AuthExpiration:      tokens.Expiry.String(),
+		Calendars:           calendarStubs,
+		Conflicts:           conflictStubs,
+		Jobs:                jobStubs,
+		ScheduledJobs:       scheduledJobStubs,
+		ScheduledOnTemporal: scheduledOnTemporal,
+		Destinations:        groupByDestination(copyStubs),
+		CopyGraph:           buildGraphNodes(calendarStubsById, copygraph.Build(copygraph.FromConfigs(copies))),
+		Feeds:               feedStubs,
+		Invitations:         inviteStubs,
+		Groups:              groupStubs,
+		IsAuthenticated:     true,
+	}
+	for _, mode := range persistence.PrivacyModes {
+		model.PrivacyModes = append(model.PrivacyModes, string(mode))
+	}
+	for _, action := range persistence.FilterActions {
+		model.FilterActions = append(model.FilterActions, string(action))
+	}
+	for _, field := range persistence.FilterFields {
+		model.FilterFields = append(model.FilterFields, string(field))
+	}
+	for _, policy := range persistence.ConflictPolicies {
+		model.ConflictPolicies = append(model.ConflictPolicies, string(policy))
+	}
+	for _, mode := range persistence.ChainModes {
+		model.ChainModes = append(model.ChainModes, string(mode))
+	}
+	for _, mode := range persistence.RecurrenceModes {
+		model.RecurrenceModes = append(model.RecurrenceModes, string(mode))
+	}
+	for _, mode := range persistence.AllDayModes {
+		model.AllDayModes = append(model.AllDayModes, string(mode))
+	}
+	for _, mode := range persistence.PadModes {
+		model.PadModes = append(model.PadModes, string(mode))
+	}
+	for _, mode := range persistence.SendUpdatesModes {
+		model.SendUpdatesModes = append(model.SendUpdatesModes, string(mode))
 	}
 
 	return c.Render(200, "index.html", model)
 }
+
+func buildGraphNodes(stubs map[string]templates.CalendarStub, nodes []copygraph.Node) []templates.GraphNode {
+	var result []templates.GraphNode
+	for _, node := range nodes {
+		result = append(result, templates.GraphNode{
+			Calendar:      findCalendarStub(stubs, node.CalendarID),
+			Bidirectional: node.Bidirectional,
+			Cycle:         node.Cycle,
+			Children:      buildGraphNodes(stubs, node.Children),
+		})
+	}
+	return result
+}
+
+// findCalendarStub falls back to the calendar ID for calendars which aren't listed, like ics feeds.
+// groupByDestination groups copies by the calendar they copy into, so calendars which many sources are copied into are
+// seen as a whole.
+func groupByDestination(copies []templates.CopyStub) []templates.DestinationStub {
+	var destinations []templates.DestinationStub
+	for _, cs := range copies {
+		idx := slices.IndexFunc(destinations, func(d templates.DestinationStub) bool { return d.Calendar.ID == cs.Destination.ID })
+		if idx < 0 {
+			destinations = append(destinations, templates.DestinationStub{Calendar: cs.Destination})
+			idx = len(destinations) - 1
+		}
+		destinations[idx].Copies = append(destinations[idx].Copies, cs)
+	}
+
+	slices.SortStableFunc(destinations, func(a, b templates.DestinationStub) int {
+		return strings.Compare(a.Calendar.Label, b.Calendar.Label)
+	})
+
+	return destinations
+}
+
+func findCalendarStub(stubs map[string]templates.CalendarStub, calendarID string) templates.CalendarStub {
+	if stub, ok := stubs[calendarID]; ok {
+		return stub
+	}
+
+	return templates.CalendarStub{ID: calendarID, Label: calendarID}
+}
@@ -0,0 +1,115 @@
+package views
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/emersion/go-ical"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/icalendar"
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/tasks/workflows"
+)
+
+const feedTokenBytes = 24
+
+// Feed renders a calendar as ics. The token in the url is the only authentication.
+func (v Views) Feed(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logs.GetLogger(ctx)
+
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	config, err := v.ctr.Database.GetFeedConfigByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.ErrNotFound
+		}
+		return errors.Wrap(err, "failed to get feed config")
+	}
+
+	result, err := v.workflows.BuildFeedWorkflow(ctx, workflows.BuildFeedWorkflowArgs{
+		CalendarID: config.CalendarID,
+		Merged:     config.Merged,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to build feed")
+	}
+
+	cal := icalendar.NewCalendar()
+	cal.Props.SetText("X-WR-CALNAME", result.Summary)
+
+	for _, item := range result.Items {
+		if item.ICalUID == "" {
+			item.ICalUID = item.Id
+		}
+
+		e, err := icalendar.FromEvent(item)
+		if err != nil {
+			log.Warn().Err(err).Str("event-id", item.Id).Msg("failed to convert event, skipping")
+			continue
+		}
+
+		cal.Children = append(cal.Children, e.Component)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, ical.MIMEType+"; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+
+	return ical.NewEncoder(c.Response()).Encode(cal)
+}
+
+func (v Views) CreateFeedConfig(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	calendarID := values.Get("calendar")
+	if calendarID == "" {
+		return errors.New("missing required field 'calendar'")
+	}
+	merged := values.Get("merged") != ""
+
+	token, err := newFeedToken()
+	if err != nil {
+		return errors.Wrap(err, "failed to generate token")
+	}
+
+	if err := v.ctr.Database.CreateFeedConfig(ctx, calendarID, token, merged); err != nil {
+		return errors.Wrap(err, "failed to create feed config")
+	}
+
+	return c.Redirect(302, "/")
+}
+
+func (v Views) DeleteFeedConfig(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	feedID := values.Get("feedID")
+	if feedID == "" {
+		return errors.New("missing required field 'feedID'")
+	}
+
+	if err := v.ctr.Database.DeleteFeedConfig(ctx, feedID); err != nil {
+		return errors.Wrap(err, "failed to delete feed config")
+	}
+
+	return c.Redirect(302, "/")
+}
+
+func newFeedToken() (string, error) {
+	buf := make([]byte, feedTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func feedPath(token string) string {
+	return "/feeds/" + token + ".ics"
+}
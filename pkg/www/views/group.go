@@ -0,0 +1,78 @@
+package views
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+func (v Views) CreateGroup(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	name := strings.TrimSpace(values.Get("name"))
+	if name == "" {
+		return errors.New("missing required field 'name'")
+	}
+
+	if err := v.ctr.Database.CreateGroup(ctx, name); err != nil {
+		return errors.Wrap(err, "failed to create group")
+	}
+
+	return c.Redirect(302, "/")
+}
+
+func (v Views) DeleteGroup(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	groupID := values.Get("groupID")
+	if groupID == "" {
+		return errors.New("missing required field 'groupID'")
+	}
+
+	if err := v.ctr.Database.DeleteGroup(ctx, groupID); err != nil {
+		return errors.Wrap(err, "failed to delete group")
+	}
+
+	return c.Redirect(302, "/")
+}
+
+func (v Views) AddGroupMember(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	groupID, err := strconv.Atoi(values.Get("groupID"))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse groupID")
+	}
+	email := strings.TrimSpace(values.Get("email"))
+	if email == "" {
+		return errors.New("missing required field 'email'")
+	}
+
+	if err := v.ctr.Database.AddGroupMember(ctx, groupID, email); err != nil {
+		return errors.Wrap(err, "failed to add group member")
+	}
+
+	return c.Redirect(302, "/")
+}
+
+func (v Views) RemoveGroupMember(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	groupID, err := strconv.Atoi(values.Get("groupID"))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse groupID")
+	}
+	email := values.Get("email")
+	if email == "" {
+		return errors.New("missing required field 'email'")
+	}
+
+	if err := v.ctr.Database.RemoveGroupMember(ctx, groupID, email); err != nil {
+		return errors.Wrap(err, "failed to remove group member")
+	}
+
+	return c.Redirect(302, "/")
+}
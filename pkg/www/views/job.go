@@ -0,0 +1,61 @@
+package views
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RunScheduledJob runs a scheduled job right away, the scheduler picks it up within a few seconds. Jobs which are
+// scheduled on Temporal are triggered there instead.
+func (v Views) RunScheduledJob(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	if v.ctr.Config.TemporalHostPort != "" {
+		return errors.New("jobs are scheduled on temporal, trigger their schedules there")
+	}
+
+	name := values.Get("name")
+	if name == "" {
+		return errors.New("missing required field 'name'")
+	}
+
+	if err := v.ctr.Database.TriggerScheduledJob(ctx, name); err != nil {
+		return errors.Wrap(err, "failed to trigger scheduled job")
+	}
+
+	return c.Redirect(302, "/")
+}
+
+// RetryJob gives a dead job another set of attempts.
+func (v Views) RetryJob(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	jobID, err := strconv.Atoi(values.Get("jobID"))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse jobID")
+	}
+
+	if err := v.ctr.Database.RequeueJob(ctx, jobID); err != nil {
+		return errors.Wrap(err, "failed to retry job")
+	}
+
+	return c.Redirect(302, "/")
+}
+
+func (v Views) DeleteJob(c echo.Context, values url.Values) error {
+	ctx := c.Request().Context()
+
+	jobID, err := strconv.Atoi(values.Get("jobID"))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse jobID")
+	}
+
+	if err := v.ctr.Database.DeleteJob(ctx, jobID); err != nil {
+		return errors.Wrap(err, "failed to delete job")
+	}
+
+	return c.Redirect(302, "/")
+}
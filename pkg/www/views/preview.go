@@ -0,0 +1,132 @@
+package views
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"calendar-sync/pkg/logs"
+	"calendar-sync/pkg/persistence"
+	"calendar-sync/pkg/tasks/workflows"
+	"calendar-sync/pkg/www/templates"
+)
+
+// PreviewCopy shows what syncing a copy config would change, without changing anything. Copies which aren't saved yet
+// are previewed by their calendars and settings instead of a copyID.
+func (v Views) PreviewCopy(c echo.Context, vals url.Values) error {
+	ctx := c.Request().Context()
+
+	config, result, err := v.previewCopy(ctx, vals)
+	if err != nil {
+		return err
+	}
+
+	model := templates.Preview{
+		Source:      v.calendarStub(ctx, config.SourceID),
+		Destination: v.calendarStub(ctx, config.DestinationID),
+	}
+	for _, change := range result.Changes {
+		stub := templates.ChangeStub{
+			Action:  string(change.Action),
+			Summary: change.Summary,
+			Start:   change.Start,
+		}
+		for _, diff := range change.Diffs {
+			stub.Diffs = append(stub.Diffs, templates.DiffStub{
+				Field:       diff.Field,
+				Source:      fmt.Sprint(diff.Source),
+				Destination: fmt.Sprint(diff.Destination),
+			})
+		}
+		model.Changes = append(model.Changes, stub)
+	}
+
+	return c.Render(200, "preview.html", model)
+}
+
+// PreviewCopyJSON is PreviewCopy for scripts, taking the copyID from the path or the rest from the query.
+func (v Views) PreviewCopyJSON(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	vals := c.QueryParams()
+	if copyID := c.Param("copyID"); copyID != "" {
+		vals.Set("copyID", copyID)
+	}
+
+	_, result, err := v.previewCopy(ctx, vals)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, result)
+}
+
+func (v Views) previewCopy(ctx context.Context, vals url.Values) (persistence.CopyConfig, workflows.CopyCalendarWorkflowResult, error) {
+	var result workflows.CopyCalendarWorkflowResult
+
+	config, err := v.previewConfig(ctx, vals)
+	if err != nil {
+		return config, result, err
+	}
+
+	args := workflows.CopyCalendarWorkflowArgsFromConfig(config)
+	args.DryRun = true
+	result, err = v.workflows.CopyCalendarWorkflow(ctx, args)
+	if err != nil {
+		return config, result, errors.Wrap(err, "failed to execute workflow")
+	}
+
+	return config, result, nil
+}
+
+// previewConfig loads a saved copy config, or builds one which isn't saved. Settings which aren't given keep their
+// defaults.
+func (v Views) previewConfig(ctx context.Context, vals url.Values) (persistence.CopyConfig, error) {
+	if copyIDstr := vals.Get("copyID"); copyIDstr != "" {
+		copyID, err := strconv.ParseInt(copyIDstr, 10, 64)
+		if err != nil {
+			return persistence.CopyConfig{}, echo.NewHTTPError(400, "invalid copyID")
+		}
+
+		config, err := v.ctr.Database.GetCopyConfig(ctx, copyID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return config, echo.ErrNotFound
+			}
+			return config, errors.Wrap(err, "failed to retrieve copy row")
+		}
+
+		return config, nil
+	}
+
+	source, destination, err := v.parseCopyCalendars(ctx, vals)
+	if err != nil {
+		return persistence.CopyConfig{}, echo.NewHTTPError(400, err.Error())
+	}
+
+	config := persistence.NewCopyConfig(source, destination)
+	options := copyOptionValues(config)
+	for key, values := range vals {
+		options[key] = values
+	}
+	if err := v.parseCopyOptions(ctx, &config, options); err != nil {
+		return config, echo.NewHTTPError(400, err.Error())
+	}
+
+	return config, nil
+}
+
+func (v Views) calendarStub(ctx context.Context, calendarID string) templates.CalendarStub {
+	info, err := v.ctr.Provider.GetCalendar(ctx, calendarID)
+	if err != nil {
+		logs.GetLogger(ctx).Warn().Err(err).Str("calendar-id", calendarID).Msg("failed to get calendar")
+		return templates.CalendarStub{ID: calendarID, Label: calendarID}
+	}
+
+	return templates.CalendarStub{ID: info.ID, Label: info.Summary, AccessRole: info.AccessRole}
+}
@@ -27,11 +27,8 @@ func (v Views) SyncCopy(c echo.Context, vals url.Values) error {
 		return errors.Wrap(err, "failed to retrieve copy row")
 	}
 
-	args := workflows.CopyCalendarWorkflowArgs{
-		SourceCalendarID:      config.SourceID,
-		DestinationCalendarID: config.DestinationID,
-	}
-	if err := v.workflows.CopyCalendarWorkflow(ctx, args); err != nil {
+	args := workflows.CopyCalendarWorkflowArgsFromConfig(config)
+	if _, err := v.workflows.CopyCalendarWorkflow(ctx, args); err != nil {
 		return errors.Wrap(err, "failed to execute workflow")
 	}
 
@@ -55,10 +52,7 @@ func (v Views) SyncInvite(c echo.Context, vals url.Values) error {
 		return errors.Wrap(err, "failed to retrieve invite row")
 	}
 
-	args := workflows.InviteCalendarWorkflowArgs{
-		CalendarID: config.CalendarID,
-		EmailToAdd: config.EmailAddress,
-	}
+	args := workflows.InviteCalendarWorkflowArgsFromConfig(config)
 	if err := v.workflows.InviteCalendarWorkflow(ctx, args); err != nil {
 		return errors.Wrap(err, "failed to execute workflow")
 	}
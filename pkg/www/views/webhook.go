@@ -1,10 +1,10 @@
 package views
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
 	"calendar-sync/pkg/logs"
@@ -26,12 +26,10 @@ func (v Views) Webhook(c echo.Context) error {
 		ChannelToken:  reqHeaders.Get("X-Goog-Channel-Token"),
 	}
 
-	go v.background(c, func(ctx context.Context) {
-		if err := v.workflows.ProcessWebhookEvent(ctx, args); err != nil {
-			logger := logs.GetLogger(ctx)
-			logger.Error().Str("channel-id", args.ChannelID).Err(err).Msg("failed to process webhook event")
-		}
-	})
+	// google retries notifications which aren't acknowledged, so failing to queue one isn't fatal
+	if err := v.workflows.EnqueueWebhookEvent(req.Context(), args); err != nil {
+		return errors.Wrap(err, "failed to queue webhook event")
+	}
 
 	c.Response().WriteHeader(200)
 	return nil